@@ -1,7 +1,12 @@
 package constants
 
 import (
+	"os"
+	"reflect"
+	"time"
+
 	"github.com/caiflower/common-tools/global/config"
+	"github.com/caiflower/common-tools/pkg/tools"
 )
 
 var DefaultConfig = config.DefaultConfig{}
@@ -14,18 +19,153 @@ func InitConfig() {
 	if err := config.LoadYamlFile("config.yaml", &Prop); err != nil {
 		panic(err)
 	}
+	if err := tools.DoTagFunc(&Prop, nil, []func(reflect.StructField, reflect.Value, interface{}) error{tools.SetDefaultValueIfNil}); err != nil {
+		panic(err)
+	}
 }
 
 type Config struct {
-	Prompt PromptConfig `yaml:"prompt"`
-	OLlama OLlamaConfig `yaml:"ollama"`
+	Prompt        PromptConfig         `yaml:"prompt"`
+	OLlama        OLlamaConfig         `yaml:"ollama"`
+	Agent         AgentConfig          `yaml:"agent"`
+	ModelProfiles []ModelProfileConfig `yaml:"modelProfiles"`
+	Admin         AdminConfig          `yaml:"admin"`
+	Suggest       SuggestConfig        `yaml:"suggest"`
+	Checkpoint    CheckpointConfig     `yaml:"checkpoint"`
+	Flow          FlowConfig           `yaml:"flow"`
+	Tool          ToolConfig           `yaml:"tool"`
+	ToolPolicy    ToolPolicyConfig     `yaml:"toolPolicy"`
+	OpenAI        OpenAIConfig         `yaml:"openai"`
+	SSE           SSEConfig            `yaml:"sse"`
+	File          FileConfig           `yaml:"file"`
 }
 
 type PromptConfig struct {
 	AgentName string `yaml:"agentName" default:"全能助手"`
+	Persona   string `yaml:"persona"`
 }
 
 type OLlamaConfig struct {
-	Url   string `yaml:"url"`
-	Model string `yaml:"model"`
+	Url    string `yaml:"url"`
+	Model  string `yaml:"model"`
+	Vision bool   `yaml:"vision"` // 模型是否支持图片输入
+}
+
+type AgentConfig struct {
+	MaxRunSteps int `yaml:"maxRunSteps" default:"20"` // 单次运行图的最大步数
+	MaxHandoffs int `yaml:"maxHandoffs" default:"3"`  // 监督者模式下一轮对话最多交给几次专家
+	MaxRepairs  int `yaml:"maxRepairs" default:"2"`   // 回答不符合指定的JSON Schema时最多请求模型修正几次
+}
+
+type AdminConfig struct {
+	Users []string `yaml:"users"` // 管理员用户，可以修改智能体定义、提示词模板等全局配置
+}
+
+// SuggestConfig 追问建议
+type SuggestConfig struct {
+	Enable       bool          `yaml:"enable"`               // 内置智能体是否生成追问建议
+	ModelProfile string        `yaml:"modelProfile"`         // 生成建议使用的模型配置，为空时使用回答的模型
+	Timeout      time.Duration `yaml:"timeout" default:"5s"` // 超时后放弃生成，不影响回答结束
+}
+
+// CheckpointConfig 运行状态在节点边界保存，用于中断后恢复和实例重启后恢复
+type CheckpointConfig struct {
+	TTL      time.Duration `yaml:"ttl" default:"30m"`             // 超过TTL未恢复的运行无法再继续
+	Store    string        `yaml:"store" default:"file"`          // memory, file, db
+	Dir      string        `yaml:"dir" default:"data/checkpoint"` // store为file时的存储目录
+	Instance string        `yaml:"instance"`                      // 实例名称，重启后恢复该实例未完成的运行，为空时使用主机名
+}
+
+// FlowConfig 声明式的图定义，目录下的yaml和json文件修改后自动重新加载
+type FlowConfig struct {
+	Dir            string        `yaml:"dir"`                          // 为空时使用配置目录下的flows
+	ReloadInterval time.Duration `yaml:"reloadInterval" default:"10s"` // 检查文件变化的间隔
+}
+
+// ToolConfig 工具执行，模型一次调用多个工具时并发执行
+type ToolConfig struct {
+	MaxParallel int                      `yaml:"maxParallel" default:"4"` // 一次运行中同时执行的工具数
+	Timeout     time.Duration            `yaml:"timeout" default:"30s"`   // 单个工具的超时时间
+	Timeouts    map[string]time.Duration `yaml:"timeouts"`                // 按工具名称覆盖超时时间
+}
+
+// ToolPolicyConfig 工具调用的审批策略，按顺序匹配规则，没有匹配的规则时使用Default
+type ToolPolicyConfig struct {
+	Default string              `yaml:"default" default:"allow"` // allow, deny, approve
+	Risks   map[string]string   `yaml:"risks"`                   // 工具的风险等级，工具Info.Extra中的risk优先
+	Groups  map[string][]string `yaml:"groups"`                  // 用户组，key为组名
+	Rules   []ToolPolicyRule    `yaml:"rules"`
+}
+
+// ToolPolicyRule 条件为空时不限制，同一条件中的多个值满足任意一个即可
+type ToolPolicyRule struct {
+	Name      string            `yaml:"name"`
+	Tools     []string          `yaml:"tools"`
+	Risks     []string          `yaml:"risks"`
+	Users     []string          `yaml:"users"`
+	Groups    []string          `yaml:"groups"`
+	Arguments map[string]string `yaml:"arguments"` // 参数名称到正则表达式，所有参数都匹配时满足
+	Action    string            `yaml:"action"`    // allow, deny, approve
+	Message   string            `yaml:"message"`   // 拒绝时返回给模型的原因
+}
+
+// OpenAIConfig OpenAI兼容的/v1/chat/completions接口，请求中的model为智能体ID
+type OpenAIConfig struct {
+	ChatProtocol string `yaml:"chatProtocol" default:"ollama"` // 智能体未指定模型配置时使用的协议
+}
+
+// SSEConfig 事件推送，客户端断线后通过Last-Event-ID或请求ID回放窗口内的事件
+type SSEConfig struct {
+	ReplayWindow time.Duration `yaml:"replayWindow" default:"2m"`    // 事件保留的时间
+	ReplayStore  string        `yaml:"replayStore" default:"memory"` // memory, redis, db，多实例部署时使用redis或db
+	PollInterval time.Duration `yaml:"pollInterval" default:"500ms"` // 运行在其他实例上时读取共享存储的间隔
+	Heartbeat    time.Duration `yaml:"heartbeat" default:"15s"`      // 没有事件时发送注释的间隔，避免代理断开空闲的连接
+	MaxIdle      time.Duration `yaml:"maxIdle" default:"3m"`         // 运行超过该时间没有产生事件时推送超时错误并取消运行
+	WriteTimeout time.Duration `yaml:"writeTimeout" default:"10s"`   // 每次写入的超时时间，推送的总时长不受HTTP服务WriteTimeout的限制
+}
+
+// FileConfig 上传的文件保存在本地目录，对话请求通过文件ID引用
+type FileConfig struct {
+	Dir           string        `yaml:"dir" default:"data/files"`
+	MaxSize       int64         `yaml:"maxSize" default:"10485760"`    // 单个文件的最大字节数
+	ContentTypes  []string      `yaml:"contentTypes"`                  // 允许上传的类型，按内容识别，为空时允许常见的图片和纯文本
+	MaxTextLength int           `yaml:"maxTextLength" default:"20000"` // 模型不支持图片等附件时提取文本，每个文件保留的最大字符数
+	TTL           time.Duration `yaml:"ttl" default:"24h"`             // 文件保留的时长，过期后无法再引用并被清理
+}
+
+// ModelProfileConfig 模型配置，智能体定义通过Name引用
+type ModelProfileConfig struct {
+	Name     string        `yaml:"name"`
+	Protocol string        `yaml:"protocol"`
+	Url      string        `yaml:"url"`
+	Model    string        `yaml:"model"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Vision   bool          `yaml:"vision"` // 模型是否支持图片输入，不支持时只发送附件中提取的文本
+}
+
+func GetModelProfile(name string) (*ModelProfileConfig, bool) {
+	for i := range Prop.ModelProfiles {
+		if Prop.ModelProfiles[i].Name == name {
+			return &Prop.ModelProfiles[i], true
+		}
+	}
+	return nil, false
+}
+
+func IsAdmin(user string) bool {
+	for _, u := range Prop.Admin.Users {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// InstanceName 当前实例的名称，未配置时使用主机名
+func InstanceName() string {
+	if Prop.Checkpoint.Instance != "" {
+		return Prop.Checkpoint.Instance
+	}
+	hostname, _ := os.Hostname()
+	return hostname
 }
@@ -7,3 +7,8 @@ import (
 )
 
 var NotLoginError = &e.ErrorCode{Code: http.StatusUnauthorized, Type: "NotLogin"}
+var ForbiddenError = &e.ErrorCode{Code: http.StatusForbidden, Type: "Forbidden"}
+var TimeoutError = &e.ErrorCode{Code: http.StatusGatewayTimeout, Type: "Timeout"}
+var PayloadTooLargeError = &e.ErrorCode{Code: http.StatusRequestEntityTooLarge, Type: "PayloadTooLarge"}
+var InvalidModelOutputError = &e.ErrorCode{Code: http.StatusBadGateway, Type: "InvalidModelOutput"}
+var ConflictError = &e.ErrorCode{Code: http.StatusConflict, Type: "Conflict"}
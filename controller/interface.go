@@ -10,6 +10,50 @@ type HealthController interface {
 }
 
 type AgentController interface {
-	Chat(request *apiv1.ChatRequest) (err e.ApiError)
+	Chat(request *apiv1.ChatRequest) (*apiv1.ChatResult, e.ApiError)
+	PostChat(request *apiv1.PostChatRequest) (*apiv1.ChatResult, e.ApiError)
+	ResumeChat(request *apiv1.ResumeChatRequest) (*apiv1.ChatResult, e.ApiError)
+	ChatWs(request *apiv1.ChatWsRequest) (err e.ApiError)
+	CancelChat(request *apiv1.CancelChatRequest) (err e.ApiError)
+	ChatEvents(request *apiv1.ChatEventsRequest) (err e.ApiError)
+	Recover()
 	Close()
 }
+
+// ChatCompletionController OpenAI兼容的对话接口
+type ChatCompletionController interface {
+	CreateChatCompletion(request *apiv1.ChatCompletionRequest) (err e.ApiError)
+}
+
+type AgentDefinitionController interface {
+	CreateAgent(request *apiv1.CreateAgentRequest) (*apiv1.AgentDefinition, e.ApiError)
+	UpdateAgent(request *apiv1.UpdateAgentRequest) (*apiv1.AgentDefinition, e.ApiError)
+	DescribeAgent(request *apiv1.DescribeAgentRequest) (*apiv1.AgentDefinition, e.ApiError)
+	DeleteAgent(request *apiv1.DeleteAgentRequest) e.ApiError
+	ListAgents(request *apiv1.ListAgentsRequest) ([]*apiv1.AgentDefinition, e.ApiError)
+}
+
+type PromptTemplateController interface {
+	CreatePromptTemplate(request *apiv1.CreatePromptTemplateRequest) (*apiv1.PromptTemplate, e.ApiError)
+	DescribePromptTemplate(request *apiv1.DescribePromptTemplateRequest) (*apiv1.PromptTemplate, e.ApiError)
+	ListPromptTemplateVersions(request *apiv1.ListPromptTemplateVersionsRequest) ([]*apiv1.PromptTemplate, e.ApiError)
+	RollbackPromptTemplate(request *apiv1.RollbackPromptTemplateRequest) (*apiv1.PromptTemplate, e.ApiError)
+}
+
+type ExperimentController interface {
+	CreateExperiment(request *apiv1.CreateExperimentRequest) (*apiv1.Experiment, e.ApiError)
+	DescribeExperiment(request *apiv1.DescribeExperimentRequest) (*apiv1.Experiment, e.ApiError)
+	ListExperiments(request *apiv1.ListExperimentsRequest) ([]*apiv1.Experiment, e.ApiError)
+	StopExperiment(request *apiv1.StopExperimentRequest) e.ApiError
+	DescribeExperimentReport(request *apiv1.DescribeExperimentReportRequest) ([]*apiv1.ExperimentVariantReport, e.ApiError)
+}
+
+type FeedbackController interface {
+	SubmitFeedback(request *apiv1.SubmitFeedbackRequest) e.ApiError
+	ExportFeedback(request *apiv1.ExportFeedbackRequest) ([]*apiv1.FeedbackRecord, e.ApiError)
+}
+
+// FileController 上传对话中引用的文件
+type FileController interface {
+	UploadFile(request *apiv1.UploadFileRequest) (*apiv1.File, e.ApiError)
+}
@@ -2,30 +2,58 @@ package v1
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/caiflower/ai-agent/constants"
 	"github.com/caiflower/ai-agent/controller"
+	"github.com/caiflower/ai-agent/model/api"
 	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/bean"
 	entity "github.com/caiflower/ai-agent/model/entity"
 	"github.com/caiflower/ai-agent/service/agent"
+	"github.com/caiflower/ai-agent/service/definition"
+	"github.com/caiflower/ai-agent/service/file"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/xsse"
 	golocalv1 "github.com/caiflower/common-tools/pkg/golocal/v1"
 	"github.com/caiflower/common-tools/pkg/logger"
 	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/caiflower/common-tools/pkg/tools"
 	"github.com/caiflower/common-tools/web"
 	"github.com/caiflower/common-tools/web/e"
 	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
 	"github.com/tmaxmax/go-sse"
 )
 
-const (
-	EventTypeOfChatModelAnswer = "chat.answer"
-	EventTypeOfChatError       = "chat.error"
-	EventTypeOfChatFinish      = "chat.finish"
-)
-
 type agentController struct {
 	SSEProvider  sse.Provider  `autowired:""`
+	ReplayStore  xsse.Store    `autowired:""`
 	AgentRuntime agent.Runtime `autowired:""`
+	FileManager  file.Manager  `autowired:""`
+	streams      sync.Map      // 本实例正在推送事件的运行，key为runID
+}
+
+// chatStream 正在推送事件的运行，事件发布到以runID为名的topic，同时保存到回放存储
+type chatStream struct {
+	runID     string
+	requestID string
+	user      string
+	agentID   string          // 事件没有指定智能体时使用运行的智能体
+	start     *sse.Message    // 第一个事件，重连时从这里开始回放
+	seq       int64           // 最后一个事件的序号
+	ctx       context.Context // 推送结束后取消
+	cancel    context.CancelFunc
+	lock      sync.Mutex
+	done      bool        // 已经发布结束事件，之后的事件不再发布
+	clients   int         // 正在订阅的客户端数量
+	orphan    *time.Timer // 没有客户端订阅时开始计时，回放窗口内没有客户端重连时取消运行
 }
 
 func NewAgentController() controller.AgentController {
@@ -42,76 +70,659 @@ func (c *agentController) Close() {
 	}
 }
 
-func (c *agentController) Chat(request *apiv1.ChatRequest) e.ApiError {
-	var (
-		topics = []string{request.RequestID}
-	)
+// Recover 继续本实例上次退出时未完成的运行，客户端通过Last-Event-ID重连后接收事件
+func (c *agentController) Recover() {
+	runs, err := c.AgentRuntime.Recover()
+	if err != nil {
+		logger.Error("recover agent runs failed. Error: %v", err)
+		return
+	}
 
-	sr, err := c.AgentRuntime.Run(&entity.AgentRequest{
+	for _, run := range runs {
+		stream, err := c.startStream(context.Background(), &api.Request{RequestID: run.RequestID, User: run.User}, apiv1.EventTypeOfChatRecovered, run.Events, false)
+		if err != nil {
+			logger.Error("start recovered chat stream failed. RunID: %s, Error: %v", run.RunID, err)
+			continue
+		}
+		c.watchOrphan(stream)
+	}
+}
+
+func (c *agentController) Chat(request *apiv1.ChatRequest) (*apiv1.ChatResult, e.ApiError) {
+	// EventSource断线重连时携带Last-Event-ID，不再开始新的运行
+	_, r := request.Context.GetResponseWriterAndRequest()
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		return nil, c.reconnect(&request.Request, lastEventID, &request.Context)
+	}
+
+	return c.startChat(&entity.AgentRequest{
+		RequestID:    request.RequestID,
+		User:         request.User,
+		AgentID:      request.AgentID,
 		Input:        schema.UserMessage(request.Input),
 		ChatProtocol: request.ChatProtocol,
-	})
+		Reasoning:    request.Reasoning,
+	}, *request.Stream, &request.Context)
+}
+
+// PostChat 请求体携带历史消息和模型参数，推送的事件与Chat相同
+func (c *agentController) PostChat(request *apiv1.PostChatRequest) (*apiv1.ChatResult, e.ApiError) {
+	_, r := request.Context.GetResponseWriterAndRequest()
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		return nil, c.reconnect(&request.Request, lastEventID, &request.Context)
+	}
+
+	agentReq, apiErr := convertPostChatRequest(request)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if agentReq.Attachments, apiErr = loadAttachments(c.FileManager, request.User, request.Files, "PostChatRequest.Files"); apiErr != nil {
+		return nil, apiErr
+	}
+	return c.startChat(agentReq, *request.Stream, &request.Context)
+}
+
+func (c *agentController) startChat(agentReq *entity.AgentRequest, stream bool, webCtx *web.Context) (*apiv1.ChatResult, e.ApiError) {
+	sr, apiErr := c.run(runContext(stream), agentReq)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if !stream {
+		return collectChat(sr, agentReq.Reasoning)
+	}
+	return nil, c.streamChat(sr, &api.Request{RequestID: agentReq.RequestID, User: agentReq.User}, agentReq.Reasoning, webCtx)
+}
+
+func (c *agentController) ResumeChat(request *apiv1.ResumeChatRequest) (*apiv1.ChatResult, e.ApiError) {
+	resumeReq, apiErr := convertResumeChatRequest(request)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	sr, apiErr := c.resume(runContext(*request.Stream), resumeReq)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if !*request.Stream {
+		return collectChat(sr, request.Reasoning)
+	}
+	return nil, c.streamChat(sr, &request.Request, request.Reasoning, &request.Context)
+}
+
+// CancelChat 中止请求ID对应的运行，事件流中推送chat.cancelled，已经生成的回答仍然记录
+func (c *agentController) CancelChat(request *apiv1.CancelChatRequest) e.ApiError {
+	if err := c.AgentRuntime.Cancel(request.TargetRequestID, request.User); err != nil {
+		if errors.Is(err, agent.ErrChatNotRunning) {
+			return e.NewApiError(e.NotFound, err.Error(), err)
+		}
+		return e.NewInternalError(err)
+	}
+	return nil
+}
+
+// runContext 流式推送时客户端可以通过Last-Event-ID重连，断开连接不取消运行，回放窗口内没有客户端重连时再取消
+func runContext(stream bool) context.Context {
+	if stream {
+		return context.WithoutCancel(golocalv1.GetContext())
+	}
+	return golocalv1.GetContext()
+}
+
+func (c *agentController) run(ctx context.Context, agentReq *entity.AgentRequest) (*schema.StreamReader[*apiv1.ChatEvent], e.ApiError) {
+	sr, err := c.AgentRuntime.Run(ctx, agentReq)
 	if err != nil {
 		logger.Error("agent run failed. Error: %v", err)
+		switch {
+		case errors.Is(err, definition.ErrAgentNotFound):
+			return nil, e.NewApiError(e.NotFound, err.Error(), err)
+		case errors.Is(err, agent.ErrImageAttachmentsUnsupported):
+			return nil, e.NewApiError(e.InvalidArgument, err.Error(), err)
+		}
+		return nil, e.NewInternalError(err)
+	}
+	return sr, nil
+}
+
+func (c *agentController) resume(ctx context.Context, resumeReq *entity.ResumeRequest) (*schema.StreamReader[*apiv1.ChatEvent], e.ApiError) {
+	sr, err := c.AgentRuntime.Resume(ctx, resumeReq)
+	if err != nil {
+		logger.Error("agent resume failed. Error: %v", err)
+		switch {
+		case errors.Is(err, agent.ErrRunNotFound), errors.Is(err, definition.ErrAgentNotFound):
+			return nil, e.NewApiError(e.NotFound, err.Error(), err)
+		case errors.Is(err, agent.ErrRunNotInterrupted), errors.Is(err, agent.ErrCheckpointExpired):
+			return nil, e.NewApiError(e.NotAcceptable, err.Error(), err)
+		default:
+			return nil, e.NewInternalError(err)
+		}
+	}
+	return sr, nil
+}
+
+// collectChat 读取运行的全部事件后返回完整结果，运行失败时返回错误而不是chat.error事件
+func collectChat(sr *schema.StreamReader[*apiv1.ChatEvent], reasoning entity.ReasoningMode) (*apiv1.ChatResult, e.ApiError) {
+	defer sr.Close()
+	var (
+		result        = &apiv1.ChatResult{}
+		answer        strings.Builder
+		reasoningText strings.Builder
+		toolCalls     = map[string]*apiv1.ChatToolCall{}
+		usage         *apiv1.ChatUsage
+	)
+	for {
+		event, err := sr.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			logger.Error("chat receive failed. Error: %v", err)
+			if errors.Is(err, agent.ErrStructuredOutputInvalid) {
+				return nil, e.NewApiError(constants.InvalidModelOutputError, err.Error(), err)
+			}
+			return nil, e.NewInternalError(err)
+		}
+
+		switch event.EventType {
+		case entity.EventTypeOfRunInfo:
+			result.MessageID = event.RunInfo.RunID
+			result.Experiment = event.RunInfo.Experiment
+			result.Variant = event.RunInfo.Variant
+		case entity.EventTypeOfChatModelAnswer, entity.EventTypeOfToolsAsChatModelStream:
+			var chunks []*schema.Message
+			for {
+				message, recvErr := event.ChatModelAnswer.Recv()
+				if recvErr != nil {
+					// 回答中断时运行随后发送取消事件或错误
+					if recvErr != io.EOF {
+						logger.Warn("chat model answer interrupted. Error: %v", recvErr)
+					}
+					break
+				}
+				reasoningText.WriteString(message.ReasoningContent)
+				answer.WriteString(message.Content)
+				if len(message.ToolCalls) > 0 {
+					chunks = append(chunks, &schema.Message{Role: schema.Assistant, ToolCalls: message.ToolCalls})
+				}
+			}
+			if len(chunks) == 0 {
+				continue
+			}
+			// 流式输出的工具调用需要合并后才是完整的参数
+			message, concatErr := schema.ConcatMessages(chunks)
+			if concatErr != nil {
+				return nil, e.NewInternalError(concatErr)
+			}
+			for _, call := range message.ToolCalls {
+				toolCall := &apiv1.ChatToolCall{ToolCallID: call.ID, ToolName: call.Function.Name, Arguments: call.Function.Arguments}
+				toolCalls[call.ID] = toolCall
+				result.ToolCalls = append(result.ToolCalls, toolCall)
+			}
+		case entity.EventTypeOfToolsMessage:
+			for _, message := range event.ToolsMessage {
+				if toolCall, ok := toolCalls[message.ToolCallID]; ok {
+					toolCall.Result = message.Content
+				}
+			}
+		case entity.EventTypeOfSuggest:
+			result.Suggestions = event.Suggestions
+		case entity.EventTypeOfStructured:
+			result.Structured = event.Structured
+		case entity.EventTypeOfInterrupt:
+			result.Interrupt = convertInterrupt(event.Interrupt)
+		case entity.EventTypeOfCancelled:
+			result.Cancelled = true
+		case entity.EventTypeOfUsage:
+			// 监督者模式下每个专家发送一次
+			if usage == nil {
+				usage = &apiv1.ChatUsage{}
+			}
+			usage.PromptTokens += event.Usage.PromptTokens
+			usage.CompletionTokens += event.Usage.CompletionTokens
+		}
+	}
+
+	result.Answer = answer.String()
+	if reasoning == "" || reasoning == entity.ReasoningModeShow {
+		result.Reasoning = reasoningText.String()
+	}
+	result.Usage = usage
+	return result, nil
+}
+
+// streamChat 将智能体的事件转换为SSE消息推送给客户端
+func (c *agentController) streamChat(sr *schema.StreamReader[*apiv1.ChatEvent], request *api.Request, reasoning entity.ReasoningMode, webCtx *web.Context) e.ApiError {
+	stream, err := c.startStream(golocalv1.GetContext(), request, apiv1.EventTypeOfChatStart, sr, reasoning == "" || reasoning == entity.ReasoningModeShow)
+	if err != nil {
 		return e.NewInternalError(err)
 	}
 
-	ctx, cancel := context.WithCancel(golocalv1.GetContext())
+	// 先发送开始事件，再订阅并回放开始事件之后的事件，订阅前已经发布的事件不会丢失
+	sseErr := c.subscribe(stream, request.RequestID, stream.start.ID, []*sse.Message{stream.start}, webCtx)
+	if sseErr != nil {
+		return e.NewInternalError(sseErr)
+	}
+
+	return nil
+}
+
+// startStream 第一个事件为运行信息，之后的事件在后台发布，parent结束或推送结束后订阅退出，
+// 运行超过maxIdle没有产生事件时推送超时错误并取消运行
+func (c *agentController) startStream(parent context.Context, request *api.Request, startType string, sr *schema.StreamReader[*apiv1.ChatEvent], showReasoning bool) (*chatStream, error) {
+	first, err := sr.Recv()
+	if err != nil {
+		logger.Error("chat receive failed. Error: %v", err)
+		return nil, err
+	}
+	if first.EventType != entity.EventTypeOfRunInfo {
+		sr.Close()
+		return nil, fmt.Errorf("unexpected first event: %v", first.EventType)
+	}
+
+	// 恢复运行时从存储中最后一个事件的序号继续，运行之前可能在其他实例上推送
+	runID := first.RunInfo.RunID
+	lastSeq, err := c.ReplayStore.LastSeq(runID)
+	if err != nil {
+		logger.Warn("get last chat event seq failed. RunID: %s, Error: %v", runID, err)
+	}
+
+	var (
+		ctx, cancel = context.WithCancel(parent)
+		stream      = &chatStream{runID: runID, requestID: request.RequestID, user: request.User, agentID: first.RunInfo.AgentID, seq: lastSeq, ctx: ctx, cancel: cancel}
+		finish      = &apiv1.ChatEventData{FinishReason: apiv1.FinishReasonOfStop, Experiment: first.RunInfo.Experiment, Variant: first.RunInfo.Variant}
+	)
+	stream.start = c.publish(stream, startType, &apiv1.ChatEventData{Experiment: first.RunInfo.Experiment, Variant: first.RunInfo.Variant})
+	c.streams.Store(runID, stream)
+
 	safego.Go(func() {
 		defer cancel()
+		defer c.streams.CompareAndDelete(runID, stream)
+		idle := c.watchIdle(stream)
+		defer idle.stop()
 		for {
 			chatEventRecv, recvErr := sr.Recv()
+			idle.reset()
 			if recvErr != nil {
 				if recvErr == io.EOF {
-					_ = c.SSEProvider.Publish(buildChatMessage(EventTypeOfChatFinish, "finish"), topics)
+					c.publish(stream, apiv1.EventTypeOfChatFinish, finish)
 					break
 				}
-				_ = c.SSEProvider.Publish(buildChatMessage(EventTypeOfChatError, "chat failed"), topics)
+				c.publish(stream, apiv1.EventTypeOfChatError, streamErrorData(recvErr))
 				logger.Error("chat receive failed. Error: %v", recvErr)
 				return
 			}
 
+			data := &apiv1.ChatEventData{Agent: chatEventRecv.AgentID, Node: chatEventRecv.Node}
 			switch chatEventRecv.EventType {
-			case entity.EventTypeOfChatModelAnswer:
+			case entity.EventTypeOfChatModelAnswer, entity.EventTypeOfToolsAsChatModelStream:
 				for {
 					message, recvErr := chatEventRecv.ChatModelAnswer.Recv()
+					idle.reset()
 					if recvErr != nil {
-						if recvErr == io.EOF {
-							break
+						// 回答中断时由运行随后的事件推送chat.cancelled或chat.error
+						if recvErr != io.EOF {
+							logger.Warn("chat model answer interrupted. Error: %v", recvErr)
 						}
-						_ = c.SSEProvider.Publish(buildChatMessage(EventTypeOfChatError, "chat failed"), topics)
-						logger.Error("chat receive failed. Error: %v", recvErr)
-						return
+						break
+					}
+					if message.ReasoningContent != "" && showReasoning {
+						c.publish(stream, apiv1.EventTypeOfChatReasoning, &apiv1.ChatEventData{Agent: data.Agent, Node: data.Node, Delta: message.ReasoningContent})
 					}
 					if message.Content != "" {
-						_ = c.SSEProvider.Publish(buildChatAnswerMessage(message), topics)
+						c.publish(stream, apiv1.EventTypeOfChatModelAnswer, &apiv1.ChatEventData{Agent: data.Agent, Node: data.Node, Delta: message.Content})
 					}
 				}
+			case entity.EventTypeOfSuggest:
+				data.Suggestions = chatEventRecv.Suggestions
+				c.publish(stream, apiv1.EventTypeOfChatSuggest, data)
+			case entity.EventTypeOfStructured:
+				data.Structured = chatEventRecv.Structured
+				c.publish(stream, apiv1.EventTypeOfChatStructured, data)
+			case entity.EventTypeOfInterrupt:
+				data.Interrupt = convertInterrupt(chatEventRecv.Interrupt)
+				finish.FinishReason = apiv1.FinishReasonOfInterrupt
+				c.publish(stream, apiv1.EventTypeOfChatInterrupt, data)
+			case entity.EventTypeOfCancelled:
+				finish.FinishReason = apiv1.FinishReasonOfCancelled
+				c.publish(stream, apiv1.EventTypeOfChatCancelled, data)
+			case entity.EventTypeOfPlan:
+				data.Plan = &apiv1.ChatPlan{Steps: chatEventRecv.Plan.Steps, Completed: chatEventRecv.Plan.Completed}
+				c.publish(stream, apiv1.EventTypeOfChatPlan, data)
+			case entity.EventTypeOfToolMidAnswer:
+				data.Tool = &apiv1.ChatTool{
+					ToolCallID: chatEventRecv.ToolProgress.ToolCallID,
+					ToolName:   chatEventRecv.ToolProgress.ToolName,
+					Content:    chatEventRecv.ToolProgress.Content,
+				}
+				c.publish(stream, apiv1.EventTypeOfChatToolProgress, data)
+			case entity.EventTypeOfToolsMessage:
+				for _, message := range chatEventRecv.ToolsMessage {
+					c.publish(stream, apiv1.EventTypeOfChatToolResult, &apiv1.ChatEventData{
+						Agent: data.Agent,
+						Node:  data.Node,
+						Tool:  &apiv1.ChatTool{ToolCallID: message.ToolCallID, ToolName: message.ToolName, Content: message.Content},
+					})
+				}
+			case entity.EventTypeOfHandoff:
+				data.Handoff = &apiv1.ChatAgent{
+					AgentID:   chatEventRecv.Handoff.AgentID,
+					Name:      chatEventRecv.Handoff.Name,
+					MessageID: chatEventRecv.Handoff.RunID,
+				}
+				c.publish(stream, apiv1.EventTypeOfChatAgent, data)
+			case entity.EventTypeOfUsage:
+				// 监督者模式下每个专家发送一次，合计后在chat.finish中推送
+				if finish.Usage == nil {
+					finish.Usage = &apiv1.ChatUsage{}
+				}
+				finish.Usage.PromptTokens += chatEventRecv.Usage.PromptTokens
+				finish.Usage.CompletionTokens += chatEventRecv.Usage.CompletionTokens
 			default:
 				logger.Warn("chat receive unknown event: %v", chatEventRecv.EventType)
 			}
 		}
 	})
 
-	sseErr := c.beginSse(ctx, topics, &request.Context)
-	if sseErr != nil {
-		return e.NewInternalError(sseErr)
+	return stream, nil
+}
+
+// idleTimer maxIdle不大于0时不限制
+type idleTimer struct {
+	timer   *time.Timer
+	maxIdle time.Duration
+}
+
+func (t *idleTimer) reset() {
+	if t.timer != nil {
+		t.timer.Reset(t.maxIdle)
+	}
+}
+
+func (t *idleTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// watchIdle 超时后推送chat.error并结束订阅，同时取消运行，取消后运行产生的事件不再发布
+func (c *agentController) watchIdle(stream *chatStream) *idleTimer {
+	t := &idleTimer{maxIdle: constants.Prop.SSE.MaxIdle}
+	if t.maxIdle <= 0 {
+		return t
+	}
+	t.timer = time.AfterFunc(t.maxIdle, func() {
+		if c.publish(stream, apiv1.EventTypeOfChatError, chatErrorData(constants.TimeoutError, "chat is idle for too long")) == nil {
+			return
+		}
+		logger.Warn("chat is idle for too long. RunID: %s", stream.runID)
+		if err := c.AgentRuntime.Cancel(stream.requestID, stream.user); err != nil {
+			logger.Warn("cancel idle chat failed. RunID: %s, Error: %v", stream.runID, err)
+		}
+		stream.cancel()
+	})
+	return t
+}
+
+// subscribe 推送本实例正在推送的运行，最后一个客户端断开后开始计时
+func (c *agentController) subscribe(stream *chatStream, requestID string, lastEventID sse.EventID, preface []*sse.Message, webCtx *web.Context) error {
+	c.attach(stream)
+	defer c.detach(stream)
+	return c.beginSse(stream.ctx, requestID, []string{stream.runID}, lastEventID, preface, webCtx)
+}
+
+// attach 客户端开始订阅，停止计时
+func (c *agentController) attach(stream *chatStream) {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	stream.clients++
+	if stream.orphan != nil {
+		stream.orphan.Stop()
+		stream.orphan = nil
+	}
+}
+
+func (c *agentController) detach(stream *chatStream) {
+	stream.lock.Lock()
+	stream.clients--
+	stream.lock.Unlock()
+	c.watchOrphan(stream)
+}
+
+// watchOrphan 没有客户端订阅时，超过回放窗口仍没有客户端重连则取消运行，之后已经无法回放全部事件。
+// 共享存储时客户端可能在其他实例上读取事件，本实例无法感知，不取消
+func (c *agentController) watchOrphan(stream *chatStream) {
+	window := constants.Prop.SSE.ReplayWindow
+	if window <= 0 || xsse.Shared() {
+		return
+	}
+
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if stream.clients > 0 || stream.done || stream.orphan != nil {
+		return
+	}
+	stream.orphan = time.AfterFunc(window, func() {
+		stream.lock.Lock()
+		orphaned := stream.clients == 0 && !stream.done
+		stream.orphan = nil
+		stream.lock.Unlock()
+		if !orphaned {
+			return
+		}
+		logger.Warn("no client subscribes the chat for too long. RunID: %s", stream.runID)
+		if err := c.AgentRuntime.Cancel(stream.requestID, stream.user); err != nil {
+			logger.Warn("cancel orphan chat failed. RunID: %s, Error: %v", stream.runID, err)
+		}
+	})
+}
+
+// reconnect 客户端携带Last-Event-ID重连，从断开的位置继续推送
+func (c *agentController) reconnect(request *api.Request, lastEventID string, webCtx *web.Context) e.ApiError {
+	runID, seq, ok := xsse.ParseEventID(lastEventID)
+	if !ok {
+		return e.NewApiError(e.InvalidArgument, "Last-Event-ID is invalid", nil)
+	}
+	return c.replay(request, runID, seq, webCtx)
+}
+
+// ChatEvents 按请求ID重新接收事件，携带Last-Event-ID时从断开的位置继续，否则从请求的第一个事件开始
+func (c *agentController) ChatEvents(request *apiv1.ChatEventsRequest) e.ApiError {
+	startID, err := c.ReplayStore.Lookup(request.TargetRequestID)
+	if err != nil {
+		return e.NewInternalError(err)
+	}
+	runID, startSeq, ok := xsse.ParseEventID(startID)
+	if !ok {
+		return e.NewApiError(e.NotFound, "chat events not found", nil)
+	}
+
+	seq := startSeq - 1
+	lastEventID := request.LastEventID
+	_, r := request.Context.GetResponseWriterAndRequest()
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		lastEventID = id
+	}
+	if lastEventID != "" {
+		lastRunID, lastSeq, ok := xsse.ParseEventID(lastEventID)
+		if !ok || lastRunID != runID {
+			return e.NewApiError(e.InvalidArgument, "Last-Event-ID is invalid", nil)
+		}
+		seq = max(seq, lastSeq)
+	}
+	return c.replay(&request.Request, runID, seq, &request.Context)
+}
+
+// replay 推送运行中序号大于seq的事件，运行仍在本实例推送时继续订阅，
+// 在其他实例推送时从共享存储中读取，存储中已经过期的运行使用运行记录中的结果
+func (c *agentController) replay(request *api.Request, runID string, seq int64, webCtx *web.Context) e.ApiError {
+	run, err := c.AgentRuntime.GetRun(runID, request.User)
+	if err != nil {
+		if errors.Is(err, agent.ErrRunNotFound) {
+			return e.NewApiError(e.NotFound, err.Error(), err)
+		}
+		return e.NewInternalError(err)
+	}
+
+	events, err := c.ReplayStore.Range(runID, seq)
+	if err != nil {
+		return e.NewInternalError(err)
+	}
+
+	if v, found := c.streams.Load(runID); found {
+		stream := v.(*chatStream)
+		lastEventID := sse.ID(xsse.FormatEventID(runID, seq))
+		if len(events) > 0 {
+			// 先发送存储中的事件，再从最后一个事件之后订阅，之间发布的事件由订阅回放
+			lastEventID = events[len(events)-1].ID
+		} else if _, startSeq, _ := xsse.ParseEventID(stream.start.ID.String()); seq < startSeq {
+			// 客户端收到的最后一个事件早于本次推送，例如实例重启前的事件，从开始事件回放
+			events, lastEventID = []*sse.Message{stream.start}, stream.start.ID
+		}
+		if err = c.subscribe(stream, request.RequestID, lastEventID, events, webCtx); err != nil {
+			return e.NewInternalError(err)
+		}
+		return nil
 	}
 
+	if finished(events) {
+		err = c.beginSse(context.Background(), request.RequestID, nil, sse.EventID{}, events, webCtx)
+	} else if run.State == bean.RunStateRunning && xsse.Shared() {
+		err = c.follow(golocalv1.GetContext(), request.RequestID, runID, seq, events, webCtx)
+	} else {
+		if n := len(events); n > 0 {
+			_, seq, _ = xsse.ParseEventID(events[n-1].ID.String())
+		}
+		err = c.beginSse(context.Background(), request.RequestID, nil, sse.EventID{}, append(events, runResultEvents(run, seq)...), webCtx)
+	}
+	if err != nil {
+		return e.NewInternalError(err)
+	}
 	return nil
 }
 
-func (c *agentController) beginSse(ctx context.Context, sessionIds []string, webCtx *web.Context) error {
-	logger.Info("beginSse sessionIds %s", sessionIds)
-	w, r := webCtx.GetResponseWriterAndRequest()
-	w.Header().Add("X-Request-Id", sessionIds[0])
-	sess, err := sse.Upgrade(w, r)
+// runResultEvents 根据运行记录生成结束事件，序号接在seq之后
+func runResultEvents(run *bean.AgentRun, seq int64) []*sse.Message {
+	var (
+		events []*sse.Message
+		event  = func(_type string, data *apiv1.ChatEventData) *sse.Message {
+			seq = xsse.NextSeq(seq)
+			return buildChatEvent(run.RunID, seq, _type, data)
+		}
+		finish = &apiv1.ChatEventData{
+			Agent:        run.AgentID,
+			FinishReason: apiv1.FinishReasonOfStop,
+			Usage:        &apiv1.ChatUsage{PromptTokens: run.PromptTokens, CompletionTokens: run.CompletionTokens},
+			Experiment:   run.Experiment,
+			Variant:      run.Variant,
+		}
+	)
+	switch run.State {
+	case bean.RunStateFinished:
+		finish.Answer = run.Answer
+		events = append(events, event(apiv1.EventTypeOfChatFinish, finish))
+	case bean.RunStateInterrupted:
+		var pending []*entity.PendingToolCall
+		_ = json.Unmarshal([]byte(run.Pending), &pending)
+		finish.FinishReason = apiv1.FinishReasonOfInterrupt
+		events = append(events,
+			event(apiv1.EventTypeOfChatInterrupt, &apiv1.ChatEventData{
+				Agent:     run.AgentID,
+				Interrupt: convertInterrupt(&entity.Interrupt{RunID: run.RunID, ToolCalls: pending}),
+			}),
+			event(apiv1.EventTypeOfChatFinish, finish))
+	case bean.RunStateCancelled:
+		finish.FinishReason = apiv1.FinishReasonOfCancelled
+		finish.Answer = run.Answer
+		events = append(events,
+			event(apiv1.EventTypeOfChatCancelled, &apiv1.ChatEventData{Agent: run.AgentID}),
+			event(apiv1.EventTypeOfChatFinish, finish))
+	case bean.RunStateRunning:
+		// 运行在其他实例上且回放存储不共享，或者所在实例退出后还没有恢复
+		events = append(events, event(apiv1.EventTypeOfChatError, chatErrorData(e.NotAcceptable, "chat is running on another instance")))
+	default:
+		events = append(events, event(apiv1.EventTypeOfChatError, chatErrorData(e.Internal, "chat failed")))
+	}
+	return events
+}
+
+// follow 运行在其他实例上推送，每隔pollInterval从共享存储读取新的事件，直到运行结束或客户端断开，
+// 没有新的事件时发送心跳
+func (c *agentController) follow(ctx context.Context, requestID, runID string, seq int64, events []*sse.Message, webCtx *web.Context) error {
+	sess, err := upgradeSse(requestID, webCtx)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(constants.Prop.SSE.PollInterval)
+	defer ticker.Stop()
+	lastSent := time.Now()
+	for {
+		if len(events) == 0 && constants.Prop.SSE.Heartbeat > 0 && time.Since(lastSent) >= constants.Prop.SSE.Heartbeat {
+			events = []*sse.Message{xsse.Heartbeat()}
+		}
+		if len(events) > 0 {
+			lastSent = time.Now()
+			if err = sendSse(sess, events); err != nil {
+				return err
+			}
+		}
+		if finished(events) {
+			return nil
+		}
+		if n := len(events); n > 0 && events[n-1].ID.String() != "" {
+			_, seq, _ = xsse.ParseEventID(events[n-1].ID.String())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if events, err = c.ReplayStore.Range(runID, seq); err != nil {
+			return err
+		}
+	}
+}
+
+// publish 为事件分配ID后发布到运行的topic，并保存到回放存储，结束事件之后的事件不再发布，返回发布的事件，未发布时为nil
+func (c *agentController) publish(stream *chatStream, _type string, data *apiv1.ChatEventData) *sse.Message {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if stream.done {
+		return nil
+	}
+
+	if data.Agent == "" {
+		data.Agent = stream.agentID
+	}
+	stream.seq = xsse.NextSeq(stream.seq)
+	msg := buildChatEvent(stream.runID, stream.seq, _type, data)
+	stream.done = finished([]*sse.Message{msg})
+	if err := c.SSEProvider.Publish(msg, []string{stream.runID}); err != nil {
+		logger.Warn("publish chat event failed. Error: %v", err)
+	}
+	// 先发布再保存，从存储读取后订阅时，存储中的事件一定可以由订阅回放
+	if err := c.ReplayStore.Append(stream.requestID, msg); err != nil {
+		logger.Warn("save chat event failed. Error: %v", err)
+	}
+	return msg
+}
+
+// beginSse 先发送preface中的事件，再订阅topics并回放lastEventID之后的事件，topics为空时只发送preface
+func (c *agentController) beginSse(ctx context.Context, requestID string, topics []string, lastEventID sse.EventID, preface []*sse.Message, webCtx *web.Context) error {
+	logger.Info("beginSse topics %s", topics)
+	sess, err := upgradeSse(requestID, webCtx)
 	if err != nil {
-		logger.Error("upgrade xsse failed. Error: %v", err)
 		return err
 	}
+	if err = sendSse(sess, preface); err != nil {
+		return err
+	}
+	if len(topics) == 0 {
+		return nil
+	}
 
-	sub := sse.Subscription{Client: sess, LastEventID: sess.LastEventID, Topics: sessionIds}
+	sub := sse.Subscription{Client: sess, LastEventID: lastEventID, Topics: topics}
 
 	err = c.SSEProvider.Subscribe(ctx, sub)
 	if err != nil {
@@ -122,20 +733,215 @@ func (c *agentController) beginSse(ctx context.Context, sessionIds []string, web
 	return nil
 }
 
-func buildChatAnswerMessage(message *schema.Message) *sse.Message {
-	msg := &sse.Message{
-		Type: sse.Type(EventTypeOfChatModelAnswer),
+func upgradeSse(requestID string, webCtx *web.Context) (*xsse.Session, error) {
+	w, r := webCtx.GetResponseWriterAndRequest()
+	w.Header().Add("X-Request-Id", requestID)
+	sess, err := xsse.Upgrade(w, r)
+	if err != nil {
+		logger.Error("upgrade xsse failed. Error: %v", err)
+		return nil, err
+	}
+	return sess, nil
+}
+
+func sendSse(sess *xsse.Session, msgs []*sse.Message) error {
+	for _, msg := range msgs {
+		if err := sess.Send(msg); err != nil {
+			logger.Error("xsse send failed. Error: %v", err)
+			return err
+		}
+	}
+	if err := sess.Flush(); err != nil {
+		logger.Error("xsse flush failed. Error: %v", err)
+		return err
 	}
-	msg.AppendData(message.Content)
+	return nil
+}
 
-	return msg
+// finished 事件中是否已经包含结束事件
+func finished(events []*sse.Message) bool {
+	if len(events) == 0 {
+		return false
+	}
+	switch events[len(events)-1].Type.String() {
+	case apiv1.EventTypeOfChatFinish, apiv1.EventTypeOfChatError:
+		return true
+	}
+	return false
 }
 
-func buildChatMessage(_type string, message string) *sse.Message {
+// buildChatEvent 事件ID为runID:seq，data中的序号与ID中的序号相同
+func buildChatEvent(runID string, seq int64, _type string, data *apiv1.ChatEventData) *sse.Message {
+	data.Version = apiv1.ChatEventVersion
+	data.MessageID = runID
+	data.Seq = seq
 	msg := &sse.Message{
+		ID:   sse.ID(xsse.FormatEventID(runID, data.Seq)),
 		Type: sse.Type(_type),
 	}
-	msg.AppendData(message)
+	msg.AppendData(tools.ToJson(data))
 
 	return msg
 }
+
+// chatErrorData chat.error的错误与HTTP接口的错误格式相同
+func chatErrorData(code *e.ErrorCode, message string) *apiv1.ChatEventData {
+	return &apiv1.ChatEventData{
+		FinishReason: apiv1.FinishReasonOfError,
+		Error:        &apiv1.ChatError{Code: code.Code, Type: code.Type, Message: message},
+	}
+}
+
+// streamErrorData 回答不符合JSON Schema时返回校验错误，其他错误不暴露内部信息
+func streamErrorData(err error) *apiv1.ChatEventData {
+	if errors.Is(err, agent.ErrStructuredOutputInvalid) {
+		return chatErrorData(constants.InvalidModelOutputError, err.Error())
+	}
+	return chatErrorData(e.Internal, "chat failed")
+}
+
+func convertInterrupt(interrupt *entity.Interrupt) *apiv1.ChatInterrupt {
+	res := &apiv1.ChatInterrupt{MessageID: interrupt.RunID, ToolCalls: make([]*apiv1.PendingToolCall, 0, len(interrupt.ToolCalls))}
+	for _, call := range interrupt.ToolCalls {
+		res.ToolCalls = append(res.ToolCalls, &apiv1.PendingToolCall{
+			ToolCallID: call.ID,
+			Name:       call.Name,
+			Arguments:  call.Arguments,
+		})
+	}
+	return res
+}
+
+// convertPostChatRequest Messages的最后一条必须是用户消息，作为本次的输入
+func convertPostChatRequest(request *apiv1.PostChatRequest) (*entity.AgentRequest, e.ApiError) {
+	if request.ModelProfile != "" {
+		if _, found := constants.GetModelProfile(request.ModelProfile); !found {
+			return nil, e.NewApiError(e.InvalidArgument, fmt.Sprintf("PostChatRequest.ModelProfile '%s' is not found", request.ModelProfile), nil)
+		}
+	}
+
+	if len(request.Messages) == 0 {
+		return nil, e.NewApiError(e.InvalidArgument, "PostChatRequest.Messages is missing", nil)
+	}
+	messages := make([]*schema.Message, 0, len(request.Messages))
+	for _, m := range request.Messages {
+		if m == nil {
+			return nil, e.NewApiError(e.InvalidArgument, "PostChatRequest.Messages is invalid", nil)
+		}
+		switch schema.RoleType(m.Role) {
+		case schema.System:
+			messages = append(messages, schema.SystemMessage(m.Content))
+		case schema.User:
+			messages = append(messages, schema.UserMessage(m.Content))
+		case schema.Assistant:
+			messages = append(messages, schema.AssistantMessage(m.Content, nil))
+		default:
+			return nil, e.NewApiError(e.InvalidArgument, "PostChatRequest.Messages.Role is not in [system user assistant]", nil)
+		}
+	}
+	if len(messages) == 0 || messages[len(messages)-1].Role != schema.User || messages[len(messages)-1].Content == "" {
+		return nil, e.NewApiError(e.InvalidArgument, "PostChatRequest.Messages must end with a user message", nil)
+	}
+
+	input := messages[len(messages)-1]
+	options, apiErr := convertChatOptions(request.Options)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &entity.AgentRequest{
+		RequestID:      request.RequestID,
+		User:           request.User,
+		AgentID:        request.AgentID,
+		ConversationID: request.ConversationID,
+		Input:          input,
+		History:        messages[:len(messages)-1],
+		ChatProtocol:   request.ChatProtocol,
+		ModelProfile:   request.ModelProfile,
+		Options:        options,
+		Reasoning:      request.Reasoning,
+	}, nil
+}
+
+func convertResumeChatRequest(request *apiv1.ResumeChatRequest) (*entity.ResumeRequest, e.ApiError) {
+	decisions := make(map[string]*entity.ToolDecision, len(request.Decisions))
+	for _, d := range request.Decisions {
+		if d == nil || d.ToolCallID == "" {
+			return nil, e.NewApiError(e.InvalidArgument, "ResumeChatRequest.Decisions.ToolCallID is missing", nil)
+		}
+		action := entity.DecisionAction(d.Action)
+		switch action {
+		case entity.DecisionActionApprove, entity.DecisionActionReject:
+		case entity.DecisionActionEdit:
+			if !json.Valid([]byte(d.Arguments)) {
+				return nil, e.NewApiError(e.InvalidArgument, "ResumeChatRequest.Decisions.Arguments is invalid", nil)
+			}
+		default:
+			return nil, e.NewApiError(e.InvalidArgument, "ResumeChatRequest.Decisions.Action is not in [approve reject edit]", nil)
+		}
+		decisions[d.ToolCallID] = &entity.ToolDecision{Action: action, Arguments: d.Arguments, Reason: d.Reason}
+	}
+
+	return &entity.ResumeRequest{
+		RequestID: request.RequestID,
+		User:      request.User,
+		RunID:     request.MessageID,
+		Decisions: decisions,
+		Reasoning: request.Reasoning,
+	}, nil
+}
+
+// parseResponseSchema 解析请求中的JSON Schema，为空时返回nil
+func parseResponseSchema(value any, field string) (*jsonschema.Schema, e.ApiError) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, e.NewApiError(e.InvalidArgument, fmt.Sprintf("%s is invalid, %v", field, err), err)
+	}
+	s := &jsonschema.Schema{}
+	if err = json.Unmarshal(data, s); err != nil {
+		return nil, e.NewApiError(e.InvalidArgument, fmt.Sprintf("%s is invalid, %v", field, err), err)
+	}
+	if _, err = chatmodel.CompileSchema(s); err != nil {
+		return nil, e.NewApiError(e.InvalidArgument, fmt.Sprintf("%s is invalid, %v", field, err), err)
+	}
+	return s, nil
+}
+
+func convertChatOptions(options *apiv1.ChatOptions) (*entity.ModelOptions, e.ApiError) {
+	if options == nil {
+		return nil, nil
+	}
+	if options.Temperature != nil && (*options.Temperature < 0 || *options.Temperature > 2) {
+		return nil, e.NewApiError(e.InvalidArgument, "PostChatRequest.Options.Temperature is not between 0 and 2", nil)
+	}
+	if options.TopP != nil && (*options.TopP < 0 || *options.TopP > 1) {
+		return nil, e.NewApiError(e.InvalidArgument, "PostChatRequest.Options.TopP is not between 0 and 1", nil)
+	}
+	if options.MaxTokens != nil && *options.MaxTokens <= 0 {
+		return nil, e.NewApiError(e.InvalidArgument, "PostChatRequest.Options.MaxTokens must be positive", nil)
+	}
+	responseSchema, apiErr := parseResponseSchema(options.ResponseSchema, "PostChatRequest.Options.ResponseSchema")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	res := &entity.ModelOptions{
+		Temperature:    options.Temperature,
+		TopP:           options.TopP,
+		MaxTokens:      options.MaxTokens,
+		Stop:           options.Stop,
+		ResponseSchema: responseSchema,
+	}
+	switch options.ToolChoice {
+	case "auto":
+		res.ToolChoice = schema.ToolChoiceAllowed
+	case "none":
+		res.ToolChoice = schema.ToolChoiceForbidden
+	case "required":
+		res.ToolChoice = schema.ToolChoiceForced
+	}
+	return res, nil
+}
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/caiflower/ai-agent/controller"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/service/definition"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/web/e"
+)
+
+type agentDefinitionController struct {
+	DefinitionManager definition.Manager `autowired:""`
+}
+
+func NewAgentDefinitionController() controller.AgentDefinitionController {
+	return &agentDefinitionController{}
+}
+
+func (c *agentDefinitionController) CreateAgent(request *apiv1.CreateAgentRequest) (*apiv1.AgentDefinition, e.ApiError) {
+	responseSchema, apiErr := parseResponseSchema(request.ResponseSchema, "CreateAgentRequest.ResponseSchema")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	def := &bean.AgentDefinition{
+		Name:                request.Name,
+		Description:         request.Description,
+		Persona:             request.Persona,
+		ModelProfile:        request.ModelProfile,
+		Mode:                request.Mode,
+		Flow:                request.Flow,
+		Tools:               request.Tools,
+		ApprovalTools:       request.ApprovalTools,
+		ReturnDirectlyTools: request.ReturnDirectlyTools,
+		KnowledgeBases:      request.KnowledgeBases,
+		OpeningMessage:      request.OpeningMessage,
+		Suggest:             request.Suggest,
+		SubAgents:           request.SubAgents,
+		ResponseSchema:      responseSchema,
+	}
+	if err := c.DefinitionManager.Create(def); err != nil {
+		logger.Error("create agent failed. Error: %v", err)
+		return nil, convertDefinitionError(err)
+	}
+
+	return convertAgentDefinition(def), nil
+}
+
+func (c *agentDefinitionController) UpdateAgent(request *apiv1.UpdateAgentRequest) (*apiv1.AgentDefinition, e.ApiError) {
+	responseSchema, apiErr := parseResponseSchema(request.ResponseSchema, "UpdateAgentRequest.ResponseSchema")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	def := &bean.AgentDefinition{
+		AgentID:             request.AgentID,
+		Name:                request.Name,
+		Description:         request.Description,
+		Persona:             request.Persona,
+		ModelProfile:        request.ModelProfile,
+		Mode:                request.Mode,
+		Flow:                request.Flow,
+		Tools:               request.Tools,
+		ApprovalTools:       request.ApprovalTools,
+		ReturnDirectlyTools: request.ReturnDirectlyTools,
+		KnowledgeBases:      request.KnowledgeBases,
+		OpeningMessage:      request.OpeningMessage,
+		Suggest:             request.Suggest,
+		SubAgents:           request.SubAgents,
+		ResponseSchema:      responseSchema,
+	}
+	if err := c.DefinitionManager.Update(def); err != nil {
+		logger.Error("update agent failed. Error: %v", err)
+		return nil, convertDefinitionError(err)
+	}
+
+	def, err := c.DefinitionManager.Get(request.AgentID)
+	if err != nil {
+		return nil, convertDefinitionError(err)
+	}
+	return convertAgentDefinition(def), nil
+}
+
+func (c *agentDefinitionController) DescribeAgent(request *apiv1.DescribeAgentRequest) (*apiv1.AgentDefinition, e.ApiError) {
+	def, err := c.DefinitionManager.Get(request.AgentID)
+	if err != nil {
+		return nil, convertDefinitionError(err)
+	}
+
+	return convertAgentDefinition(def), nil
+}
+
+func (c *agentDefinitionController) DeleteAgent(request *apiv1.DeleteAgentRequest) e.ApiError {
+	if err := c.DefinitionManager.Delete(request.AgentID); err != nil {
+		logger.Error("delete agent failed. Error: %v", err)
+		return convertDefinitionError(err)
+	}
+
+	return nil
+}
+
+func (c *agentDefinitionController) ListAgents(_ *apiv1.ListAgentsRequest) ([]*apiv1.AgentDefinition, e.ApiError) {
+	defs, err := c.DefinitionManager.List()
+	if err != nil {
+		logger.Error("list agents failed. Error: %v", err)
+		return nil, e.NewInternalError(err)
+	}
+
+	res := make([]*apiv1.AgentDefinition, 0, len(defs))
+	for _, def := range defs {
+		res = append(res, convertAgentDefinition(def))
+	}
+	return res, nil
+}
+
+func convertDefinitionError(err error) e.ApiError {
+	switch {
+	case errors.Is(err, definition.ErrAgentNotFound):
+		return e.NewApiError(e.NotFound, err.Error(), err)
+	case errors.Is(err, definition.ErrInvalidDefinition):
+		return e.NewApiError(e.InvalidArgument, err.Error(), err)
+	default:
+		return e.NewInternalError(err)
+	}
+}
+
+func convertAgentDefinition(def *bean.AgentDefinition) *apiv1.AgentDefinition {
+	return &apiv1.AgentDefinition{
+		AgentID:             def.AgentID,
+		Name:                def.Name,
+		Description:         def.Description,
+		Persona:             def.Persona,
+		ModelProfile:        def.ModelProfile,
+		Mode:                def.Mode,
+		Flow:                def.Flow,
+		Tools:               def.Tools,
+		ApprovalTools:       def.ApprovalTools,
+		ReturnDirectlyTools: def.ReturnDirectlyTools,
+		KnowledgeBases:      def.KnowledgeBases,
+		OpeningMessage:      def.OpeningMessage,
+		Suggest:             def.Suggest,
+		SubAgents:           def.SubAgents,
+		ResponseSchema:      def.ResponseSchema,
+		CreateTime:          def.CreateTime,
+		UpdateTime:          def.UpdateTime,
+	}
+}
@@ -0,0 +1,443 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/controller"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/agent"
+	"github.com/caiflower/ai-agent/service/definition"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/xsse"
+	golocalv1 "github.com/caiflower/common-tools/pkg/golocal/v1"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/pkg/tools"
+	"github.com/caiflower/common-tools/web/e"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/tmaxmax/go-sse"
+)
+
+const (
+	objectOfChatCompletion      = "chat.completion"
+	objectOfChatCompletionChunk = "chat.completion.chunk"
+
+	finishReasonOfStop      = "stop"
+	finishReasonOfToolCalls = "tool_calls"
+)
+
+type chatCompletionController struct {
+	AgentRuntime agent.Runtime `autowired:""`
+}
+
+func NewChatCompletionController() controller.ChatCompletionController {
+	return &chatCompletionController{}
+}
+
+// completion 一次运行转换后的结果
+type completion struct {
+	id          string
+	content     strings.Builder
+	reasoning   strings.Builder
+	toolCalls   []*apiv1.ChatCompletionToolCall
+	usage       apiv1.ChatCompletionUsage
+	interrupted string // 等待确认工具调用的运行ID
+}
+
+func (c *completion) finishReason() *string {
+	reason := finishReasonOfStop
+	if len(c.toolCalls) > 0 {
+		reason = finishReasonOfToolCalls
+	}
+	return &reason
+}
+
+// CreateChatCompletion 响应按OpenAI的格式由controller写入，框架不再写入通用的响应
+func (c *chatCompletionController) CreateChatCompletion(request *apiv1.ChatCompletionRequest) e.ApiError {
+	request.Context.UpgradeWebsocket()
+	w, r := request.Context.GetResponseWriterAndRequest()
+	w.Header().Set("X-Request-Id", request.RequestID)
+
+	agentReq, err := convertChatCompletionRequest(request)
+	if err != nil {
+		writeChatCompletionError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return nil
+	}
+
+	// 客户端断开连接后取消运行
+	sr, err := c.AgentRuntime.Run(golocalv1.GetContext(), agentReq)
+	if err != nil {
+		logger.Error("agent run failed. Error: %v", err)
+		switch {
+		case errors.Is(err, definition.ErrAgentNotFound):
+			writeChatCompletionError(w, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("The model '%s' does not exist", request.Model))
+		case errors.Is(err, agent.ErrClientToolsUnsupported):
+			writeChatCompletionError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		default:
+			writeChatCompletionError(w, http.StatusInternalServerError, "server_error", "chat failed")
+		}
+		return nil
+	}
+	defer sr.Close()
+
+	clientTools := make([]string, 0, len(request.Tools))
+	for _, t := range request.Tools {
+		clientTools = append(clientTools, t.Function.Name)
+	}
+	result := &completion{}
+	created := time.Now().Unix()
+
+	if !request.Stream {
+		err = readCompletion(sr, clientTools, result, nil)
+		if err != nil {
+			logger.Error("chat receive failed. Error: %v", err)
+			writeChatCompletionError(w, http.StatusInternalServerError, "server_error", "chat failed")
+			return nil
+		}
+		if result.interrupted != "" {
+			writeChatCompletionError(w, http.StatusConflict, "invalid_request_error", interruptedMessage(result.interrupted))
+			return nil
+		}
+
+		// index仅在chunk中使用
+		for _, call := range result.toolCalls {
+			call.Index = nil
+		}
+		message := &apiv1.ChatCompletionMessage{
+			Role:             string(schema.Assistant),
+			Content:          result.content.String(),
+			ReasoningContent: result.reasoning.String(),
+			ToolCalls:        result.toolCalls,
+		}
+		writeChatCompletion(w, http.StatusOK, &apiv1.ChatCompletion{
+			ID:      result.id,
+			Object:  objectOfChatCompletion,
+			Created: created,
+			Model:   request.Model,
+			Choices: []*apiv1.ChatCompletionChoice{{Message: message, FinishReason: result.finishReason()}},
+			Usage:   &result.usage,
+		})
+		return nil
+	}
+
+	sess, err := xsse.Upgrade(w, r)
+	if err != nil {
+		logger.Error("upgrade xsse failed. Error: %v", err)
+		return nil
+	}
+	// 工具执行等较长时间没有增量时发送心跳，避免代理断开连接
+	client, stop := xsse.KeepAlive(sess, constants.Prop.SSE.Heartbeat)
+	defer stop()
+	send := func(v any) error {
+		msg := &sse.Message{}
+		msg.AppendData(tools.ToJson(v))
+		if err := client.Send(msg); err != nil {
+			return err
+		}
+		return client.Flush()
+	}
+	chunk := func(delta *apiv1.ChatCompletionMessage, finishReason *string) *apiv1.ChatCompletion {
+		return &apiv1.ChatCompletion{
+			ID:      result.id,
+			Object:  objectOfChatCompletionChunk,
+			Created: created,
+			Model:   request.Model,
+			Choices: []*apiv1.ChatCompletionChoice{{Delta: delta, FinishReason: finishReason}},
+		}
+	}
+
+	err = readCompletion(sr, clientTools, result, func(delta *apiv1.ChatCompletionMessage) error {
+		return send(chunk(delta, nil))
+	})
+	switch {
+	case err != nil:
+		logger.Error("chat receive failed. Error: %v", err)
+		err = send(&apiv1.ChatCompletionError{Error: &apiv1.ChatCompletionErrorDetail{Message: "chat failed", Type: "server_error"}})
+	case result.interrupted != "":
+		err = send(&apiv1.ChatCompletionError{Error: &apiv1.ChatCompletionErrorDetail{Message: interruptedMessage(result.interrupted), Type: "invalid_request_error"}})
+	default:
+		err = send(chunk(&apiv1.ChatCompletionMessage{}, result.finishReason()))
+		if err == nil && request.StreamOptions != nil && request.StreamOptions.IncludeUsage {
+			usage := chunk(nil, nil)
+			usage.Choices, usage.Usage = []*apiv1.ChatCompletionChoice{}, &result.usage
+			err = send(usage)
+		}
+	}
+	if err == nil {
+		msg := &sse.Message{}
+		msg.AppendData("[DONE]")
+		if err = client.Send(msg); err == nil {
+			err = client.Flush()
+		}
+	}
+	if err != nil {
+		logger.Warn("xsse send failed. Error: %v", err)
+	}
+	return nil
+}
+
+// readCompletion 读取智能体的事件，onDelta不为空时每个增量都回调。
+// 只返回客户端声明的工具调用，智能体自己的工具调用在服务端执行
+func readCompletion(sr *schema.StreamReader[*apiv1.ChatEvent], clientTools []string, result *completion, onDelta func(delta *apiv1.ChatCompletionMessage) error) error {
+	first := true
+	for {
+		event, err := sr.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		switch event.EventType {
+		case entity.EventTypeOfRunInfo:
+			result.id = "chatcmpl-" + event.RunInfo.RunID
+		case entity.EventTypeOfChatModelAnswer, entity.EventTypeOfToolsAsChatModelStream:
+			var toolCallChunks []*schema.Message
+			for {
+				message, err := event.ChatModelAnswer.Recv()
+				if err != nil {
+					if err == io.EOF {
+						break
+					}
+					return err
+				}
+				if len(message.ToolCalls) > 0 {
+					toolCallChunks = append(toolCallChunks, &schema.Message{Role: schema.Assistant, ToolCalls: message.ToolCalls})
+				}
+				if message.Content == "" && message.ReasoningContent == "" {
+					continue
+				}
+				result.content.WriteString(message.Content)
+				result.reasoning.WriteString(message.ReasoningContent)
+				if onDelta != nil {
+					delta := &apiv1.ChatCompletionMessage{ReasoningContent: message.ReasoningContent}
+					if message.Content != "" {
+						delta.Content = message.Content
+					}
+					if first {
+						delta.Role, first = string(schema.Assistant), false
+					}
+					if err = onDelta(delta); err != nil {
+						return err
+					}
+				}
+			}
+
+			toolCalls, err := clientToolCalls(toolCallChunks, clientTools, len(result.toolCalls))
+			if err != nil {
+				return err
+			}
+			if len(toolCalls) == 0 {
+				continue
+			}
+			result.toolCalls = append(result.toolCalls, toolCalls...)
+			if onDelta != nil {
+				delta := &apiv1.ChatCompletionMessage{ToolCalls: toolCalls}
+				if first {
+					delta.Role, first = string(schema.Assistant), false
+				}
+				if err = onDelta(delta); err != nil {
+					return err
+				}
+			}
+		case entity.EventTypeOfInterrupt:
+			result.interrupted = event.Interrupt.RunID
+		case entity.EventTypeOfUsage:
+			// 监督者模式下每个专家发送一次
+			result.usage.PromptTokens += event.Usage.PromptTokens
+			result.usage.CompletionTokens += event.Usage.CompletionTokens
+			result.usage.TotalTokens = result.usage.PromptTokens + result.usage.CompletionTokens
+		}
+	}
+}
+
+// clientToolCalls 合并流式输出的工具调用，返回其中客户端声明的工具
+func clientToolCalls(chunks []*schema.Message, clientTools []string, offset int) ([]*apiv1.ChatCompletionToolCall, error) {
+	if len(chunks) == 0 || len(clientTools) == 0 {
+		return nil, nil
+	}
+	message, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	var toolCalls []*apiv1.ChatCompletionToolCall
+	for _, call := range message.ToolCalls {
+		if !slices.Contains(clientTools, call.Function.Name) {
+			continue
+		}
+		index := offset + len(toolCalls)
+		toolCalls = append(toolCalls, &apiv1.ChatCompletionToolCall{
+			Index:    &index,
+			ID:       call.ID,
+			Type:     "function",
+			Function: apiv1.ChatCompletionFunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+		})
+	}
+	return toolCalls, nil
+}
+
+// convertChatCompletionRequest 最后一条消息为本次输入，可以是用户消息或客户端工具的结果
+func convertChatCompletionRequest(request *apiv1.ChatCompletionRequest) (*entity.AgentRequest, error) {
+	if len(request.Messages) == 0 {
+		return nil, errors.New("messages is missing")
+	}
+	messages := make([]*schema.Message, 0, len(request.Messages))
+	for i, m := range request.Messages {
+		if m == nil {
+			return nil, fmt.Errorf("messages[%d] is invalid", i)
+		}
+		content, err := textContent(m.Content)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d].content: %w", i, err)
+		}
+		switch m.Role {
+		case "system", "developer":
+			messages = append(messages, schema.SystemMessage(content))
+		case "user":
+			messages = append(messages, schema.UserMessage(content))
+		case "assistant":
+			toolCalls := make([]schema.ToolCall, 0, len(m.ToolCalls))
+			for _, call := range m.ToolCalls {
+				toolCalls = append(toolCalls, schema.ToolCall{
+					ID:       call.ID,
+					Type:     "function",
+					Function: schema.FunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+				})
+			}
+			messages = append(messages, schema.AssistantMessage(content, toolCalls))
+		case "tool":
+			if m.ToolCallID == "" {
+				return nil, fmt.Errorf("messages[%d].tool_call_id is missing", i)
+			}
+			messages = append(messages, schema.ToolMessage(content, m.ToolCallID))
+		default:
+			return nil, fmt.Errorf("messages[%d].role '%s' is not supported", i, m.Role)
+		}
+	}
+	input := messages[len(messages)-1]
+	if input.Role != schema.User && input.Role != schema.Tool {
+		return nil, errors.New("the last message must be a user or tool message")
+	}
+
+	options, err := convertCompletionOptions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.AgentRequest{
+		RequestID:    request.RequestID,
+		User:         request.User,
+		AgentID:      request.Model,
+		Input:        input,
+		History:      messages[:len(messages)-1],
+		ChatProtocol: chatmodel.Protocol(constants.Prop.OpenAI.ChatProtocol),
+		Options:      options,
+	}, nil
+}
+
+func convertCompletionOptions(request *apiv1.ChatCompletionRequest) (*entity.ModelOptions, error) {
+	options := &entity.ModelOptions{
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		MaxTokens:   request.MaxTokens,
+	}
+
+	switch stop := request.Stop.(type) {
+	case nil:
+	case string:
+		options.Stop = []string{stop}
+	case []any:
+		for _, s := range stop {
+			str, ok := s.(string)
+			if !ok {
+				return nil, errors.New("stop must be a string or an array of strings")
+			}
+			options.Stop = append(options.Stop, str)
+		}
+	default:
+		return nil, errors.New("stop must be a string or an array of strings")
+	}
+
+	switch choice := request.ToolChoice.(type) {
+	case nil:
+	case string:
+		switch choice {
+		case "auto":
+			options.ToolChoice = schema.ToolChoiceAllowed
+		case "none":
+			options.ToolChoice = schema.ToolChoiceForbidden
+		case "required":
+			options.ToolChoice = schema.ToolChoiceForced
+		default:
+			return nil, fmt.Errorf("tool_choice '%s' is not supported", choice)
+		}
+	default:
+		// 指定函数时要求模型调用工具
+		options.ToolChoice = schema.ToolChoiceForced
+	}
+
+	for i, t := range request.Tools {
+		if t == nil || t.Type != "function" || t.Function.Name == "" {
+			return nil, fmt.Errorf("tools[%d] is invalid, only function tools are supported", i)
+		}
+		tool := &entity.ClientTool{Name: t.Function.Name, Description: t.Function.Description}
+		if len(t.Function.Parameters) > 0 {
+			tool.Parameters = &jsonschema.Schema{}
+			if err := json.Unmarshal(t.Function.Parameters, tool.Parameters); err != nil {
+				return nil, fmt.Errorf("tools[%d].function.parameters is invalid: %w", i, err)
+			}
+		}
+		options.Tools = append(options.Tools, tool)
+	}
+	return options, nil
+}
+
+// textContent 内容为字符串或文本片段数组
+func textContent(content any) (string, error) {
+	switch v := content.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []any:
+		var text strings.Builder
+		for _, part := range v {
+			p, ok := part.(map[string]any)
+			if !ok || p["type"] != "text" {
+				return "", errors.New("only text content is supported")
+			}
+			s, _ := p["text"].(string)
+			text.WriteString(s)
+		}
+		return text.String(), nil
+	default:
+		return "", errors.New("content must be a string or an array of content parts")
+	}
+}
+
+func interruptedMessage(runID string) string {
+	return fmt.Sprintf("The run is waiting for tool call approval, resume it with POST /v1/chat/resume and messageId '%s'", runID)
+}
+
+func writeChatCompletion(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(tools.ToJson(v))); err != nil {
+		logger.Warn("write chat completion failed. Error: %v", err)
+	}
+}
+
+func writeChatCompletionError(w http.ResponseWriter, status int, errType, message string) {
+	writeChatCompletion(w, status, &apiv1.ChatCompletionError{Error: &apiv1.ChatCompletionErrorDetail{Message: message, Type: errType}})
+}
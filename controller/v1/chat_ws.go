@@ -0,0 +1,267 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/model/api"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/entity"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/caiflower/common-tools/pkg/tools"
+	"github.com/caiflower/common-tools/web/e"
+	"github.com/cloudwego/eino/schema"
+	"github.com/tmaxmax/go-sse"
+	"golang.org/x/net/websocket"
+)
+
+// wsSession 一个WebSocket连接，同时只推送一个运行的事件
+type wsSession struct {
+	conn    *websocket.Conn
+	request api.Request
+	writeMu sync.Mutex // 推送事件和回复消息在不同的goroutine中写入
+	runMu   sync.Mutex
+	running *wsRun
+}
+
+// wsRun 连接上正在推送的运行，连接断开后只停止推送，运行在后台继续，回放窗口内没有客户端重连时取消
+type wsRun struct {
+	session   *wsSession
+	requestID string
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// Send 订阅的SSE消息转换为JSON消息发送，类型和ID与SSE相同
+func (r *wsRun) Send(msg *sse.Message) error {
+	for ev, err := range sse.Read(strings.NewReader(msg.String()), nil) {
+		if err != nil {
+			return err
+		}
+		// 客户端收到结束事件后可以立即开始下一个运行
+		if ev.Type == apiv1.EventTypeOfChatFinish || ev.Type == apiv1.EventTypeOfChatError {
+			r.session.release(r)
+		}
+		if err = r.session.write(&apiv1.ChatWsServerMessage{Type: ev.Type, ID: ev.LastEventID, Data: ev.Data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *wsRun) Flush() error {
+	return nil
+}
+
+func (s *wsSession) write(msg *apiv1.ChatWsServerMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if timeout := constants.Prop.SSE.WriteTimeout; timeout > 0 {
+		if err := s.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+	}
+	return websocket.Message.Send(s.conn, tools.ToJson(msg))
+}
+
+func (s *wsSession) writeError(apiErr e.ApiError) {
+	if err := s.write(&apiv1.ChatWsServerMessage{Type: apiv1.WsMessageTypeOfError, Error: apiErr}); err != nil {
+		logger.Warn("websocket send failed. Error: %v", err)
+	}
+}
+
+func (s *wsSession) current() *wsRun {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	return s.running
+}
+
+func (s *wsSession) release(run *wsRun) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if s.running == run {
+		s.running = nil
+	}
+}
+
+// stop 停止推送并等待订阅退出
+func (s *wsSession) stop() {
+	if run := s.current(); run != nil {
+		run.cancel()
+		<-run.done
+	}
+}
+
+// ChatWs 升级为WebSocket后在同一连接上对话、确认工具调用和取消，鉴权与Chat相同
+func (c *agentController) ChatWs(request *apiv1.ChatWsRequest) e.ApiError {
+	// 响应由websocket写入，框架不再写入通用的响应
+	request.Context.UpgradeWebsocket()
+	w, r := request.Context.GetResponseWriterAndRequest()
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		c.serveWs(&wsSession{conn: conn, request: request.Request})
+	}}.ServeHTTP(w, r)
+	return nil
+}
+
+func (c *agentController) serveWs(s *wsSession) {
+	// 连接断开后停止推送，客户端可以通过Last-Event-ID从SSE接口继续接收
+	defer s.stop()
+	// 清除HTTP服务为请求设置的读写超时，写超时在每次写入时设置
+	if err := s.conn.SetDeadline(time.Time{}); err != nil {
+		logger.Warn("reset websocket deadline failed. Error: %v", err)
+	}
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(s.conn, &data); err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Warn("websocket receive failed. Error: %v", err)
+			}
+			return
+		}
+
+		msg := &apiv1.ChatWsClientMessage{}
+		if err := json.Unmarshal(data, msg); err != nil {
+			s.writeError(e.NewApiError(e.InvalidArgument, "ChatWsClientMessage is invalid", err))
+			continue
+		}
+		if apiErr := c.handleWsMessage(s, msg); apiErr != nil {
+			s.writeError(apiErr)
+		}
+	}
+}
+
+func (c *agentController) handleWsMessage(s *wsSession, msg *apiv1.ChatWsClientMessage) e.ApiError {
+	switch msg.Type {
+	case apiv1.WsMessageTypeOfPing:
+		if err := s.write(&apiv1.ChatWsServerMessage{Type: apiv1.WsMessageTypeOfPong}); err != nil {
+			logger.Warn("websocket send failed. Error: %v", err)
+		}
+		return nil
+	case apiv1.WsMessageTypeOfCancel:
+		// 取消后继续推送chat.cancelled和chat.finish
+		run := s.current()
+		if run == nil {
+			return e.NewApiError(e.NotAcceptable, "no chat is running", nil)
+		}
+		return c.CancelChat(&apiv1.CancelChatRequest{Request: s.request, TargetRequestID: run.requestID})
+	case apiv1.WsMessageTypeOfUserMessage, apiv1.WsMessageTypeOfApprove:
+	default:
+		return e.NewApiError(e.InvalidArgument, "ChatWsClientMessage.Type is not in [user_message cancel approve ping]", nil)
+	}
+
+	if s.current() != nil {
+		return e.NewApiError(e.NotAcceptable, "a chat is already running", nil)
+	}
+	if apiErr := validateWsMessage(msg); apiErr != nil {
+		return apiErr
+	}
+
+	// 每个运行使用新的请求ID
+	request := s.request
+	request.RequestID = tools.UUID()
+	var (
+		sr     *schema.StreamReader[*apiv1.ChatEvent]
+		apiErr e.ApiError
+	)
+	if msg.Type == apiv1.WsMessageTypeOfUserMessage {
+		agentReq, convertErr := convertPostChatRequest(&apiv1.PostChatRequest{
+			Request:        request,
+			ConversationID: msg.ConversationID,
+			AgentID:        msg.AgentID,
+			ModelProfile:   msg.ModelProfile,
+			ChatProtocol:   msg.ChatProtocol,
+			Messages:       msg.Messages,
+			Files:          msg.Files,
+			Options:        msg.Options,
+			Reasoning:      msg.Reasoning,
+		})
+		if convertErr != nil {
+			return convertErr
+		}
+		if agentReq.Attachments, convertErr = loadAttachments(c.FileManager, request.User, msg.Files, "ChatWsClientMessage.Files"); convertErr != nil {
+			return convertErr
+		}
+		sr, apiErr = c.run(runContext(true), agentReq)
+	} else {
+		resumeReq, convertErr := convertResumeChatRequest(&apiv1.ResumeChatRequest{
+			Request:   request,
+			MessageID: msg.MessageID,
+			Decisions: msg.Decisions,
+			Reasoning: msg.Reasoning,
+		})
+		if convertErr != nil {
+			return convertErr
+		}
+		sr, apiErr = c.resume(runContext(true), resumeReq)
+	}
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return c.pushWs(s, &request, sr, msg.Reasoning)
+}
+
+// pushWs 先发送开始事件，再订阅运行的topic，推送结束后连接可以开始下一个运行
+func (c *agentController) pushWs(s *wsSession, request *api.Request, sr *schema.StreamReader[*apiv1.ChatEvent], reasoning entity.ReasoningMode) e.ApiError {
+	stream, err := c.startStream(context.Background(), request, apiv1.EventTypeOfChatStart, sr, reasoning == "" || reasoning == entity.ReasoningModeShow)
+	if err != nil {
+		return e.NewInternalError(err)
+	}
+
+	ctx, cancel := context.WithCancel(stream.ctx)
+	run := &wsRun{session: s, requestID: request.RequestID, cancel: cancel, done: make(chan struct{})}
+	s.runMu.Lock()
+	s.running = run
+	s.runMu.Unlock()
+
+	c.attach(stream)
+	safego.Go(func() {
+		defer func() {
+			cancel()
+			s.release(run)
+			close(run.done)
+			c.detach(stream)
+		}()
+
+		if err := run.Send(stream.start); err != nil {
+			logger.Warn("websocket send failed. Error: %v", err)
+			return
+		}
+		sub := sse.Subscription{Client: run, LastEventID: stream.start.ID, Topics: []string{stream.runID}}
+		if err := c.SSEProvider.Subscribe(ctx, sub); err != nil {
+			logger.Warn("websocket subscribe failed. Error: %v", err)
+		}
+	})
+	return nil
+}
+
+// validateWsMessage HTTP接口由框架按tag校验，WebSocket的消息需要自己校验
+func validateWsMessage(msg *apiv1.ChatWsClientMessage) e.ApiError {
+	switch msg.ChatProtocol {
+	case "", chatmodel.ProtocolMock, chatmodel.ProtocolOllama:
+	default:
+		return e.NewApiError(e.InvalidArgument, "ChatWsClientMessage.ChatProtocol is not in [mock ollama]", nil)
+	}
+	switch msg.Reasoning {
+	case "", entity.ReasoningModeShow, entity.ReasoningModeHide, entity.ReasoningModeDisable:
+	default:
+		return e.NewApiError(e.InvalidArgument, "ChatWsClientMessage.Reasoning is not in [show hide disable]", nil)
+	}
+	if msg.Options != nil {
+		switch msg.Options.ToolChoice {
+		case "", "auto", "none", "required":
+		default:
+			return e.NewApiError(e.InvalidArgument, "ChatWsClientMessage.Options.ToolChoice is not in [auto none required]", nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/caiflower/ai-agent/controller"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/service/experiment"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/web/e"
+)
+
+type experimentController struct {
+	ExperimentManager experiment.Manager `autowired:""`
+}
+
+func NewExperimentController() controller.ExperimentController {
+	return &experimentController{}
+}
+
+func (c *experimentController) CreateExperiment(request *apiv1.CreateExperimentRequest) (*apiv1.Experiment, e.ApiError) {
+	exp := &bean.Experiment{
+		Name:    request.Name,
+		AgentID: request.AgentID,
+	}
+	for _, v := range request.Variants {
+		exp.Variants = append(exp.Variants, &bean.ExperimentVariant{
+			Name:          v.Name,
+			Weight:        v.Weight,
+			PromptVersion: v.PromptVersion,
+			ModelProfile:  v.ModelProfile,
+		})
+	}
+	if err := c.ExperimentManager.Create(exp); err != nil {
+		logger.Error("create experiment failed. Error: %v", err)
+		return nil, convertExperimentError(err)
+	}
+
+	return convertExperiment(exp), nil
+}
+
+func (c *experimentController) DescribeExperiment(request *apiv1.DescribeExperimentRequest) (*apiv1.Experiment, e.ApiError) {
+	exp, err := c.ExperimentManager.Get(request.ExperimentID)
+	if err != nil {
+		return nil, convertExperimentError(err)
+	}
+
+	return convertExperiment(exp), nil
+}
+
+func (c *experimentController) ListExperiments(_ *apiv1.ListExperimentsRequest) ([]*apiv1.Experiment, e.ApiError) {
+	exps, err := c.ExperimentManager.List()
+	if err != nil {
+		logger.Error("list experiments failed. Error: %v", err)
+		return nil, e.NewInternalError(err)
+	}
+
+	res := make([]*apiv1.Experiment, 0, len(exps))
+	for _, exp := range exps {
+		res = append(res, convertExperiment(exp))
+	}
+	return res, nil
+}
+
+func (c *experimentController) StopExperiment(request *apiv1.StopExperimentRequest) e.ApiError {
+	if err := c.ExperimentManager.Stop(request.ExperimentID); err != nil {
+		logger.Error("stop experiment failed. Error: %v", err)
+		return convertExperimentError(err)
+	}
+
+	return nil
+}
+
+func (c *experimentController) DescribeExperimentReport(request *apiv1.DescribeExperimentReportRequest) ([]*apiv1.ExperimentVariantReport, e.ApiError) {
+	stats, err := c.ExperimentManager.Report(request.ExperimentID)
+	if err != nil {
+		logger.Error("describe experiment report failed. Error: %v", err)
+		return nil, convertExperimentError(err)
+	}
+
+	res := make([]*apiv1.ExperimentVariantReport, 0, len(stats))
+	for _, stat := range stats {
+		report := &apiv1.ExperimentVariantReport{
+			Variant:          stat.Variant,
+			Runs:             stat.Runs,
+			PromptTokens:     stat.PromptTokens,
+			CompletionTokens: stat.CompletionTokens,
+			ThumbsUp:         stat.ThumbsUp,
+			ThumbsDown:       stat.ThumbsDown,
+		}
+		if stat.Runs > 0 {
+			report.AvgTotalTokens = float64(stat.PromptTokens+stat.CompletionTokens) / float64(stat.Runs)
+		}
+		res = append(res, report)
+	}
+	return res, nil
+}
+
+func convertExperimentError(err error) e.ApiError {
+	switch {
+	case errors.Is(err, experiment.ErrExperimentNotFound):
+		return e.NewApiError(e.NotFound, err.Error(), err)
+	case errors.Is(err, experiment.ErrInvalidExperiment):
+		return e.NewApiError(e.InvalidArgument, err.Error(), err)
+	default:
+		return e.NewInternalError(err)
+	}
+}
+
+func convertExperiment(exp *bean.Experiment) *apiv1.Experiment {
+	res := &apiv1.Experiment{
+		ExperimentID: exp.ExperimentID,
+		Name:         exp.Name,
+		AgentID:      exp.AgentID,
+		Running:      exp.Running,
+		CreateTime:   exp.CreateTime,
+		UpdateTime:   exp.UpdateTime,
+	}
+	for _, v := range exp.Variants {
+		res.Variants = append(res.Variants, &apiv1.ExperimentVariant{
+			Name:          v.Name,
+			Weight:        v.Weight,
+			PromptVersion: v.PromptVersion,
+			ModelProfile:  v.ModelProfile,
+		})
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/caiflower/ai-agent/controller"
+	"github.com/caiflower/ai-agent/dao"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/service/feedback"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/web/e"
+)
+
+type feedbackController struct {
+	FeedbackManager feedback.Manager `autowired:""`
+}
+
+func NewFeedbackController() controller.FeedbackController {
+	return &feedbackController{}
+}
+
+func (c *feedbackController) SubmitFeedback(request *apiv1.SubmitFeedbackRequest) e.ApiError {
+	fb := &bean.MessageFeedback{
+		MessageID: request.MessageID,
+		User:      request.User,
+		Rating:    convertRating(request.Rating),
+		Category:  request.Category,
+		Comment:   request.Comment,
+	}
+	if err := c.FeedbackManager.Submit(fb); err != nil {
+		logger.Error("submit feedback failed. Error: %v", err)
+		return convertFeedbackError(err)
+	}
+
+	return nil
+}
+
+func (c *feedbackController) ExportFeedback(request *apiv1.ExportFeedbackRequest) ([]*apiv1.FeedbackRecord, e.ApiError) {
+	filter := &dao.FeedbackFilter{
+		Rating:   convertRating(request.Rating),
+		Category: request.Category,
+		AgentID:  request.AgentID,
+		Offset:   request.Offset,
+		Limit:    request.Limit,
+	}
+
+	var err error
+	if request.StartTime != "" {
+		if filter.StartTime, err = time.ParseInLocation(time.DateTime, request.StartTime, time.Local); err != nil {
+			return nil, e.NewApiError(e.InvalidArgument, "ExportFeedbackRequest.StartTime is invalid", err)
+		}
+	}
+	if request.EndTime != "" {
+		if filter.EndTime, err = time.ParseInLocation(time.DateTime, request.EndTime, time.Local); err != nil {
+			return nil, e.NewApiError(e.InvalidArgument, "ExportFeedbackRequest.EndTime is invalid", err)
+		}
+	}
+
+	records, err := c.FeedbackManager.Export(filter)
+	if err != nil {
+		logger.Error("export feedback failed. Error: %v", err)
+		return nil, convertFeedbackError(err)
+	}
+
+	res := make([]*apiv1.FeedbackRecord, 0, len(records))
+	for _, record := range records {
+		fb := record.Feedback
+		res = append(res, &apiv1.FeedbackRecord{
+			MessageID:     fb.MessageID,
+			RequestID:     fb.RequestID,
+			User:          fb.User,
+			AgentID:       fb.AgentID,
+			Model:         fb.Model,
+			PromptName:    fb.PromptName,
+			PromptVersion: fb.PromptVersion,
+			Rating:        convertApiRating(fb.Rating),
+			Category:      fb.Category,
+			Comment:       fb.Comment,
+			Input:         record.Input,
+			Answer:        record.Answer,
+			CreateTime:    fb.CreateTime,
+		})
+	}
+	return res, nil
+}
+
+func convertFeedbackError(err error) e.ApiError {
+	switch {
+	case errors.Is(err, feedback.ErrMessageNotFound):
+		return e.NewApiError(e.NotFound, err.Error(), err)
+	case errors.Is(err, feedback.ErrInvalidFeedback):
+		return e.NewApiError(e.InvalidArgument, err.Error(), err)
+	default:
+		return e.NewInternalError(err)
+	}
+}
+
+func convertRating(rating string) int {
+	switch rating {
+	case apiv1.RatingUp:
+		return feedback.RatingUp
+	case apiv1.RatingDown:
+		return feedback.RatingDown
+	default:
+		return 0
+	}
+}
+
+func convertApiRating(rating int) string {
+	switch rating {
+	case feedback.RatingUp:
+		return apiv1.RatingUp
+	case feedback.RatingDown:
+		return apiv1.RatingDown
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/controller"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/file"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/web/e"
+)
+
+// maxAttachments 一次对话请求最多引用的文件数
+const maxAttachments = 10
+
+type fileController struct {
+	FileManager file.Manager `autowired:""`
+}
+
+func NewFileController() controller.FileController {
+	return &fileController{}
+}
+
+func (c *fileController) UploadFile(request *apiv1.UploadFileRequest) (*apiv1.File, e.ApiError) {
+	// 解码前按base64编码后的长度检查大小，避免解码过大的内容
+	if maxSize := constants.Prop.File.MaxSize; maxSize > 0 && len(request.Content) > base64.StdEncoding.EncodedLen(int(maxSize)) {
+		return nil, convertFileError(fmt.Errorf("%w, max size is %d bytes", file.ErrFileTooLarge, maxSize))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(request.Content)
+	if err != nil {
+		return nil, e.NewApiError(e.InvalidArgument, "UploadFileRequest.Content is not valid base64", err)
+	}
+
+	f, err := c.FileManager.Upload(request.User, request.Name, data)
+	if err != nil {
+		logger.Error("upload file failed. Error: %v", err)
+		return nil, convertFileError(err)
+	}
+	return convertFile(f), nil
+}
+
+// loadAttachments 读取对话请求引用的文件，只能引用自己上传的文件
+func loadAttachments(fileManager file.Manager, user string, fileIDs []string, field string) ([]*entity.Attachment, e.ApiError) {
+	if len(fileIDs) > maxAttachments {
+		return nil, e.NewApiError(e.InvalidArgument, fmt.Sprintf("%s can not be more than %d", field, maxAttachments), nil)
+	}
+
+	attachments := make([]*entity.Attachment, 0, len(fileIDs))
+	for _, fileID := range fileIDs {
+		f, data, err := fileManager.Get(fileID, user)
+		if err != nil {
+			if errors.Is(err, file.ErrFileNotFound) {
+				return nil, e.NewApiError(e.InvalidArgument, fmt.Sprintf("%s '%s' is not found", field, fileID), err)
+			}
+			logger.Error("get file failed. Error: %v", err)
+			return nil, e.NewInternalError(err)
+		}
+		attachments = append(attachments, &entity.Attachment{FileID: f.FileID, Name: f.Name, ContentType: f.ContentType, Data: data})
+	}
+	return attachments, nil
+}
+
+func convertFileError(err error) e.ApiError {
+	switch {
+	case errors.Is(err, file.ErrFileTooLarge):
+		return e.NewApiError(constants.PayloadTooLargeError, err.Error(), err)
+	case errors.Is(err, file.ErrFileEmpty), errors.Is(err, file.ErrUnsupportedType):
+		return e.NewApiError(e.InvalidArgument, err.Error(), err)
+	default:
+		return e.NewInternalError(err)
+	}
+}
+
+func convertFile(f *file.File) *apiv1.File {
+	return &apiv1.File{
+		FileID:      f.FileID,
+		Name:        f.Name,
+		ContentType: f.ContentType,
+		Size:        f.Size,
+		CreateTime:  f.CreateTime,
+	}
+}
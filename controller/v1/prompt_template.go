@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/controller"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/bean"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/web/e"
+)
+
+type promptTemplateController struct {
+	PromptManager prompttpl.Manager `autowired:""`
+}
+
+func NewPromptTemplateController() controller.PromptTemplateController {
+	return &promptTemplateController{}
+}
+
+func (c *promptTemplateController) CreatePromptTemplate(request *apiv1.CreatePromptTemplateRequest) (*apiv1.PromptTemplate, e.ApiError) {
+	tpl := &bean.PromptTemplate{
+		Name:       request.Name,
+		Content:    request.Content,
+		Author:     request.User,
+		ChangeNote: request.ChangeNote,
+	}
+	if err := c.PromptManager.Create(tpl); err != nil {
+		logger.Error("create prompt template failed. Error: %v", err)
+		return nil, convertPromptTemplateError(err)
+	}
+
+	return convertPromptTemplate(tpl), nil
+}
+
+func (c *promptTemplateController) DescribePromptTemplate(request *apiv1.DescribePromptTemplateRequest) (*apiv1.PromptTemplate, e.ApiError) {
+	var (
+		tpl *bean.PromptTemplate
+		err error
+	)
+	if request.Version > 0 {
+		tpl, err = c.PromptManager.GetVersion(request.Name, request.Version)
+	} else {
+		tpl, err = c.PromptManager.GetActive(request.Name)
+	}
+	if err != nil {
+		return nil, convertPromptTemplateError(err)
+	}
+
+	return convertPromptTemplate(tpl), nil
+}
+
+func (c *promptTemplateController) ListPromptTemplateVersions(request *apiv1.ListPromptTemplateVersionsRequest) ([]*apiv1.PromptTemplate, e.ApiError) {
+	tpls, err := c.PromptManager.ListVersions(request.Name)
+	if err != nil {
+		logger.Error("list prompt template versions failed. Error: %v", err)
+		return nil, e.NewInternalError(err)
+	}
+
+	res := make([]*apiv1.PromptTemplate, 0, len(tpls))
+	for _, tpl := range tpls {
+		res = append(res, convertPromptTemplate(tpl))
+	}
+	return res, nil
+}
+
+func (c *promptTemplateController) RollbackPromptTemplate(request *apiv1.RollbackPromptTemplateRequest) (*apiv1.PromptTemplate, e.ApiError) {
+	if err := c.PromptManager.Rollback(request.Name, request.Version); err != nil {
+		logger.Error("rollback prompt template failed. Error: %v", err)
+		return nil, convertPromptTemplateError(err)
+	}
+	logger.Info("prompt template %s rollback to version %d by %s", request.Name, request.Version, request.User)
+
+	tpl, err := c.PromptManager.GetActive(request.Name)
+	if err != nil {
+		return nil, convertPromptTemplateError(err)
+	}
+	return convertPromptTemplate(tpl), nil
+}
+
+func convertPromptTemplateError(err error) e.ApiError {
+	switch {
+	case errors.Is(err, prompttpl.ErrTemplateNotFound):
+		return e.NewApiError(e.NotFound, err.Error(), err)
+	case errors.Is(err, prompttpl.ErrInvalidTemplate):
+		return e.NewApiError(e.InvalidArgument, err.Error(), err)
+	case errors.Is(err, prompttpl.ErrVersionConflict):
+		return e.NewApiError(constants.ConflictError, err.Error(), err)
+	default:
+		return e.NewInternalError(err)
+	}
+}
+
+func convertPromptTemplate(tpl *bean.PromptTemplate) *apiv1.PromptTemplate {
+	return &apiv1.PromptTemplate{
+		Name:       tpl.Name,
+		Version:    tpl.Version,
+		Content:    tpl.Content,
+		Author:     tpl.Author,
+		ChangeNote: tpl.ChangeNote,
+		Active:     tpl.Active,
+		CreateTime: tpl.CreateTime,
+	}
+}
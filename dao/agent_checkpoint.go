@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+)
+
+//go:generate mockgen -destination ../internal/mock/dao/agent_checkpoint_mock.go -package dao -source agent_checkpoint.go
+type AgentCheckpointDao interface {
+	// Save 不存在时写入，存在时覆盖
+	Save(cp *bean.AgentCheckpoint) error
+	// Get 未过期的checkpoint
+	Get(checkpointID string) (*bean.AgentCheckpoint, error)
+	Delete(checkpointID string) (int64, error)
+	DeleteExpired() (int64, error)
+}
+
+type agentCheckpointDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewAgentCheckpointDao() AgentCheckpointDao {
+	return &agentCheckpointDao{}
+}
+
+func (d *agentCheckpointDao) Save(cp *bean.AgentCheckpoint) error {
+	now := time.Now()
+	cp.CreateTime = now
+	cp.UpdateTime = now
+	cp.Status = statusNormal
+
+	_, err := d.DB.GetInsert(cp, nil).
+		On("DUPLICATE KEY UPDATE").
+		Set("data=VALUES(data)").
+		Set("expire_time=VALUES(expire_time)").
+		Set("update_time=VALUES(update_time)").
+		Exec(dbv1.GetContext())
+	return err
+}
+
+func (d *agentCheckpointDao) Get(checkpointID string) (*bean.AgentCheckpoint, error) {
+	cp := &bean.AgentCheckpoint{}
+	if err := d.DB.GetSelect(cp).
+		Where("checkpoint_id=?", checkpointID).
+		Where("expire_time>?", time.Now()).
+		Limit(1).
+		Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return cp, nil
+}
+
+func (d *agentCheckpointDao) Delete(checkpointID string) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetDelete(&bean.AgentCheckpoint{}, nil).
+		Where("checkpoint_id=?", checkpointID).
+		Exec(dbv1.GetContext()))
+}
+
+func (d *agentCheckpointDao) DeleteExpired() (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetDelete(&bean.AgentCheckpoint{}, nil).
+		Where("expire_time<=?", time.Now()).
+		Exec(dbv1.GetContext()))
+}
@@ -0,0 +1,82 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+)
+
+//go:generate mockgen -destination ../internal/mock/dao/agent_definition_mock.go -package dao -source agent_definition.go
+type AgentDefinitionDao interface {
+	Insert(def *bean.AgentDefinition) error
+	Update(def *bean.AgentDefinition) (int64, error)
+	DeleteByAgentID(agentID string) (int64, error)
+	GetByAgentID(agentID string) (*bean.AgentDefinition, error)
+	List() ([]*bean.AgentDefinition, error)
+}
+
+type agentDefinitionDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewAgentDefinitionDao() AgentDefinitionDao {
+	return &agentDefinitionDao{}
+}
+
+func (d *agentDefinitionDao) Insert(def *bean.AgentDefinition) error {
+	now := time.Now()
+	def.CreateTime = now
+	def.UpdateTime = now
+	def.Status = statusNormal
+
+	_, err := d.DB.Insert(def, nil)
+	return err
+}
+
+func (d *agentDefinitionDao) Update(def *bean.AgentDefinition) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetUpdate(def, nil).
+		Set("name=?", def.Name).
+		Set("description=?", def.Description).
+		Set("persona=?", def.Persona).
+		Set("model_profile=?", def.ModelProfile).
+		Set("mode=?", def.Mode).
+		Set("flow=?", def.Flow).
+		Set("tools=?", def.Tools).
+		Set("approval_tools=?", def.ApprovalTools).
+		Set("return_directly_tools=?", def.ReturnDirectlyTools).
+		Set("knowledge_bases=?", def.KnowledgeBases).
+		Set("opening_message=?", def.OpeningMessage).
+		Set("suggest=?", def.Suggest).
+		Set("sub_agents=?", def.SubAgents).
+		Set("response_schema=?", def.ResponseSchema).
+		Where("agent_id=?", def.AgentID).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *agentDefinitionDao) DeleteByAgentID(agentID string) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetSoftDelete(&bean.AgentDefinition{}, nil).
+		Where("agent_id=?", agentID).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *agentDefinitionDao) GetByAgentID(agentID string) (*bean.AgentDefinition, error) {
+	def := &bean.AgentDefinition{}
+	if err := d.DB.GetSelect(def).Where("agent_id=?", agentID).Limit(1).Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return def, nil
+}
+
+func (d *agentDefinitionDao) List() ([]*bean.AgentDefinition, error) {
+	var defs []*bean.AgentDefinition
+	if err := d.DB.GetSelect(&defs).Order("id desc").Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return defs, nil
+}
@@ -0,0 +1,133 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+	"github.com/uptrace/bun"
+)
+
+//go:generate mockgen -destination ../internal/mock/dao/agent_run_mock.go -package dao -source agent_run.go
+type AgentRunDao interface {
+	Insert(run *bean.AgentRun) error
+	// UpdateResult 运行结束或中断后记录状态，回答和token用量在恢复运行后累加
+	UpdateResult(run *bean.AgentRun) (int64, error)
+	// UpdateState 仅当运行处于from状态时更新为to状态，返回影响的记录数
+	UpdateState(runID, from, to string) (int64, error)
+	// Claim 仅当运行处于from状态时由instance继续执行，返回影响的记录数
+	Claim(runID, from, instance string) (int64, error)
+	// ListByInstance 查询实例上处于state状态的运行
+	ListByInstance(instance, state string) ([]*bean.AgentRun, error)
+	// UpdateRating 记录用户反馈
+	UpdateRating(runID string, rating int) (int64, error)
+	GetByRunID(runID string) (*bean.AgentRun, error)
+	ListByRunIDs(runIDs []string) ([]*bean.AgentRun, error)
+	// StatByVariant 按变体聚合实验的运行统计
+	StatByVariant(experimentID string) ([]*bean.AgentRunStat, error)
+}
+
+type agentRunDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewAgentRunDao() AgentRunDao {
+	return &agentRunDao{}
+}
+
+func (d *agentRunDao) Insert(run *bean.AgentRun) error {
+	now := time.Now()
+	run.CreateTime = now
+	run.UpdateTime = now
+	run.Status = statusNormal
+
+	_, err := d.DB.Insert(run, nil)
+	return err
+}
+
+func (d *agentRunDao) UpdateResult(run *bean.AgentRun) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetUpdate(&bean.AgentRun{}, nil).
+		Set("state=?", run.State).
+		Set("pending=?", run.Pending).
+		Set("answer=CONCAT(IFNULL(answer, ''), ?)", run.Answer).
+		Set("prompt_tokens=prompt_tokens+?", run.PromptTokens).
+		Set("completion_tokens=completion_tokens+?", run.CompletionTokens).
+		Where("run_id=?", run.RunID).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *agentRunDao) UpdateState(runID, from, to string) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetUpdate(&bean.AgentRun{}, nil).
+		Set("state=?", to).
+		Where("run_id=?", runID).
+		Where("state=?", from).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *agentRunDao) Claim(runID, from, instance string) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetUpdate(&bean.AgentRun{}, nil).
+		Set("state=?", bean.RunStateRunning).
+		Set("instance=?", instance).
+		Where("run_id=?", runID).
+		Where("state=?", from).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *agentRunDao) ListByInstance(instance, state string) ([]*bean.AgentRun, error) {
+	var runs []*bean.AgentRun
+	if err := d.DB.GetSelect(&runs).Where("instance=?", instance).Where("state=?", state).Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return runs, nil
+}
+
+func (d *agentRunDao) UpdateRating(runID string, rating int) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetUpdate(&bean.AgentRun{}, nil).
+		Set("rating=?", rating).
+		Where("run_id=?", runID).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *agentRunDao) GetByRunID(runID string) (*bean.AgentRun, error) {
+	run := &bean.AgentRun{}
+	if err := d.DB.GetSelect(run).Where("run_id=?", runID).Limit(1).Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return run, nil
+}
+
+func (d *agentRunDao) ListByRunIDs(runIDs []string) ([]*bean.AgentRun, error) {
+	var runs []*bean.AgentRun
+	if len(runIDs) == 0 {
+		return runs, nil
+	}
+	if err := d.DB.GetSelect(&runs).Where("run_id in (?)", bun.In(runIDs)).Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return runs, nil
+}
+
+func (d *agentRunDao) StatByVariant(experimentID string) ([]*bean.AgentRunStat, error) {
+	var stats []*bean.AgentRunStat
+	if err := d.DB.GetSelect(&bean.AgentRun{}).
+		ColumnExpr("variant").
+		ColumnExpr("COUNT(*) AS runs").
+		ColumnExpr("COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens").
+		ColumnExpr("COALESCE(SUM(completion_tokens), 0) AS completion_tokens").
+		ColumnExpr("COALESCE(SUM(rating>0), 0) AS thumbs_up").
+		ColumnExpr("COALESCE(SUM(rating<0), 0) AS thumbs_down").
+		Where("experiment=?", experimentID).
+		Group("variant").
+		Order("variant").
+		Scan(dbv1.GetContext(), &stats); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,88 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+)
+
+//go:generate mockgen -destination ../internal/mock/dao/chat_event_mock.go -package dao -source chat_event.go
+type ChatEventDao interface {
+	Insert(event *bean.ChatEvent) error
+	// ListByRunID 运行中序号大于seq且未过期的事件，按序号排序
+	ListByRunID(runID string, seq int64) ([]*bean.ChatEvent, error)
+	// GetFirstByRequestID 请求推送的第一个未过期的事件
+	GetFirstByRequestID(requestID string) (*bean.ChatEvent, error)
+	// GetLastByRunID 运行序号最大的事件，包括已过期还没有清理的事件
+	GetLastByRunID(runID string) (*bean.ChatEvent, error)
+	DeleteExpired() (int64, error)
+}
+
+type chatEventDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewChatEventDao() ChatEventDao {
+	return &chatEventDao{}
+}
+
+func (d *chatEventDao) Insert(event *bean.ChatEvent) error {
+	now := time.Now()
+	event.CreateTime = now
+	event.UpdateTime = now
+	event.Status = statusNormal
+
+	_, err := d.DB.Insert(event, nil)
+	return err
+}
+
+func (d *chatEventDao) ListByRunID(runID string, seq int64) ([]*bean.ChatEvent, error) {
+	var events []*bean.ChatEvent
+	if err := d.DB.GetSelect(&events).
+		Where("run_id=?", runID).
+		Where("seq>?", seq).
+		Where("expire_time>?", time.Now()).
+		Order("seq").
+		Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return events, nil
+}
+
+func (d *chatEventDao) GetFirstByRequestID(requestID string) (*bean.ChatEvent, error) {
+	event := &bean.ChatEvent{}
+	if err := d.DB.GetSelect(event).
+		Where("request_id=?", requestID).
+		Where("expire_time>?", time.Now()).
+		Order("seq").
+		Limit(1).
+		Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return event, nil
+}
+
+func (d *chatEventDao) GetLastByRunID(runID string) (*bean.ChatEvent, error) {
+	event := &bean.ChatEvent{}
+	if err := d.DB.GetSelect(event).
+		Where("run_id=?", runID).
+		Order("seq desc").
+		Limit(1).
+		Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return event, nil
+}
+
+func (d *chatEventDao) DeleteExpired() (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetDelete(&bean.ChatEvent{}, nil).
+		Where("expire_time<=?", time.Now()).
+		Exec(dbv1.GetContext()))
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+)
+
+//go:generate mockgen -destination ../internal/mock/dao/experiment_mock.go -package dao -source experiment.go
+type ExperimentDao interface {
+	Insert(exp *bean.Experiment) error
+	// Stop 停止实验，返回影响的记录数
+	Stop(experimentID string) (int64, error)
+	GetByExperimentID(experimentID string) (*bean.Experiment, error)
+	List() ([]*bean.Experiment, error)
+	ListRunning() ([]*bean.Experiment, error)
+}
+
+type experimentDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewExperimentDao() ExperimentDao {
+	return &experimentDao{}
+}
+
+func (d *experimentDao) Insert(exp *bean.Experiment) error {
+	now := time.Now()
+	exp.CreateTime = now
+	exp.UpdateTime = now
+	exp.Status = statusNormal
+	exp.Running = true
+
+	_, err := d.DB.Insert(exp, nil)
+	return err
+}
+
+func (d *experimentDao) Stop(experimentID string) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetUpdate(&bean.Experiment{}, nil).
+		Set("running=?", false).
+		Where("experiment_id=?", experimentID).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *experimentDao) GetByExperimentID(experimentID string) (*bean.Experiment, error) {
+	exp := &bean.Experiment{}
+	if err := d.DB.GetSelect(exp).Where("experiment_id=?", experimentID).Limit(1).Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return exp, nil
+}
+
+func (d *experimentDao) List() ([]*bean.Experiment, error) {
+	var exps []*bean.Experiment
+	if err := d.DB.GetSelect(&exps).Order("id desc").Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return exps, nil
+}
+
+func (d *experimentDao) ListRunning() ([]*bean.Experiment, error) {
+	var exps []*bean.Experiment
+	if err := d.DB.GetSelect(&exps).Where("running=?", true).Order("id desc").Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return exps, nil
+}
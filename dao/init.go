@@ -1 +1,26 @@
 package dao
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	statusNormal = 1
+
+	// mysqlDuplicateEntry 违反唯一索引的错误码
+	mysqlDuplicateEntry = 1062
+)
+
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// parseInsertErr 违反唯一索引时返回ErrDuplicateKey，调用方可以重试或返回冲突
+func parseInsertErr(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return fmt.Errorf("%w: %s", ErrDuplicateKey, mysqlErr.Message)
+	}
+	return err
+}
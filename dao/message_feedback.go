@@ -0,0 +1,92 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+)
+
+// FeedbackFilter 反馈查询条件，零值表示不过滤
+type FeedbackFilter struct {
+	Rating    int
+	Category  string
+	AgentID   string
+	StartTime time.Time
+	EndTime   time.Time
+	Offset    int
+	Limit     int
+}
+
+//go:generate mockgen -destination ../internal/mock/dao/message_feedback_mock.go -package dao -source message_feedback.go
+type MessageFeedbackDao interface {
+	Insert(fb *bean.MessageFeedback) error
+	// Update 更新评分、分类和内容，返回影响的记录数
+	Update(fb *bean.MessageFeedback) (int64, error)
+	GetByMessageIDAndUser(messageID, user string) (*bean.MessageFeedback, error)
+	List(filter *FeedbackFilter) ([]*bean.MessageFeedback, error)
+}
+
+type messageFeedbackDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewMessageFeedbackDao() MessageFeedbackDao {
+	return &messageFeedbackDao{}
+}
+
+func (d *messageFeedbackDao) Insert(fb *bean.MessageFeedback) error {
+	now := time.Now()
+	fb.CreateTime = now
+	fb.UpdateTime = now
+	fb.Status = statusNormal
+
+	_, err := d.DB.Insert(fb, nil)
+	return err
+}
+
+func (d *messageFeedbackDao) Update(fb *bean.MessageFeedback) (int64, error) {
+	return d.DB.GetRowsAffected(d.DB.GetUpdate(&bean.MessageFeedback{}, nil).
+		Set("rating=?", fb.Rating).
+		Set("category=?", fb.Category).
+		Set("comment=?", fb.Comment).
+		Where("message_id=?", fb.MessageID).
+		Where("user=?", fb.User).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+}
+
+func (d *messageFeedbackDao) GetByMessageIDAndUser(messageID, user string) (*bean.MessageFeedback, error) {
+	fb := &bean.MessageFeedback{}
+	if err := d.DB.GetSelect(fb).Where("message_id=?", messageID).Where("user=?", user).Limit(1).Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return fb, nil
+}
+
+func (d *messageFeedbackDao) List(filter *FeedbackFilter) ([]*bean.MessageFeedback, error) {
+	var fbs []*bean.MessageFeedback
+	query := d.DB.GetSelect(&fbs)
+	if filter.Rating != 0 {
+		query.Where("rating=?", filter.Rating)
+	}
+	if filter.Category != "" {
+		query.Where("category=?", filter.Category)
+	}
+	if filter.AgentID != "" {
+		query.Where("agent_id=?", filter.AgentID)
+	}
+	if !filter.StartTime.IsZero() {
+		query.Where("create_time>=?", filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		query.Where("create_time<?", filter.EndTime)
+	}
+	if err := query.Order("id").Offset(filter.Offset).Limit(filter.Limit).Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return fbs, nil
+}
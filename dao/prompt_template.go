@@ -0,0 +1,115 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+)
+
+//go:generate mockgen -destination ../internal/mock/dao/prompt_template_mock.go -package dao -source prompt_template.go
+type PromptTemplateDao interface {
+	// InsertAndActivate 写入新版本，并将其设置为生效版本，版本已经存在时返回ErrDuplicateKey
+	InsertAndActivate(tpl *bean.PromptTemplate) error
+	// Activate 将指定版本设置为生效版本，返回影响的记录数
+	Activate(name string, version int) (int64, error)
+	GetActive(name string) (*bean.PromptTemplate, error)
+	GetVersion(name string, version int) (*bean.PromptTemplate, error)
+	GetLatestVersion(name string) (int, error)
+	ListVersions(name string) ([]*bean.PromptTemplate, error)
+}
+
+type promptTemplateDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewPromptTemplateDao() PromptTemplateDao {
+	return &promptTemplateDao{}
+}
+
+func (d *promptTemplateDao) InsertAndActivate(tpl *bean.PromptTemplate) error {
+	now := time.Now()
+	tpl.CreateTime = now
+	tpl.UpdateTime = now
+	tpl.Status = statusNormal
+	tpl.Active = true
+
+	tx, cancel, err := d.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if _, err = d.DB.GetUpdate(&bean.PromptTemplate{}, tx).
+		Set("active=?", false).
+		Where("name=?", tpl.Name).
+		Where("status>0").
+		Exec(dbv1.GetContext()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = d.DB.Insert(tpl, tx); err != nil {
+		_ = tx.Rollback()
+		return parseInsertErr(err)
+	}
+
+	return tx.Commit()
+}
+
+func (d *promptTemplateDao) Activate(name string, version int) (int64, error) {
+	tx, cancel, err := d.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	rows, err := d.DB.GetRowsAffected(d.DB.GetUpdate(&bean.PromptTemplate{}, tx).
+		Set("active=(version=?)", version).
+		Where("name=?", name).
+		Where("status>0").
+		Exec(dbv1.GetContext()))
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
+
+func (d *promptTemplateDao) GetActive(name string) (*bean.PromptTemplate, error) {
+	tpl := &bean.PromptTemplate{}
+	if err := d.DB.GetSelect(tpl).Where("name=?", name).Where("active=?", true).Limit(1).Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return tpl, nil
+}
+
+func (d *promptTemplateDao) GetVersion(name string, version int) (*bean.PromptTemplate, error) {
+	tpl := &bean.PromptTemplate{}
+	if err := d.DB.GetSelect(tpl).Where("name=?", name).Where("version=?", version).Limit(1).Scan(dbv1.GetContext()); err != nil {
+		if err = d.DB.ParseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return tpl, nil
+}
+
+func (d *promptTemplateDao) GetLatestVersion(name string) (int, error) {
+	var version int
+	if err := d.DB.GetSelect(&bean.PromptTemplate{}).ColumnExpr("COALESCE(MAX(version), 0)").Where("name=?", name).Scan(dbv1.GetContext(), &version); err != nil {
+		return 0, d.DB.ParseErr(err)
+	}
+	return version, nil
+}
+
+func (d *promptTemplateDao) ListVersions(name string) ([]*bean.PromptTemplate, error) {
+	var tpls []*bean.PromptTemplate
+	if err := d.DB.GetSelect(&tpls).Where("name=?", name).Order("version desc").Scan(dbv1.GetContext()); err != nil {
+		return nil, d.DB.ParseErr(err)
+	}
+	return tpls, nil
+}
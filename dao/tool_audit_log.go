@@ -0,0 +1,31 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	dbv1 "github.com/caiflower/common-tools/db/v1"
+)
+
+//go:generate mockgen -destination ../internal/mock/dao/tool_audit_log_mock.go -package dao -source tool_audit_log.go
+type ToolAuditLogDao interface {
+	Insert(log *bean.ToolAuditLog) error
+}
+
+type toolAuditLogDao struct {
+	DB dbv1.IDB `autowired:""`
+}
+
+func NewToolAuditLogDao() ToolAuditLogDao {
+	return &toolAuditLogDao{}
+}
+
+func (d *toolAuditLogDao) Insert(log *bean.ToolAuditLog) error {
+	now := time.Now()
+	log.CreateTime = now
+	log.UpdateTime = now
+	log.Status = statusNormal
+
+	_, err := d.DB.Insert(log, nil)
+	return err
+}
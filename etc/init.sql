@@ -0,0 +1,171 @@
+CREATE TABLE IF NOT EXISTS `agent_definition`
+(
+    `id`                    int          NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `agent_id`              varchar(64)  NOT NULL COMMENT '智能体ID',
+    `name`                  varchar(128) NOT NULL COMMENT '名称',
+    `description`           varchar(512) NOT NULL DEFAULT '' COMMENT '职责描述，监督者据此选择专家智能体',
+    `persona`               text COMMENT '人设',
+    `model_profile`         varchar(64)  NOT NULL DEFAULT '' COMMENT '模型配置名称',
+    `mode`                  varchar(32)  NOT NULL DEFAULT '' COMMENT '运行模式: react, plan_execute, flow',
+    `flow`                  varchar(64)  NOT NULL DEFAULT '' COMMENT 'flow模式运行的图名称',
+    `tools`                 json COMMENT '工具集',
+    `approval_tools`        json COMMENT '需要用户确认后才能执行的工具',
+    `return_directly_tools` json COMMENT '调用后直接把输出作为回答的工具',
+    `knowledge_bases`       json COMMENT '知识库',
+    `opening_message`       text COMMENT '开场白',
+    `suggest`               tinyint(1)   NOT NULL DEFAULT 0 COMMENT '回答后是否生成追问建议',
+    `sub_agents`            json COMMENT '专家智能体，不为空时作为监督者路由对话',
+    `response_schema`       json COMMENT '回答需要符合的JSON Schema',
+    `create_time`           datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time`           datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`                int          NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_agent_id` (`agent_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='智能体定义';
+
+CREATE TABLE IF NOT EXISTS `prompt_template`
+(
+    `id`          int          NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `name`        varchar(64)  NOT NULL COMMENT '模板名称',
+    `version`     int          NOT NULL COMMENT '版本号',
+    `content`     text         NOT NULL COMMENT '模板内容',
+    `author`      varchar(128) NOT NULL DEFAULT '' COMMENT '作者',
+    `change_note` varchar(512) NOT NULL DEFAULT '' COMMENT '变更说明',
+    `active`      tinyint(1)   NOT NULL DEFAULT 0 COMMENT '是否生效',
+    `create_time` datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`      int          NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name_version` (`name`, `version`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='提示词模板';
+
+CREATE TABLE IF NOT EXISTS `agent_run`
+(
+    `id`                int          NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `run_id`            varchar(64)  NOT NULL COMMENT '运行ID',
+    `request_id`        varchar(64)  NOT NULL DEFAULT '' COMMENT '请求ID',
+    `user`              varchar(128) NOT NULL DEFAULT '' COMMENT '用户',
+    `agent_id`          varchar(64)  NOT NULL DEFAULT '' COMMENT '智能体ID',
+    `conversation_id`   varchar(64)  NOT NULL DEFAULT '' COMMENT '会话ID',
+    `prompt_name`       varchar(64)  NOT NULL DEFAULT '' COMMENT '提示词模板名称',
+    `prompt_version`    int          NOT NULL DEFAULT 0 COMMENT '提示词模板版本',
+    `model_profile`     varchar(64)  NOT NULL DEFAULT '' COMMENT '模型配置名称',
+    `model`             varchar(128) NOT NULL DEFAULT '' COMMENT '模型',
+    `chat_protocol`     varchar(32)  NOT NULL DEFAULT '' COMMENT '未指定模型配置时使用的协议',
+    `options`           text COMMENT '请求指定的采样参数',
+    `state`             varchar(32)  NOT NULL DEFAULT '' COMMENT '运行状态',
+    `instance`          varchar(128) NOT NULL DEFAULT '' COMMENT '执行运行的实例',
+    `pending`           text COMMENT '等待确认的工具调用',
+    `input`             text COMMENT '用户输入',
+    `answer`            text COMMENT '最终回答',
+    `experiment`        varchar(64)  NOT NULL DEFAULT '' COMMENT '命中的实验ID',
+    `variant`           varchar(64)  NOT NULL DEFAULT '' COMMENT '命中的实验变体',
+    `prompt_tokens`     int          NOT NULL DEFAULT 0 COMMENT '输入token数',
+    `completion_tokens` int          NOT NULL DEFAULT 0 COMMENT '输出token数',
+    `rating`            int          NOT NULL DEFAULT 0 COMMENT '用户反馈，1赞，-1踩，0未反馈',
+    `create_time`       datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time`       datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`            int          NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_run_id` (`run_id`),
+    KEY `idx_experiment` (`experiment`),
+    KEY `idx_conversation_id` (`conversation_id`),
+    KEY `idx_instance_state` (`instance`, `state`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='智能体运行记录';
+
+CREATE TABLE IF NOT EXISTS `agent_checkpoint`
+(
+    `id`            int          NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `checkpoint_id` varchar(64)  NOT NULL COMMENT 'checkpoint ID，即运行ID',
+    `data`          mediumblob   NOT NULL COMMENT '序列化后的运行状态',
+    `expire_time`   datetime     NOT NULL COMMENT '过期时间',
+    `create_time`   datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time`   datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`        int          NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_checkpoint_id` (`checkpoint_id`),
+    KEY `idx_expire_time` (`expire_time`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='运行状态的checkpoint';
+
+CREATE TABLE IF NOT EXISTS `experiment`
+(
+    `id`            int          NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `experiment_id` varchar(64)  NOT NULL COMMENT '实验ID',
+    `name`          varchar(128) NOT NULL COMMENT '名称',
+    `agent_id`      varchar(64)  NOT NULL DEFAULT '' COMMENT '生效的智能体ID，为空时对所有智能体生效',
+    `variants`      json COMMENT '变体',
+    `running`       tinyint(1)   NOT NULL DEFAULT 1 COMMENT '是否运行中',
+    `create_time`   datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time`   datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`        int          NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_experiment_id` (`experiment_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='实验';
+
+CREATE TABLE IF NOT EXISTS `message_feedback`
+(
+    `id`             int           NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `message_id`     varchar(64)   NOT NULL COMMENT '消息ID',
+    `request_id`     varchar(64)   NOT NULL DEFAULT '' COMMENT '请求ID',
+    `user`           varchar(128)  NOT NULL COMMENT '用户',
+    `agent_id`       varchar(64)   NOT NULL DEFAULT '' COMMENT '智能体ID',
+    `model`          varchar(128)  NOT NULL DEFAULT '' COMMENT '模型',
+    `prompt_name`    varchar(64)   NOT NULL DEFAULT '' COMMENT '提示词模板名称',
+    `prompt_version` int           NOT NULL DEFAULT 0 COMMENT '提示词模板版本',
+    `rating`         int           NOT NULL COMMENT '1赞，-1踩',
+    `category`       varchar(64)   NOT NULL DEFAULT '' COMMENT '反馈分类',
+    `comment`        varchar(2000) NOT NULL DEFAULT '' COMMENT '反馈内容',
+    `create_time`    datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time`    datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`         int           NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_message_user` (`message_id`, `user`),
+    KEY `idx_create_time` (`create_time`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='用户反馈';
+
+CREATE TABLE IF NOT EXISTS `tool_audit_log`
+(
+    `id`           int           NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `run_id`       varchar(64)   NOT NULL COMMENT '运行ID',
+    `tool_call_id` varchar(64)   NOT NULL DEFAULT '' COMMENT '工具调用ID',
+    `user`         varchar(128)  NOT NULL DEFAULT '' COMMENT '用户',
+    `agent_id`     varchar(64)   NOT NULL DEFAULT '' COMMENT '智能体ID',
+    `tool`         varchar(128)  NOT NULL COMMENT '工具名称',
+    `risk`         varchar(32)   NOT NULL DEFAULT '' COMMENT '风险等级',
+    `arguments`    text COMMENT '调用参数',
+    `source`       varchar(16)   NOT NULL COMMENT '决定的来源: policy, user',
+    `rule`         varchar(128)  NOT NULL DEFAULT '' COMMENT '命中的规则，为空时为默认策略',
+    `action`       varchar(16)   NOT NULL COMMENT '策略: allow, deny, approve；用户: approve, reject, edit',
+    `reason`       varchar(2000) NOT NULL DEFAULT '' COMMENT '拒绝的原因',
+    `create_time`  datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time`  datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`       int           NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    KEY `idx_run_id` (`run_id`),
+    KEY `idx_create_time` (`create_time`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='工具调用审计日志';
+
+CREATE TABLE IF NOT EXISTS `chat_event`
+(
+    `id`          int          NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `request_id`  varchar(64)  NOT NULL DEFAULT '' COMMENT '请求ID',
+    `run_id`      varchar(64)  NOT NULL COMMENT '运行ID',
+    `seq`         bigint       NOT NULL COMMENT '事件序号，同一运行内单调递增',
+    `data`        mediumtext   NOT NULL COMMENT 'SSE格式的事件',
+    `expire_time` datetime     NOT NULL COMMENT '过期时间',
+    `create_time` datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '更新时间',
+    `status`      int          NOT NULL DEFAULT 1 COMMENT '状态',
+    PRIMARY KEY (`id`),
+    KEY `idx_run_id_seq` (`run_id`, `seq`),
+    KEY `idx_request_id` (`request_id`),
+    KEY `idx_expire_time` (`expire_time`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='推送给客户端的事件，用于断线重连时回放';
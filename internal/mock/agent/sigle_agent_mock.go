@@ -10,8 +10,10 @@
 package agent
 
 import (
+	context "context"
 	reflect "reflect"
 
+	bean "github.com/caiflower/ai-agent/model/bean"
 	entity "github.com/caiflower/ai-agent/model/entity"
 	schema "github.com/cloudwego/eino/schema"
 	gomock "go.uber.org/mock/gomock"
@@ -41,17 +43,62 @@ func (m *MockSingleAgent) EXPECT() *MockSingleAgentMockRecorder {
 	return m.recorder
 }
 
+// GetRun mocks base method.
+func (m *MockSingleAgent) GetRun(runID, user string) (*bean.AgentRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRun", runID, user)
+	ret0, _ := ret[0].(*bean.AgentRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRun indicates an expected call of GetRun.
+func (mr *MockSingleAgentMockRecorder) GetRun(runID, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRun", reflect.TypeOf((*MockSingleAgent)(nil).GetRun), runID, user)
+}
+
+// Recover mocks base method.
+func (m *MockSingleAgent) Recover() ([]*entity.RecoveredRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recover")
+	ret0, _ := ret[0].([]*entity.RecoveredRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recover indicates an expected call of Recover.
+func (mr *MockSingleAgentMockRecorder) Recover() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recover", reflect.TypeOf((*MockSingleAgent)(nil).Recover))
+}
+
+// Resume mocks base method.
+func (m *MockSingleAgent) Resume(ctx context.Context, req *entity.ResumeRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", ctx, req)
+	ret0, _ := ret[0].(*schema.StreamReader[*entity.AgentRespEvent])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockSingleAgentMockRecorder) Resume(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockSingleAgent)(nil).Resume), ctx, req)
+}
+
 // StreamExecute mocks base method.
-func (m *MockSingleAgent) StreamExecute(req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
+func (m *MockSingleAgent) StreamExecute(ctx context.Context, req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StreamExecute", req)
+	ret := m.ctrl.Call(m, "StreamExecute", ctx, req)
 	ret0, _ := ret[0].(*schema.StreamReader[*entity.AgentRespEvent])
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // StreamExecute indicates an expected call of StreamExecute.
-func (mr *MockSingleAgentMockRecorder) StreamExecute(req any) *gomock.Call {
+func (mr *MockSingleAgentMockRecorder) StreamExecute(ctx, req any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamExecute", reflect.TypeOf((*MockSingleAgent)(nil).StreamExecute), req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamExecute", reflect.TypeOf((*MockSingleAgent)(nil).StreamExecute), ctx, req)
 }
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: agent_checkpoint.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/agent_checkpoint_mock.go -package dao -source agent_checkpoint.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAgentCheckpointDao is a mock of AgentCheckpointDao interface.
+type MockAgentCheckpointDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgentCheckpointDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockAgentCheckpointDaoMockRecorder is the mock recorder for MockAgentCheckpointDao.
+type MockAgentCheckpointDaoMockRecorder struct {
+	mock *MockAgentCheckpointDao
+}
+
+// NewMockAgentCheckpointDao creates a new mock instance.
+func NewMockAgentCheckpointDao(ctrl *gomock.Controller) *MockAgentCheckpointDao {
+	mock := &MockAgentCheckpointDao{ctrl: ctrl}
+	mock.recorder = &MockAgentCheckpointDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAgentCheckpointDao) EXPECT() *MockAgentCheckpointDaoMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockAgentCheckpointDao) Delete(checkpointID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", checkpointID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAgentCheckpointDaoMockRecorder) Delete(checkpointID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAgentCheckpointDao)(nil).Delete), checkpointID)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockAgentCheckpointDao) DeleteExpired() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockAgentCheckpointDaoMockRecorder) DeleteExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockAgentCheckpointDao)(nil).DeleteExpired))
+}
+
+// Get mocks base method.
+func (m *MockAgentCheckpointDao) Get(checkpointID string) (*bean.AgentCheckpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", checkpointID)
+	ret0, _ := ret[0].(*bean.AgentCheckpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAgentCheckpointDaoMockRecorder) Get(checkpointID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAgentCheckpointDao)(nil).Get), checkpointID)
+}
+
+// Save mocks base method.
+func (m *MockAgentCheckpointDao) Save(cp *bean.AgentCheckpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", cp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockAgentCheckpointDaoMockRecorder) Save(cp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockAgentCheckpointDao)(nil).Save), cp)
+}
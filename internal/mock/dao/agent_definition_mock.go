@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: agent_definition.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/agent_definition_mock.go -package dao -source agent_definition.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAgentDefinitionDao is a mock of AgentDefinitionDao interface.
+type MockAgentDefinitionDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgentDefinitionDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockAgentDefinitionDaoMockRecorder is the mock recorder for MockAgentDefinitionDao.
+type MockAgentDefinitionDaoMockRecorder struct {
+	mock *MockAgentDefinitionDao
+}
+
+// NewMockAgentDefinitionDao creates a new mock instance.
+func NewMockAgentDefinitionDao(ctrl *gomock.Controller) *MockAgentDefinitionDao {
+	mock := &MockAgentDefinitionDao{ctrl: ctrl}
+	mock.recorder = &MockAgentDefinitionDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAgentDefinitionDao) EXPECT() *MockAgentDefinitionDaoMockRecorder {
+	return m.recorder
+}
+
+// DeleteByAgentID mocks base method.
+func (m *MockAgentDefinitionDao) DeleteByAgentID(agentID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByAgentID", agentID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByAgentID indicates an expected call of DeleteByAgentID.
+func (mr *MockAgentDefinitionDaoMockRecorder) DeleteByAgentID(agentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByAgentID", reflect.TypeOf((*MockAgentDefinitionDao)(nil).DeleteByAgentID), agentID)
+}
+
+// GetByAgentID mocks base method.
+func (m *MockAgentDefinitionDao) GetByAgentID(agentID string) (*bean.AgentDefinition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAgentID", agentID)
+	ret0, _ := ret[0].(*bean.AgentDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAgentID indicates an expected call of GetByAgentID.
+func (mr *MockAgentDefinitionDaoMockRecorder) GetByAgentID(agentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAgentID", reflect.TypeOf((*MockAgentDefinitionDao)(nil).GetByAgentID), agentID)
+}
+
+// Insert mocks base method.
+func (m *MockAgentDefinitionDao) Insert(def *bean.AgentDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", def)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockAgentDefinitionDaoMockRecorder) Insert(def any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockAgentDefinitionDao)(nil).Insert), def)
+}
+
+// List mocks base method.
+func (m *MockAgentDefinitionDao) List() ([]*bean.AgentDefinition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*bean.AgentDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAgentDefinitionDaoMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAgentDefinitionDao)(nil).List))
+}
+
+// Update mocks base method.
+func (m *MockAgentDefinitionDao) Update(def *bean.AgentDefinition) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", def)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAgentDefinitionDaoMockRecorder) Update(def any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAgentDefinitionDao)(nil).Update), def)
+}
@@ -0,0 +1,175 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: agent_run.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/agent_run_mock.go -package dao -source agent_run.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAgentRunDao is a mock of AgentRunDao interface.
+type MockAgentRunDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgentRunDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockAgentRunDaoMockRecorder is the mock recorder for MockAgentRunDao.
+type MockAgentRunDaoMockRecorder struct {
+	mock *MockAgentRunDao
+}
+
+// NewMockAgentRunDao creates a new mock instance.
+func NewMockAgentRunDao(ctrl *gomock.Controller) *MockAgentRunDao {
+	mock := &MockAgentRunDao{ctrl: ctrl}
+	mock.recorder = &MockAgentRunDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAgentRunDao) EXPECT() *MockAgentRunDaoMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockAgentRunDao) Claim(runID, from, instance string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", runID, from, instance)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockAgentRunDaoMockRecorder) Claim(runID, from, instance any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockAgentRunDao)(nil).Claim), runID, from, instance)
+}
+
+// GetByRunID mocks base method.
+func (m *MockAgentRunDao) GetByRunID(runID string) (*bean.AgentRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRunID", runID)
+	ret0, _ := ret[0].(*bean.AgentRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRunID indicates an expected call of GetByRunID.
+func (mr *MockAgentRunDaoMockRecorder) GetByRunID(runID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRunID", reflect.TypeOf((*MockAgentRunDao)(nil).GetByRunID), runID)
+}
+
+// Insert mocks base method.
+func (m *MockAgentRunDao) Insert(run *bean.AgentRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockAgentRunDaoMockRecorder) Insert(run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockAgentRunDao)(nil).Insert), run)
+}
+
+// ListByInstance mocks base method.
+func (m *MockAgentRunDao) ListByInstance(instance, state string) ([]*bean.AgentRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByInstance", instance, state)
+	ret0, _ := ret[0].([]*bean.AgentRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByInstance indicates an expected call of ListByInstance.
+func (mr *MockAgentRunDaoMockRecorder) ListByInstance(instance, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByInstance", reflect.TypeOf((*MockAgentRunDao)(nil).ListByInstance), instance, state)
+}
+
+// ListByRunIDs mocks base method.
+func (m *MockAgentRunDao) ListByRunIDs(runIDs []string) ([]*bean.AgentRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByRunIDs", runIDs)
+	ret0, _ := ret[0].([]*bean.AgentRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByRunIDs indicates an expected call of ListByRunIDs.
+func (mr *MockAgentRunDaoMockRecorder) ListByRunIDs(runIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByRunIDs", reflect.TypeOf((*MockAgentRunDao)(nil).ListByRunIDs), runIDs)
+}
+
+// StatByVariant mocks base method.
+func (m *MockAgentRunDao) StatByVariant(experimentID string) ([]*bean.AgentRunStat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatByVariant", experimentID)
+	ret0, _ := ret[0].([]*bean.AgentRunStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatByVariant indicates an expected call of StatByVariant.
+func (mr *MockAgentRunDaoMockRecorder) StatByVariant(experimentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatByVariant", reflect.TypeOf((*MockAgentRunDao)(nil).StatByVariant), experimentID)
+}
+
+// UpdateRating mocks base method.
+func (m *MockAgentRunDao) UpdateRating(runID string, rating int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRating", runID, rating)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRating indicates an expected call of UpdateRating.
+func (mr *MockAgentRunDaoMockRecorder) UpdateRating(runID, rating any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRating", reflect.TypeOf((*MockAgentRunDao)(nil).UpdateRating), runID, rating)
+}
+
+// UpdateResult mocks base method.
+func (m *MockAgentRunDao) UpdateResult(run *bean.AgentRun) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateResult", run)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateResult indicates an expected call of UpdateResult.
+func (mr *MockAgentRunDaoMockRecorder) UpdateResult(run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResult", reflect.TypeOf((*MockAgentRunDao)(nil).UpdateResult), run)
+}
+
+// UpdateState mocks base method.
+func (m *MockAgentRunDao) UpdateState(runID, from, to string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateState", runID, from, to)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateState indicates an expected call of UpdateState.
+func (mr *MockAgentRunDaoMockRecorder) UpdateState(runID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateState", reflect.TypeOf((*MockAgentRunDao)(nil).UpdateState), runID, from, to)
+}
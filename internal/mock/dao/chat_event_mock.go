@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: chat_event.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/chat_event_mock.go -package dao -source chat_event.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockChatEventDao is a mock of ChatEventDao interface.
+type MockChatEventDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockChatEventDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockChatEventDaoMockRecorder is the mock recorder for MockChatEventDao.
+type MockChatEventDaoMockRecorder struct {
+	mock *MockChatEventDao
+}
+
+// NewMockChatEventDao creates a new mock instance.
+func NewMockChatEventDao(ctrl *gomock.Controller) *MockChatEventDao {
+	mock := &MockChatEventDao{ctrl: ctrl}
+	mock.recorder = &MockChatEventDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChatEventDao) EXPECT() *MockChatEventDaoMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpired mocks base method.
+func (m *MockChatEventDao) DeleteExpired() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockChatEventDaoMockRecorder) DeleteExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockChatEventDao)(nil).DeleteExpired))
+}
+
+// GetFirstByRequestID mocks base method.
+func (m *MockChatEventDao) GetFirstByRequestID(requestID string) (*bean.ChatEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirstByRequestID", requestID)
+	ret0, _ := ret[0].(*bean.ChatEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirstByRequestID indicates an expected call of GetFirstByRequestID.
+func (mr *MockChatEventDaoMockRecorder) GetFirstByRequestID(requestID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirstByRequestID", reflect.TypeOf((*MockChatEventDao)(nil).GetFirstByRequestID), requestID)
+}
+
+// GetLastByRunID mocks base method.
+func (m *MockChatEventDao) GetLastByRunID(runID string) (*bean.ChatEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastByRunID", runID)
+	ret0, _ := ret[0].(*bean.ChatEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastByRunID indicates an expected call of GetLastByRunID.
+func (mr *MockChatEventDaoMockRecorder) GetLastByRunID(runID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastByRunID", reflect.TypeOf((*MockChatEventDao)(nil).GetLastByRunID), runID)
+}
+
+// Insert mocks base method.
+func (m *MockChatEventDao) Insert(event *bean.ChatEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockChatEventDaoMockRecorder) Insert(event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockChatEventDao)(nil).Insert), event)
+}
+
+// ListByRunID mocks base method.
+func (m *MockChatEventDao) ListByRunID(runID string, seq int64) ([]*bean.ChatEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByRunID", runID, seq)
+	ret0, _ := ret[0].([]*bean.ChatEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByRunID indicates an expected call of ListByRunID.
+func (mr *MockChatEventDaoMockRecorder) ListByRunID(runID, seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByRunID", reflect.TypeOf((*MockChatEventDao)(nil).ListByRunID), runID, seq)
+}
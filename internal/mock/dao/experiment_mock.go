@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: experiment.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/experiment_mock.go -package dao -source experiment.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockExperimentDao is a mock of ExperimentDao interface.
+type MockExperimentDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockExperimentDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockExperimentDaoMockRecorder is the mock recorder for MockExperimentDao.
+type MockExperimentDaoMockRecorder struct {
+	mock *MockExperimentDao
+}
+
+// NewMockExperimentDao creates a new mock instance.
+func NewMockExperimentDao(ctrl *gomock.Controller) *MockExperimentDao {
+	mock := &MockExperimentDao{ctrl: ctrl}
+	mock.recorder = &MockExperimentDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExperimentDao) EXPECT() *MockExperimentDaoMockRecorder {
+	return m.recorder
+}
+
+// GetByExperimentID mocks base method.
+func (m *MockExperimentDao) GetByExperimentID(experimentID string) (*bean.Experiment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByExperimentID", experimentID)
+	ret0, _ := ret[0].(*bean.Experiment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByExperimentID indicates an expected call of GetByExperimentID.
+func (mr *MockExperimentDaoMockRecorder) GetByExperimentID(experimentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByExperimentID", reflect.TypeOf((*MockExperimentDao)(nil).GetByExperimentID), experimentID)
+}
+
+// Insert mocks base method.
+func (m *MockExperimentDao) Insert(exp *bean.Experiment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockExperimentDaoMockRecorder) Insert(exp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockExperimentDao)(nil).Insert), exp)
+}
+
+// List mocks base method.
+func (m *MockExperimentDao) List() ([]*bean.Experiment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*bean.Experiment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockExperimentDaoMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockExperimentDao)(nil).List))
+}
+
+// ListRunning mocks base method.
+func (m *MockExperimentDao) ListRunning() ([]*bean.Experiment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRunning")
+	ret0, _ := ret[0].([]*bean.Experiment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRunning indicates an expected call of ListRunning.
+func (mr *MockExperimentDaoMockRecorder) ListRunning() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRunning", reflect.TypeOf((*MockExperimentDao)(nil).ListRunning))
+}
+
+// Stop mocks base method.
+func (m *MockExperimentDao) Stop(experimentID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop", experimentID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockExperimentDaoMockRecorder) Stop(experimentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockExperimentDao)(nil).Stop), experimentID)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_feedback.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/message_feedback_mock.go -package dao -source message_feedback.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	dao "github.com/caiflower/ai-agent/dao"
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageFeedbackDao is a mock of MessageFeedbackDao interface.
+type MockMessageFeedbackDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageFeedbackDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockMessageFeedbackDaoMockRecorder is the mock recorder for MockMessageFeedbackDao.
+type MockMessageFeedbackDaoMockRecorder struct {
+	mock *MockMessageFeedbackDao
+}
+
+// NewMockMessageFeedbackDao creates a new mock instance.
+func NewMockMessageFeedbackDao(ctrl *gomock.Controller) *MockMessageFeedbackDao {
+	mock := &MockMessageFeedbackDao{ctrl: ctrl}
+	mock.recorder = &MockMessageFeedbackDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageFeedbackDao) EXPECT() *MockMessageFeedbackDaoMockRecorder {
+	return m.recorder
+}
+
+// GetByMessageIDAndUser mocks base method.
+func (m *MockMessageFeedbackDao) GetByMessageIDAndUser(messageID, user string) (*bean.MessageFeedback, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByMessageIDAndUser", messageID, user)
+	ret0, _ := ret[0].(*bean.MessageFeedback)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByMessageIDAndUser indicates an expected call of GetByMessageIDAndUser.
+func (mr *MockMessageFeedbackDaoMockRecorder) GetByMessageIDAndUser(messageID, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByMessageIDAndUser", reflect.TypeOf((*MockMessageFeedbackDao)(nil).GetByMessageIDAndUser), messageID, user)
+}
+
+// Insert mocks base method.
+func (m *MockMessageFeedbackDao) Insert(fb *bean.MessageFeedback) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", fb)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockMessageFeedbackDaoMockRecorder) Insert(fb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockMessageFeedbackDao)(nil).Insert), fb)
+}
+
+// List mocks base method.
+func (m *MockMessageFeedbackDao) List(filter *dao.FeedbackFilter) ([]*bean.MessageFeedback, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter)
+	ret0, _ := ret[0].([]*bean.MessageFeedback)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockMessageFeedbackDaoMockRecorder) List(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockMessageFeedbackDao)(nil).List), filter)
+}
+
+// Update mocks base method.
+func (m *MockMessageFeedbackDao) Update(fb *bean.MessageFeedback) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", fb)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockMessageFeedbackDaoMockRecorder) Update(fb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMessageFeedbackDao)(nil).Update), fb)
+}
@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: prompt_template.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/prompt_template_mock.go -package dao -source prompt_template.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPromptTemplateDao is a mock of PromptTemplateDao interface.
+type MockPromptTemplateDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromptTemplateDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockPromptTemplateDaoMockRecorder is the mock recorder for MockPromptTemplateDao.
+type MockPromptTemplateDaoMockRecorder struct {
+	mock *MockPromptTemplateDao
+}
+
+// NewMockPromptTemplateDao creates a new mock instance.
+func NewMockPromptTemplateDao(ctrl *gomock.Controller) *MockPromptTemplateDao {
+	mock := &MockPromptTemplateDao{ctrl: ctrl}
+	mock.recorder = &MockPromptTemplateDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromptTemplateDao) EXPECT() *MockPromptTemplateDaoMockRecorder {
+	return m.recorder
+}
+
+// Activate mocks base method.
+func (m *MockPromptTemplateDao) Activate(name string, version int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Activate", name, version)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Activate indicates an expected call of Activate.
+func (mr *MockPromptTemplateDaoMockRecorder) Activate(name, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Activate", reflect.TypeOf((*MockPromptTemplateDao)(nil).Activate), name, version)
+}
+
+// GetActive mocks base method.
+func (m *MockPromptTemplateDao) GetActive(name string) (*bean.PromptTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive", name)
+	ret0, _ := ret[0].(*bean.PromptTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive.
+func (mr *MockPromptTemplateDaoMockRecorder) GetActive(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockPromptTemplateDao)(nil).GetActive), name)
+}
+
+// GetLatestVersion mocks base method.
+func (m *MockPromptTemplateDao) GetLatestVersion(name string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestVersion", name)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestVersion indicates an expected call of GetLatestVersion.
+func (mr *MockPromptTemplateDaoMockRecorder) GetLatestVersion(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestVersion", reflect.TypeOf((*MockPromptTemplateDao)(nil).GetLatestVersion), name)
+}
+
+// GetVersion mocks base method.
+func (m *MockPromptTemplateDao) GetVersion(name string, version int) (*bean.PromptTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", name, version)
+	ret0, _ := ret[0].(*bean.PromptTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockPromptTemplateDaoMockRecorder) GetVersion(name, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockPromptTemplateDao)(nil).GetVersion), name, version)
+}
+
+// InsertAndActivate mocks base method.
+func (m *MockPromptTemplateDao) InsertAndActivate(tpl *bean.PromptTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertAndActivate", tpl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertAndActivate indicates an expected call of InsertAndActivate.
+func (mr *MockPromptTemplateDaoMockRecorder) InsertAndActivate(tpl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertAndActivate", reflect.TypeOf((*MockPromptTemplateDao)(nil).InsertAndActivate), tpl)
+}
+
+// ListVersions mocks base method.
+func (m *MockPromptTemplateDao) ListVersions(name string) ([]*bean.PromptTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVersions", name)
+	ret0, _ := ret[0].([]*bean.PromptTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVersions indicates an expected call of ListVersions.
+func (mr *MockPromptTemplateDaoMockRecorder) ListVersions(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVersions", reflect.TypeOf((*MockPromptTemplateDao)(nil).ListVersions), name)
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: tool_audit_log.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../internal/mock/dao/tool_audit_log_mock.go -package dao -source tool_audit_log.go
+//
+
+// Package dao is a generated GoMock package.
+package dao
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockToolAuditLogDao is a mock of ToolAuditLogDao interface.
+type MockToolAuditLogDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockToolAuditLogDaoMockRecorder
+	isgomock struct{}
+}
+
+// MockToolAuditLogDaoMockRecorder is the mock recorder for MockToolAuditLogDao.
+type MockToolAuditLogDaoMockRecorder struct {
+	mock *MockToolAuditLogDao
+}
+
+// NewMockToolAuditLogDao creates a new mock instance.
+func NewMockToolAuditLogDao(ctrl *gomock.Controller) *MockToolAuditLogDao {
+	mock := &MockToolAuditLogDao{ctrl: ctrl}
+	mock.recorder = &MockToolAuditLogDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockToolAuditLogDao) EXPECT() *MockToolAuditLogDaoMockRecorder {
+	return m.recorder
+}
+
+// Insert mocks base method.
+func (m *MockToolAuditLogDao) Insert(log *bean.ToolAuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", log)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockToolAuditLogDaoMockRecorder) Insert(log any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockToolAuditLogDao)(nil).Insert), log)
+}
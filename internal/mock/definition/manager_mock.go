@@ -0,0 +1,113 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: manager.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../../internal/mock/definition/manager_mock.go -package definition -source manager.go
+//
+
+// Package definition is a generated GoMock package.
+package definition
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockManager is a mock of Manager interface.
+type MockManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockManagerMockRecorder is the mock recorder for MockManager.
+type MockManagerMockRecorder struct {
+	mock *MockManager
+}
+
+// NewMockManager creates a new mock instance.
+func NewMockManager(ctrl *gomock.Controller) *MockManager {
+	mock := &MockManager{ctrl: ctrl}
+	mock.recorder = &MockManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManager) EXPECT() *MockManagerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockManager) Create(def *bean.AgentDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", def)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockManagerMockRecorder) Create(def any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockManager)(nil).Create), def)
+}
+
+// Delete mocks base method.
+func (m *MockManager) Delete(agentID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", agentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockManagerMockRecorder) Delete(agentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockManager)(nil).Delete), agentID)
+}
+
+// Get mocks base method.
+func (m *MockManager) Get(agentID string) (*bean.AgentDefinition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", agentID)
+	ret0, _ := ret[0].(*bean.AgentDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockManagerMockRecorder) Get(agentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockManager)(nil).Get), agentID)
+}
+
+// List mocks base method.
+func (m *MockManager) List() ([]*bean.AgentDefinition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*bean.AgentDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockManagerMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockManager)(nil).List))
+}
+
+// Update mocks base method.
+func (m *MockManager) Update(def *bean.AgentDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", def)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockManagerMockRecorder) Update(def any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockManager)(nil).Update), def)
+}
@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: manager.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../../internal/mock/experiment/manager_mock.go -package experiment -source manager.go
+//
+
+// Package experiment is a generated GoMock package.
+package experiment
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	experiment "github.com/caiflower/ai-agent/service/experiment"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockManager is a mock of Manager interface.
+type MockManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockManagerMockRecorder is the mock recorder for MockManager.
+type MockManagerMockRecorder struct {
+	mock *MockManager
+}
+
+// NewMockManager creates a new mock instance.
+func NewMockManager(ctrl *gomock.Controller) *MockManager {
+	mock := &MockManager{ctrl: ctrl}
+	mock.recorder = &MockManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManager) EXPECT() *MockManagerMockRecorder {
+	return m.recorder
+}
+
+// Assign mocks base method.
+func (m *MockManager) Assign(agentID, user string) (*experiment.Assignment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Assign", agentID, user)
+	ret0, _ := ret[0].(*experiment.Assignment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Assign indicates an expected call of Assign.
+func (mr *MockManagerMockRecorder) Assign(agentID, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assign", reflect.TypeOf((*MockManager)(nil).Assign), agentID, user)
+}
+
+// Create mocks base method.
+func (m *MockManager) Create(exp *bean.Experiment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockManagerMockRecorder) Create(exp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockManager)(nil).Create), exp)
+}
+
+// Get mocks base method.
+func (m *MockManager) Get(experimentID string) (*bean.Experiment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", experimentID)
+	ret0, _ := ret[0].(*bean.Experiment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockManagerMockRecorder) Get(experimentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockManager)(nil).Get), experimentID)
+}
+
+// List mocks base method.
+func (m *MockManager) List() ([]*bean.Experiment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*bean.Experiment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockManagerMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockManager)(nil).List))
+}
+
+// Report mocks base method.
+func (m *MockManager) Report(experimentID string) ([]*bean.AgentRunStat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", experimentID)
+	ret0, _ := ret[0].([]*bean.AgentRunStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockManagerMockRecorder) Report(experimentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockManager)(nil).Report), experimentID)
+}
+
+// Stop mocks base method.
+func (m *MockManager) Stop(experimentID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop", experimentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockManagerMockRecorder) Stop(experimentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockManager)(nil).Stop), experimentID)
+}
@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: manager.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../../internal/mock/feedback/manager_mock.go -package feedback -source manager.go
+//
+
+// Package feedback is a generated GoMock package.
+package feedback
+
+import (
+	reflect "reflect"
+
+	dao "github.com/caiflower/ai-agent/dao"
+	bean "github.com/caiflower/ai-agent/model/bean"
+	feedback "github.com/caiflower/ai-agent/service/feedback"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockManager is a mock of Manager interface.
+type MockManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockManagerMockRecorder is the mock recorder for MockManager.
+type MockManagerMockRecorder struct {
+	mock *MockManager
+}
+
+// NewMockManager creates a new mock instance.
+func NewMockManager(ctrl *gomock.Controller) *MockManager {
+	mock := &MockManager{ctrl: ctrl}
+	mock.recorder = &MockManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManager) EXPECT() *MockManagerMockRecorder {
+	return m.recorder
+}
+
+// Export mocks base method.
+func (m *MockManager) Export(filter *dao.FeedbackFilter) ([]*feedback.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", filter)
+	ret0, _ := ret[0].([]*feedback.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockManagerMockRecorder) Export(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockManager)(nil).Export), filter)
+}
+
+// Submit mocks base method.
+func (m *MockManager) Submit(fb *bean.MessageFeedback) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", fb)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockManagerMockRecorder) Submit(fb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockManager)(nil).Submit), fb)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: manager.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../../internal/mock/flow/manager_mock.go -package flow -source manager.go
+//
+
+// Package flow is a generated GoMock package.
+package flow
+
+import (
+	reflect "reflect"
+
+	flow "github.com/caiflower/ai-agent/service/flow"
+	compose "github.com/cloudwego/eino/compose"
+	schema "github.com/cloudwego/eino/schema"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockManager is a mock of Manager interface.
+type MockManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockManagerMockRecorder is the mock recorder for MockManager.
+type MockManagerMockRecorder struct {
+	mock *MockManager
+}
+
+// NewMockManager creates a new mock instance.
+func NewMockManager(ctrl *gomock.Controller) *MockManager {
+	mock := &MockManager{ctrl: ctrl}
+	mock.recorder = &MockManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManager) EXPECT() *MockManagerMockRecorder {
+	return m.recorder
+}
+
+// Exist mocks base method.
+func (m *MockManager) Exist(name string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exist", name)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Exist indicates an expected call of Exist.
+func (mr *MockManagerMockRecorder) Exist(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exist", reflect.TypeOf((*MockManager)(nil).Exist), name)
+}
+
+// Graph mocks base method.
+func (m *MockManager) Graph(name, answerNodeName string, wrapTools flow.ToolWrapper) (*compose.Graph[map[string]any, *schema.Message], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Graph", name, answerNodeName, wrapTools)
+	ret0, _ := ret[0].(*compose.Graph[map[string]any, *schema.Message])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Graph indicates an expected call of Graph.
+func (mr *MockManagerMockRecorder) Graph(name, answerNodeName, wrapTools any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Graph", reflect.TypeOf((*MockManager)(nil).Graph), name, answerNodeName, wrapTools)
+}
+
+// List mocks base method.
+func (m *MockManager) List() []*flow.Definition {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*flow.Definition)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockManagerMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockManager)(nil).List))
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: engine.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../../internal/mock/policy/engine_mock.go -package policy -source engine.go
+//
+
+// Package policy is a generated GoMock package.
+package policy
+
+import (
+	reflect "reflect"
+
+	entity "github.com/caiflower/ai-agent/model/entity"
+	policy "github.com/caiflower/ai-agent/service/policy"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEngine is a mock of Engine interface.
+type MockEngine struct {
+	ctrl     *gomock.Controller
+	recorder *MockEngineMockRecorder
+	isgomock struct{}
+}
+
+// MockEngineMockRecorder is the mock recorder for MockEngine.
+type MockEngineMockRecorder struct {
+	mock *MockEngine
+}
+
+// NewMockEngine creates a new mock instance.
+func NewMockEngine(ctrl *gomock.Controller) *MockEngine {
+	mock := &MockEngine{ctrl: ctrl}
+	mock.recorder = &MockEngineMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEngine) EXPECT() *MockEngineMockRecorder {
+	return m.recorder
+}
+
+// Evaluate mocks base method.
+func (m *MockEngine) Evaluate(call *policy.ToolCall) *policy.Decision {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Evaluate", call)
+	ret0, _ := ret[0].(*policy.Decision)
+	return ret0
+}
+
+// Evaluate indicates an expected call of Evaluate.
+func (mr *MockEngineMockRecorder) Evaluate(call any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Evaluate", reflect.TypeOf((*MockEngine)(nil).Evaluate), call)
+}
+
+// Record mocks base method.
+func (m *MockEngine) Record(call *policy.ToolCall, source string, decision *policy.Decision) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", call, source, decision)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockEngineMockRecorder) Record(call, source, decision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockEngine)(nil).Record), call, source, decision)
+}
+
+// RecordUserDecision mocks base method.
+func (m *MockEngine) RecordUserDecision(call *policy.ToolCall, decision *entity.ToolDecision) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordUserDecision", call, decision)
+}
+
+// RecordUserDecision indicates an expected call of RecordUserDecision.
+func (mr *MockEngineMockRecorder) RecordUserDecision(call, decision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordUserDecision", reflect.TypeOf((*MockEngine)(nil).RecordUserDecision), call, decision)
+}
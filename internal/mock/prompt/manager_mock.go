@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: manager.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../../internal/mock/prompt/manager_mock.go -package prompttpl -source manager.go
+//
+
+// Package prompttpl is a generated GoMock package.
+package prompttpl
+
+import (
+	reflect "reflect"
+
+	bean "github.com/caiflower/ai-agent/model/bean"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockManager is a mock of Manager interface.
+type MockManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockManagerMockRecorder is the mock recorder for MockManager.
+type MockManagerMockRecorder struct {
+	mock *MockManager
+}
+
+// NewMockManager creates a new mock instance.
+func NewMockManager(ctrl *gomock.Controller) *MockManager {
+	mock := &MockManager{ctrl: ctrl}
+	mock.recorder = &MockManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManager) EXPECT() *MockManagerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockManager) Create(tpl *bean.PromptTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", tpl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockManagerMockRecorder) Create(tpl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockManager)(nil).Create), tpl)
+}
+
+// GetActive mocks base method.
+func (m *MockManager) GetActive(name string) (*bean.PromptTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive", name)
+	ret0, _ := ret[0].(*bean.PromptTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive.
+func (mr *MockManagerMockRecorder) GetActive(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockManager)(nil).GetActive), name)
+}
+
+// GetVersion mocks base method.
+func (m *MockManager) GetVersion(name string, version int) (*bean.PromptTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", name, version)
+	ret0, _ := ret[0].(*bean.PromptTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockManagerMockRecorder) GetVersion(name, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockManager)(nil).GetVersion), name, version)
+}
+
+// ListVersions mocks base method.
+func (m *MockManager) ListVersions(name string) ([]*bean.PromptTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVersions", name)
+	ret0, _ := ret[0].([]*bean.PromptTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVersions indicates an expected call of ListVersions.
+func (mr *MockManagerMockRecorder) ListVersions(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVersions", reflect.TypeOf((*MockManager)(nil).ListVersions), name)
+}
+
+// Rollback mocks base method.
+func (m *MockManager) Rollback(name string, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback", name, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockManagerMockRecorder) Rollback(name, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockManager)(nil).Rollback), name, version)
+}
+
+// Validate mocks base method.
+func (m *MockManager) Validate(content string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockManagerMockRecorder) Validate(content any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockManager)(nil).Validate), content)
+}
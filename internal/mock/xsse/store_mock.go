@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store.go
+//
+// Generated by this command:
+//
+//	mockgen -destination ../../internal/mock/xsse/store_mock.go -package xsse -source store.go
+//
+
+// Package xsse is a generated GoMock package.
+package xsse
+
+import (
+	reflect "reflect"
+
+	sse "github.com/tmaxmax/go-sse"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockStore) Append(requestID string, msg *sse.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", requestID, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockStoreMockRecorder) Append(requestID, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockStore)(nil).Append), requestID, msg)
+}
+
+// LastSeq mocks base method.
+func (m *MockStore) LastSeq(runID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastSeq", runID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastSeq indicates an expected call of LastSeq.
+func (mr *MockStoreMockRecorder) LastSeq(runID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastSeq", reflect.TypeOf((*MockStore)(nil).LastSeq), runID)
+}
+
+// Lookup mocks base method.
+func (m *MockStore) Lookup(requestID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", requestID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockStoreMockRecorder) Lookup(requestID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockStore)(nil).Lookup), requestID)
+}
+
+// Range mocks base method.
+func (m *MockStore) Range(runID string, seq int64) ([]*sse.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Range", runID, seq)
+	ret0, _ := ret[0].([]*sse.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Range indicates an expected call of Range.
+func (mr *MockStoreMockRecorder) Range(runID, seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Range", reflect.TypeOf((*MockStore)(nil).Range), runID, seq)
+}
@@ -4,9 +4,21 @@ import (
 	"fmt"
 
 	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/controller"
 	"github.com/caiflower/ai-agent/controller/v1"
+	"github.com/caiflower/ai-agent/dao"
 	"github.com/caiflower/ai-agent/service/agent"
+	"github.com/caiflower/ai-agent/service/checkpoint"
+	"github.com/caiflower/ai-agent/service/definition"
+	"github.com/caiflower/ai-agent/service/experiment"
+	"github.com/caiflower/ai-agent/service/feedback"
+	"github.com/caiflower/ai-agent/service/file"
+	"github.com/caiflower/ai-agent/service/flow"
+	"github.com/caiflower/ai-agent/service/knowledge"
 	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/policy"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/ai-agent/service/toolkit"
 	"github.com/caiflower/ai-agent/service/xsse"
 	"github.com/caiflower/ai-agent/web"
 	"github.com/caiflower/common-tools/cluster"
@@ -32,16 +44,25 @@ func init() {
 	setBean()
 
 	//initCluster()
+	initDatabase()
 
 	// 依赖注入
 	bean.Ioc()
 }
 
+var agentController controller.AgentController
+
 func addController() {
 	webv1.AddController(v1.NewHealthController())
-	agentController := v1.NewAgentController()
+	agentController = v1.NewAgentController()
 	webv1.AddController(agentController)
 	global.DefaultResourceManger.Add(agentController)
+	webv1.AddController(v1.NewChatCompletionController())
+	webv1.AddController(v1.NewAgentDefinitionController())
+	webv1.AddController(v1.NewPromptTemplateController())
+	webv1.AddController(v1.NewExperimentController())
+	webv1.AddController(v1.NewFeedbackController())
+	webv1.AddController(v1.NewFileController())
 }
 
 func setBean() {
@@ -49,9 +70,44 @@ func setBean() {
 	bean.AddBean(client)
 
 	// init dao
+	bean.AddBean(dao.NewAgentDefinitionDao())
+	bean.AddBean(dao.NewPromptTemplateDao())
+	bean.AddBean(dao.NewAgentRunDao())
+	bean.AddBean(dao.NewExperimentDao())
+	bean.AddBean(dao.NewMessageFeedbackDao())
+	bean.AddBean(dao.NewAgentCheckpointDao())
+	bean.AddBean(dao.NewToolAuditLogDao())
+	bean.AddBean(dao.NewChatEventDao())
 
 	// init entity
+	bean.AddBean(toolkit.NewRegistry())
+	bean.AddBean(knowledge.NewRegistry())
+	bean.AddBean(flow.NewRegistry())
+	bean.AddBean(flow.NewManager())
+	bean.AddBean(policy.NewEngine())
+	bean.AddBean(definition.NewManager())
+	bean.AddBean(prompttpl.NewManager())
+	bean.AddBean(experiment.NewManager())
+	bean.AddBean(feedback.NewManager())
+	checkpointStore, err := checkpoint.NewStore()
+	if err != nil {
+		panic(fmt.Sprintf("Init checkpoint store failed. %s", err.Error()))
+	}
+	bean.AddBean(checkpointStore)
 	bean.AddBean(xsse.NewSSEProvider())
+	if constants.Prop.SSE.ReplayStore == xsse.StoreOfRedis {
+		initRedis()
+	}
+	replayStore, err := xsse.NewStore()
+	if err != nil {
+		panic(fmt.Sprintf("Init sse replay store failed. %s", err.Error()))
+	}
+	bean.AddBean(replayStore)
+	fileManager, err := file.NewManager()
+	if err != nil {
+		panic(fmt.Sprintf("Init file manager failed. %s", err.Error()))
+	}
+	bean.AddBean(fileManager)
 	bean.AddBean(agent.NewAgentRuntime())
 	bean.AddBean(agent.NewSingleAgent())
 	bean.AddBean(chatmodel.NewDefaultFactory())
@@ -96,6 +152,8 @@ func initKafka() {
 func main() {
 	// webserver
 	web.StartUp()
+	// 继续上次退出时未完成的运行
+	agentController.Recover()
 	// Signal
 	global.DefaultResourceManger.Signal()
 }
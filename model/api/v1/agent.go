@@ -1,6 +1,8 @@
 package apiv1
 
 import (
+	"encoding/json"
+
 	"github.com/caiflower/ai-agent/model/api"
 	"github.com/caiflower/ai-agent/model/entity"
 	chatmodel "github.com/caiflower/ai-agent/service/model"
@@ -10,8 +12,113 @@ import (
 type ChatRequest struct {
 	api.Request
 	web.Context
-	Input        string             `verf:""`
-	ChatProtocol chatmodel.Protocol `inList:"mock,ollama" verf:""`
+	AgentID      string               `param:"agentId"`
+	Input        string               `verf:""`
+	ChatProtocol chatmodel.Protocol   `inList:"mock,ollama" verf:""`
+	Reasoning    entity.ReasoningMode `inList:"show,hide,disable" verf:"nilable"` // 为空时输出推理内容
+	Stream       *bool                `default:"true"`                            // 为false时等待运行结束后返回ChatResult
+}
+
+// PostChatRequest POST /v1/chat的请求，Messages的最后一条为用户输入，之前的消息作为历史
+type PostChatRequest struct {
+	api.Request
+	web.Context
+	ConversationID string               `len:",64"`
+	AgentID        string               `len:",64"`
+	ModelProfile   string               // 为空时使用智能体定义的模型配置
+	ChatProtocol   chatmodel.Protocol   `inList:"mock,ollama" verf:"nilable"` // 未指定模型配置时使用的协议
+	Messages       []*ChatMessage       // 结构体切片不能通过verf校验，在controller中校验
+	Files          []string             // POST /v1/files返回的文件ID，作为最后一条用户消息的附件
+	Options        *ChatOptions         // 采样参数、工具选择和回答格式，为空时使用模型默认值
+	Reasoning      entity.ReasoningMode `inList:"show,hide,disable" verf:"nilable"`
+	Stream         *bool                `default:"true"`
+}
+
+type ChatMessage struct {
+	Role    string // system, user, assistant
+	Content string
+}
+
+type ChatOptions struct {
+	Temperature *float32 // 0到2之间
+	TopP        *float32 // 0到1之间
+	MaxTokens   *int
+	Stop        []string
+	ToolChoice  string `inList:"auto,none,required" verf:"nilable"`
+	// ResponseSchema 回答需要符合的JSON Schema，优先于智能体定义，校验通过后通过chat.structured事件返回解析后的JSON。
+	// Schema是递归的结构，不能经过请求参数的默认值处理，在controller中解析
+	ResponseSchema any
 }
 
 type ChatEvent = entity.AgentRespEvent
+
+// CancelChatRequest POST /v1/chat/{requestId}/cancel，只能取消本实例上正在运行的请求
+type CancelChatRequest struct {
+	api.Request
+	TargetRequestID string `verf:""` // 要取消的对话请求的X-Request-Id
+}
+
+// ChatEventsRequest GET /v1/chat/{requestId}/events，任意实例都可以回放共享存储中的事件
+type ChatEventsRequest struct {
+	api.Request
+	web.Context
+	TargetRequestID string `verf:""`             // 对话请求的X-Request-Id
+	LastEventID     string `param:"lastEventId"` // 未携带Last-Event-ID请求头时使用，为空时从请求的第一个事件开始
+}
+
+// ChatResult stream为false时返回的完整结果，包含SSE推送的全部内容
+type ChatResult struct {
+	MessageID   string
+	Experiment  string          `json:",omitempty"`
+	Variant     string          `json:",omitempty"`
+	Answer      string          // 各轮回答的内容，与chat.answer事件拼接的结果一致
+	Reasoning   string          `json:",omitempty"`
+	ToolCalls   []*ChatToolCall `json:",omitempty"`
+	Interrupt   *ChatInterrupt  `json:",omitempty"` // 等待用户确认工具调用时返回
+	Cancelled   bool            `json:",omitempty"` // 运行被取消，Answer为取消前生成的部分
+	Suggestions []string        `json:",omitempty"`
+	Structured  json.RawMessage `json:",omitempty"` // 指定JSON Schema时为校验通过的回答
+	Usage       *ChatUsage      `json:",omitempty"`
+}
+
+// ChatToolCall 模型发起的工具调用，Result为工具的输出，未执行时为空
+type ChatToolCall struct {
+	ToolCallID string
+	ToolName   string
+	Arguments  string
+	Result     string
+}
+
+type ChatUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// ChatInterrupt 运行等待用户确认工具调用
+type ChatInterrupt struct {
+	MessageID string // 调用/v1/chat/resume时使用
+	ToolCalls []*PendingToolCall
+}
+
+type PendingToolCall struct {
+	ToolCallID string
+	Name       string
+	Arguments  string
+}
+
+type ResumeChatRequest struct {
+	api.Request
+	web.Context
+	MessageID string               `verf:""`
+	Decisions []*ToolDecision      `verf:""`
+	Reasoning entity.ReasoningMode `inList:"show,hide,disable" verf:"nilable"`
+	Stream    *bool                `default:"true"`
+}
+
+// ToolDecision 对一个工具调用的决定，未给出决定的工具调用会再次中断
+type ToolDecision struct {
+	ToolCallID string
+	Action     string // approve, reject, edit
+	Arguments  string // Action为edit时使用的参数
+	Reason     string // Action为reject时返回给模型的原因
+}
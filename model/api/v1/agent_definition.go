@@ -0,0 +1,79 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/api"
+	"github.com/eino-contrib/jsonschema"
+)
+
+type AgentDefinition struct {
+	AgentID             string
+	Name                string
+	Description         string
+	Persona             string
+	ModelProfile        string
+	Mode                string
+	Flow                string
+	Tools               []string
+	ApprovalTools       []string
+	ReturnDirectlyTools []string
+	KnowledgeBases      []string
+	OpeningMessage      string
+	Suggest             bool
+	SubAgents           []string
+	ResponseSchema      *jsonschema.Schema `json:",omitempty"` // 回答需要符合的JSON Schema，通过chat.structured事件返回解析后的JSON
+	CreateTime          time.Time
+	UpdateTime          time.Time
+}
+
+type CreateAgentRequest struct {
+	api.Request
+	Name                string `verf:"" len:",128"`
+	Description         string `len:",512"`
+	Persona             string
+	ModelProfile        string
+	Mode                string `inList:"react,plan_execute,flow" verf:"nilable"`
+	Flow                string
+	Tools               []string
+	ApprovalTools       []string
+	ReturnDirectlyTools []string
+	KnowledgeBases      []string
+	OpeningMessage      string
+	Suggest             bool
+	SubAgents           []string
+	ResponseSchema      any // JSON Schema，在controller中解析
+}
+
+type UpdateAgentRequest struct {
+	api.Request
+	AgentID             string `verf:""`
+	Name                string `verf:"" len:",128"`
+	Description         string `len:",512"`
+	Persona             string
+	ModelProfile        string
+	Mode                string `inList:"react,plan_execute,flow" verf:"nilable"`
+	Flow                string
+	Tools               []string
+	ApprovalTools       []string
+	ReturnDirectlyTools []string
+	KnowledgeBases      []string
+	OpeningMessage      string
+	Suggest             bool
+	SubAgents           []string
+	ResponseSchema      any // JSON Schema，在controller中解析
+}
+
+type DescribeAgentRequest struct {
+	api.Request
+	AgentID string `verf:""`
+}
+
+type DeleteAgentRequest struct {
+	api.Request
+	AgentID string `verf:""`
+}
+
+type ListAgentsRequest struct {
+	api.Request
+}
@@ -0,0 +1,94 @@
+package apiv1
+
+import (
+	"encoding/json"
+
+	"github.com/caiflower/ai-agent/model/api"
+	"github.com/caiflower/common-tools/web"
+)
+
+// ChatCompletionRequest OpenAI兼容的请求，字段名称与OpenAI一致
+type ChatCompletionRequest struct {
+	api.Request
+	web.Context   `json:"-"`
+	Model         string                   `json:"model" verf:""` // 智能体ID，default为内置智能体
+	Messages      []*ChatCompletionMessage `json:"messages"`
+	Stream        bool                     `json:"stream"`
+	StreamOptions *StreamOptions           `json:"stream_options"`
+	Tools         []*ChatCompletionTool    `json:"tools"`
+	ToolChoice    any                      `json:"tool_choice"` // none, auto, required或指定的函数
+	Temperature   *float32                 `json:"temperature"`
+	TopP          *float32                 `json:"top_p"`
+	MaxTokens     *int                     `json:"max_tokens"`
+	Stop          any                      `json:"stop"` // 字符串或字符串数组
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // 结束前发送一个只包含用量的chunk
+}
+
+// ChatCompletionMessage 请求中的Content为字符串或内容片段数组，响应中为字符串
+type ChatCompletionMessage struct {
+	Role             string                    `json:"role,omitempty"`
+	Content          any                       `json:"content,omitempty"`
+	ReasoningContent string                    `json:"reasoning_content,omitempty"`
+	ToolCalls        []*ChatCompletionToolCall `json:"tool_calls,omitempty"`
+	ToolCallID       string                    `json:"tool_call_id,omitempty"`
+}
+
+type ChatCompletionToolCall struct {
+	Index    *int                       `json:"index,omitempty"` // 仅在chunk中使用
+	ID       string                     `json:"id"`
+	Type     string                     `json:"type"`
+	Function ChatCompletionFunctionCall `json:"function"`
+}
+
+type ChatCompletionFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type ChatCompletionTool struct {
+	Type     string                    `json:"type"`
+	Function ChatCompletionFunctionDef `json:"function"`
+}
+
+type ChatCompletionFunctionDef struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON Schema
+}
+
+// ChatCompletion Object为chat.completion或chat.completion.chunk
+type ChatCompletion struct {
+	ID      string                  `json:"id"`
+	Object  string                  `json:"object"`
+	Created int64                   `json:"created"`
+	Model   string                  `json:"model"`
+	Choices []*ChatCompletionChoice `json:"choices"`
+	Usage   *ChatCompletionUsage    `json:"usage,omitempty"`
+}
+
+type ChatCompletionChoice struct {
+	Index        int                    `json:"index"`
+	Message      *ChatCompletionMessage `json:"message,omitempty"`
+	Delta        *ChatCompletionMessage `json:"delta,omitempty"`
+	FinishReason *string                `json:"finish_reason"` // chunk中结束前为null
+}
+
+type ChatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionError OpenAI格式的错误响应
+type ChatCompletionError struct {
+	Error *ChatCompletionErrorDetail `json:"error"`
+}
+
+type ChatCompletionErrorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
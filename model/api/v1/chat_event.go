@@ -0,0 +1,82 @@
+package apiv1
+
+import "encoding/json"
+
+// SSE和WebSocket推送的事件类型
+const (
+	EventTypeOfChatStart        = "chat.start"
+	EventTypeOfChatRecovered    = "chat.recovered" // 实例重启后从最近的节点边界继续运行，之前收到的未完成回答需要丢弃
+	EventTypeOfChatModelAnswer  = "chat.answer"
+	EventTypeOfChatReasoning    = "chat.reasoning"
+	EventTypeOfChatSuggest      = "chat.suggest"
+	EventTypeOfChatInterrupt    = "chat.interrupt"
+	EventTypeOfChatError        = "chat.error"
+	EventTypeOfChatFinish       = "chat.finish"
+	EventTypeOfChatAgent        = "chat.agent"         // 监督者模式下切换回答的专家
+	EventTypeOfChatPlan         = "chat.plan"          // 计划执行模式的步骤
+	EventTypeOfChatToolProgress = "chat.tool.progress" // 工具执行期间的进度
+	EventTypeOfChatToolResult   = "chat.tool.result"   // 工具执行结束后的输出
+	EventTypeOfChatCancelled    = "chat.cancelled"     // 运行被取消，之后发送chat.finish
+	EventTypeOfChatStructured   = "chat.structured"    // 回答符合指定的JSON Schema，在chat.finish之前发送
+)
+
+// ChatEventVersion 事件内容的版本，字段发生不兼容的修改时增加，客户端遇到不认识的版本时不应继续解析
+const ChatEventVersion = 1
+
+// 运行结束的原因，chat.finish和chat.error中返回
+const (
+	FinishReasonOfStop      = "stop"      // 正常结束
+	FinishReasonOfInterrupt = "interrupt" // 等待用户确认工具调用
+	FinishReasonOfCancelled = "cancelled" // 被用户取消
+	FinishReasonOfError     = "error"     // 运行失败或超时，原因见Error
+)
+
+// ChatEventData 所有事件的data都是该结构的JSON，只填写与事件类型相关的字段
+type ChatEventData struct {
+	Version      int        // 等于ChatEventVersion
+	MessageID    string     // 消息ID，用于恢复运行和提交反馈
+	Seq          int64      // 与事件ID中的序号相同，同一消息内单调递增
+	Agent        string     `json:",omitempty"` // 产生事件的智能体ID，监督者模式下为回答的专家
+	Node         string     `json:",omitempty"` // 产生事件的图节点，运行级别的事件为空
+	Delta        string     `json:",omitempty"` // chat.answer、chat.reasoning的增量文本
+	FinishReason string     `json:",omitempty"` // chat.finish、chat.error
+	Error        *ChatError `json:",omitempty"` // chat.error
+	Usage        *ChatUsage `json:",omitempty"` // chat.finish，本次运行的token用量，重连时可能为空
+
+	Experiment  string          `json:",omitempty"` // chat.start、chat.recovered、chat.finish，命中的实验ID
+	Variant     string          `json:",omitempty"` // 命中的实验变体
+	Answer      string          `json:",omitempty"` // chat.finish，重连时运行已经结束，返回完整的回答
+	Suggestions []string        `json:",omitempty"` // chat.suggest
+	Interrupt   *ChatInterrupt  `json:",omitempty"` // chat.interrupt
+	Plan        *ChatPlan       `json:",omitempty"` // chat.plan
+	Tool        *ChatTool       `json:",omitempty"` // chat.tool.progress、chat.tool.result
+	Handoff     *ChatAgent      `json:",omitempty"` // chat.agent
+	Structured  json.RawMessage `json:",omitempty"` // chat.structured，按JSON Schema校验通过的回答，修正后可能与chat.answer拼接的结果不同
+}
+
+// ChatError 与HTTP接口的错误格式相同
+type ChatError struct {
+	Code    int
+	Type    string
+	Message string
+}
+
+// ChatAgent 监督者把对话交给专家后发送，之后的回答由该专家产生
+type ChatAgent struct {
+	AgentID   string
+	Name      string
+	MessageID string // 专家的消息ID，用于恢复中断和提交反馈
+}
+
+// ChatPlan 重新规划后再次发送
+type ChatPlan struct {
+	Steps     []string
+	Completed int // 已完成的步骤数
+}
+
+// ChatTool chat.tool.progress中为执行期间上报的进度或部分输出，chat.tool.result中为执行结束后的输出
+type ChatTool struct {
+	ToolCallID string
+	ToolName   string
+	Content    string
+}
@@ -0,0 +1,52 @@
+package apiv1
+
+import (
+	"github.com/caiflower/ai-agent/model/api"
+	"github.com/caiflower/ai-agent/model/entity"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/common-tools/web"
+	"github.com/caiflower/common-tools/web/e"
+)
+
+const (
+	WsMessageTypeOfUserMessage = "user_message"
+	WsMessageTypeOfCancel      = "cancel"
+	WsMessageTypeOfApprove     = "approve"
+	WsMessageTypeOfPing        = "ping"
+
+	WsMessageTypeOfPong  = "pong"
+	WsMessageTypeOfError = "error"
+)
+
+// ChatWsRequest GET /v1/chat/ws 升级为WebSocket，之后通过JSON消息对话
+type ChatWsRequest struct {
+	api.Request
+	web.Context
+}
+
+// ChatWsClientMessage 客户端发送的消息，同一连接上同时只能有一个运行
+type ChatWsClientMessage struct {
+	Type string // user_message, cancel, approve, ping
+
+	// user_message的内容，与POST /v1/chat的请求相同
+	ConversationID string
+	AgentID        string
+	ModelProfile   string
+	ChatProtocol   chatmodel.Protocol
+	Messages       []*ChatMessage
+	Files          []string
+	Options        *ChatOptions
+	Reasoning      entity.ReasoningMode
+
+	// approve的内容，与POST /v1/chat/resume的请求相同
+	MessageID string
+	Decisions []*ToolDecision
+}
+
+// ChatWsServerMessage 服务端发送的消息，Type和Data与SSE的事件相同，另有pong和error
+type ChatWsServerMessage struct {
+	Type  string
+	ID    string     `json:",omitempty"` // 事件ID，可以通过Last-Event-ID从SSE接口继续接收
+	Data  string     `json:",omitempty"`
+	Error e.ApiError `json:",omitempty"` // Type为error时的错误，格式与HTTP接口相同
+}
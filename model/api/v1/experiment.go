@@ -0,0 +1,61 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/api"
+)
+
+type Experiment struct {
+	ExperimentID string
+	Name         string
+	AgentID      string
+	Variants     []*ExperimentVariant
+	Running      bool
+	CreateTime   time.Time
+	UpdateTime   time.Time
+}
+
+type ExperimentVariant struct {
+	Name          string
+	Weight        int
+	PromptVersion *int   `json:",omitempty"` // 为空时使用生效的提示词版本
+	ModelProfile  string `json:",omitempty"` // 为空时使用智能体定义的模型配置
+}
+
+// ExperimentVariantReport 单个变体的统计
+type ExperimentVariantReport struct {
+	Variant          string
+	Runs             int
+	PromptTokens     int
+	CompletionTokens int
+	AvgTotalTokens   float64
+	ThumbsUp         int
+	ThumbsDown       int
+}
+
+type CreateExperimentRequest struct {
+	api.Request
+	Name     string `verf:"" len:",128"`
+	AgentID  string // 为空时对所有智能体生效
+	Variants []*ExperimentVariant
+}
+
+type DescribeExperimentRequest struct {
+	api.Request
+	ExperimentID string `verf:""`
+}
+
+type ListExperimentsRequest struct {
+	api.Request
+}
+
+type StopExperimentRequest struct {
+	api.Request
+	ExperimentID string `verf:""`
+}
+
+type DescribeExperimentReportRequest struct {
+	api.Request
+	ExperimentID string `verf:""`
+}
@@ -0,0 +1,48 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/api"
+)
+
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+type SubmitFeedbackRequest struct {
+	api.Request
+	MessageID string `verf:""`
+	Rating    string `verf:"" inList:"up,down"`
+	Category  string `len:",64"`
+	Comment   string `len:",2000"`
+}
+
+type ExportFeedbackRequest struct {
+	api.Request
+	Rating    string `inList:"up,down" verf:"nilable"`
+	Category  string
+	AgentID   string
+	StartTime string // 格式为2006-01-02 15:04:05
+	EndTime   string // 格式为2006-01-02 15:04:05
+	Offset    int
+	Limit     int // 最大1000
+}
+
+// FeedbackRecord 导出的反馈记录，一条记录对应评测数据集中的一个样本
+type FeedbackRecord struct {
+	MessageID     string
+	RequestID     string
+	User          string
+	AgentID       string
+	Model         string
+	PromptName    string
+	PromptVersion int
+	Rating        string
+	Category      string
+	Comment       string
+	Input         string
+	Answer        string
+	CreateTime    time.Time
+}
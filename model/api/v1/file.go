@@ -0,0 +1,23 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/api"
+)
+
+// File 上传的文件，ContentType按内容识别
+type File struct {
+	FileID      string // 对话请求的Files中引用
+	Name        string
+	ContentType string
+	Size        int64
+	CreateTime  time.Time
+}
+
+// UploadFileRequest POST /v1/files，Content为base64编码的文件内容
+type UploadFileRequest struct {
+	api.Request
+	Name    string `verf:"" len:",255"`
+	Content string `verf:""`
+}
@@ -0,0 +1,41 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/caiflower/ai-agent/model/api"
+)
+
+type PromptTemplate struct {
+	Name       string
+	Version    int
+	Content    string
+	Author     string
+	ChangeNote string
+	Active     bool
+	CreateTime time.Time
+}
+
+type CreatePromptTemplateRequest struct {
+	api.Request
+	Name       string `verf:""`
+	Content    string `verf:""`
+	ChangeNote string `len:",512"`
+}
+
+type DescribePromptTemplateRequest struct {
+	api.Request
+	Name    string `verf:""`
+	Version int    // 为空时返回生效的版本
+}
+
+type ListPromptTemplateVersionsRequest struct {
+	api.Request
+	Name string `verf:""`
+}
+
+type RollbackPromptTemplateRequest struct {
+	api.Request
+	Name    string `verf:""`
+	Version int    // 0表示回滚到内置模板
+}
@@ -0,0 +1,11 @@
+package bean
+
+import "time"
+
+// AgentCheckpoint 运行状态的checkpoint，checkpoint.store为db时使用
+type AgentCheckpoint struct {
+	BaseModel
+	CheckpointID string    //checkpoint ID，即运行ID
+	Data         []byte    //序列化后的运行状态
+	ExpireTime   time.Time //过期时间
+}
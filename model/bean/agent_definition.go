@@ -0,0 +1,30 @@
+package bean
+
+import "github.com/eino-contrib/jsonschema"
+
+// 智能体的运行模式
+const (
+	AgentModeReact       = "react"        // 模型与工具循环直到给出回答
+	AgentModePlanExecute = "plan_execute" // 先规划步骤，逐步执行后汇总回答
+	AgentModeFlow        = "flow"         // 运行声明式定义的图
+)
+
+// AgentDefinition 智能体定义
+type AgentDefinition struct {
+	BaseModel
+	AgentID             string             //智能体ID
+	Name                string             //名称
+	Description         string             //职责描述，监督者据此选择专家智能体
+	Persona             string             //人设
+	ModelProfile        string             //模型配置名称
+	Mode                string             //运行模式，为空时为react
+	Flow                string             //flow模式运行的图名称
+	Tools               []string           //工具集
+	ApprovalTools       []string           //需要用户确认后才能执行的工具
+	ReturnDirectlyTools []string           //调用后直接把输出作为回答的工具，不再请求模型
+	KnowledgeBases      []string           //知识库
+	OpeningMessage      string             //开场白
+	Suggest             bool               //回答后是否生成追问建议
+	SubAgents           []string           //专家智能体，不为空时作为监督者将每轮对话路由给专家回答
+	ResponseSchema      *jsonschema.Schema //回答需要符合的JSON Schema，为空时不限制格式
+}
@@ -0,0 +1,46 @@
+package bean
+
+// 运行状态
+const (
+	RunStateRunning     = "running"
+	RunStateInterrupted = "interrupted" // 等待用户确认后恢复
+	RunStateFinished    = "finished"
+	RunStateFailed      = "failed"
+	RunStateCancelled   = "cancelled" // 用户取消，记录取消前已经生成的回答
+)
+
+// AgentRun 智能体的一次运行记录
+type AgentRun struct {
+	BaseModel
+	RunID            string //运行ID
+	RequestID        string //请求ID
+	User             string //用户
+	AgentID          string //智能体ID
+	ConversationID   string //会话ID
+	PromptName       string //提示词模板名称
+	PromptVersion    int    //提示词模板版本
+	ModelProfile     string //模型配置名称
+	Model            string //模型
+	ChatProtocol     string //未指定模型配置时使用的协议，恢复运行时使用
+	Options          string //请求指定的采样参数，JSON格式，恢复运行时使用
+	State            string //运行状态
+	Instance         string //执行运行的实例，实例重启后恢复其未完成的运行
+	Pending          string //等待确认的工具调用，JSON格式
+	Input            string //用户输入
+	Answer           string //最终回答
+	Experiment       string //命中的实验ID
+	Variant          string //命中的实验变体
+	PromptTokens     int    //输入token数
+	CompletionTokens int    //输出token数
+	Rating           int    //用户反馈，1赞，-1踩，0未反馈
+}
+
+// AgentRunStat 按实验变体聚合的运行统计
+type AgentRunStat struct {
+	Variant          string
+	Runs             int
+	PromptTokens     int
+	CompletionTokens int
+	ThumbsUp         int
+	ThumbsDown       int
+}
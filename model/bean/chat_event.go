@@ -0,0 +1,13 @@
+package bean
+
+import "time"
+
+// ChatEvent 推送给客户端的事件，sse.replayStore为db时使用，任意实例都可以回放
+type ChatEvent struct {
+	BaseModel
+	RequestID  string    //请求ID
+	RunID      string    //运行ID
+	Seq        int64     //事件序号，同一运行内单调递增
+	Data       string    //SSE格式的事件
+	ExpireTime time.Time //过期时间
+}
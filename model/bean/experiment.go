@@ -0,0 +1,19 @@
+package bean
+
+// Experiment 实验，按用户哈希分桶，命中的变体覆盖提示词版本或模型配置
+type Experiment struct {
+	BaseModel
+	ExperimentID string               //实验ID
+	Name         string               //名称
+	AgentID      string               //生效的智能体ID，为空时对所有智能体生效
+	Variants     []*ExperimentVariant //变体
+	Running      bool                 //是否运行中
+}
+
+// ExperimentVariant 实验变体
+type ExperimentVariant struct {
+	Name          string //变体名称
+	Weight        int    //流量权重
+	PromptVersion *int   //覆盖的提示词版本，为空时使用生效版本
+	ModelProfile  string //覆盖的模型配置，为空时使用智能体定义的模型配置
+}
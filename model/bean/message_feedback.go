@@ -0,0 +1,16 @@
+package bean
+
+// MessageFeedback 用户对回答的反馈，MessageID即运行ID，同时冗余运行时的模型和提示词版本便于导出评测数据
+type MessageFeedback struct {
+	BaseModel
+	MessageID     string //消息ID
+	RequestID     string //请求ID
+	User          string //用户
+	AgentID       string //智能体ID
+	Model         string //模型
+	PromptName    string //提示词模板名称
+	PromptVersion int    //提示词模板版本
+	Rating        int    //1赞，-1踩
+	Category      string //反馈分类
+	Comment       string //反馈内容
+}
@@ -0,0 +1,12 @@
+package bean
+
+// PromptTemplate 提示词模板，同一个Name下有多个版本，Active标记当前生效的版本
+type PromptTemplate struct {
+	BaseModel
+	Name       string //模板名称
+	Version    int    //版本号
+	Content    string //模板内容，jinja2格式
+	Author     string //作者
+	ChangeNote string //变更说明
+	Active     bool   //是否生效
+}
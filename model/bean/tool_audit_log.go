@@ -0,0 +1,23 @@
+package bean
+
+// 审计日志中决定的来源
+const (
+	AuditSourcePolicy = "policy" // 审批策略
+	AuditSourceUser   = "user"   // 用户确认
+)
+
+// ToolAuditLog 工具调用的审批记录，策略的每次判定和用户的每次确认各记录一条
+type ToolAuditLog struct {
+	BaseModel
+	RunID      string //运行ID
+	ToolCallID string //工具调用ID
+	User       string //用户
+	AgentID    string //智能体ID
+	Tool       string //工具名称
+	Risk       string //风险等级
+	Arguments  string //调用参数
+	Source     string //决定的来源: policy, user
+	Rule       string //命中的规则，为空时为默认策略
+	Action     string //策略: allow, deny, approve；用户: approve, reject, edit
+	Reason     string //拒绝的原因
+}
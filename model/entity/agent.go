@@ -1,16 +1,63 @@
 package entity
 
 import (
+	"encoding/json"
+
 	chatmodel "github.com/caiflower/ai-agent/service/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
 )
 
 type AgentRequest struct {
-	Input        *schema.Message
-	History      []*schema.Message
-	ChatProtocol chatmodel.Protocol
+	RequestID      string
+	User           string
+	AgentID        string
+	ConversationID string
+	Input          *schema.Message
+	Attachments    []*Attachment // 用户输入的附件，运行开始时按模型是否支持图片加入Input
+	History        []*schema.Message
+	ChatProtocol   chatmodel.Protocol
+	ModelProfile   string // 请求指定的模型配置，优先于智能体定义和实验变体
+	Options        *ModelOptions
+	Reasoning      ReasoningMode
+}
+
+// Attachment 上传的文件，ContentType按内容识别
+type Attachment struct {
+	FileID      string
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// ModelOptions 请求指定的采样参数、工具选择和回答格式，为空的参数使用模型默认值
+type ModelOptions struct {
+	Temperature *float32          `json:",omitempty"`
+	TopP        *float32          `json:",omitempty"`
+	MaxTokens   *int              `json:",omitempty"`
+	Stop        []string          `json:",omitempty"`
+	ToolChoice  schema.ToolChoice `json:",omitempty"`
+	Tools       []*ClientTool     `json:",omitempty"`
+	// ResponseSchema 回答需要符合的JSON Schema，优先于智能体定义
+	ResponseSchema *jsonschema.Schema `json:",omitempty"`
 }
 
+// ClientTool 客户端声明的工具，模型调用时结束运行，工具调用由客户端执行后在下一轮对话中返回结果
+type ClientTool struct {
+	Name        string
+	Description string
+	Parameters  *jsonschema.Schema
+}
+
+// ReasoningMode 推理内容的处理方式
+type ReasoningMode string
+
+const (
+	ReasoningModeShow    ReasoningMode = "show"    // 推理内容作为单独的事件输出
+	ReasoningModeHide    ReasoningMode = "hide"    // 模型仍然思考，但不输出推理内容
+	ReasoningModeDisable ReasoningMode = "disable" // 关闭模型的思考模式
+)
+
 type EventType string
 
 const (
@@ -22,9 +69,103 @@ const (
 	EventTypeOfSuggest                EventType = "suggest"
 	EventTypeOfKnowledge              EventType = "knowledge"
 	EventTypeOfInterrupt              EventType = "interrupt"
+	EventTypeOfUsage                  EventType = "usage"     // 运行结束或中断时本次的token用量
+	EventTypeOfCancelled              EventType = "cancelled" // 运行被取消，之后只发送用量
+	EventTypeOfRunInfo                EventType = "run_info"
+	EventTypeOfHandoff                EventType = "handoff"
+	EventTypeOfPlan                   EventType = "plan"
+	EventTypeOfStructured             EventType = "structured" // 回答按JSON Schema校验通过后解析的JSON
 )
 
+// RunInfo 运行信息，作为第一个事件发送
+type RunInfo struct {
+	RunID      string
+	AgentID    string
+	Experiment string
+	Variant    string
+}
+
+// Handoff 监督者把对话交给专家智能体，之后的事件由该专家产生
+type Handoff struct {
+	AgentID string
+	Name    string
+	RunID   string // 专家的运行ID，用于恢复中断和提交反馈
+}
+
+// Plan 计划执行模式的步骤，重新规划后未完成的步骤会被替换
+type Plan struct {
+	Steps     []string
+	Completed int // 已完成的步骤数
+}
+
+// ToolProgress 工具执行期间上报的进度或部分输出
+type ToolProgress struct {
+	ToolCallID string
+	ToolName   string
+	Content    string
+}
+
+// Interrupt 运行中断，等待用户对待执行的工具调用做出决定
+type Interrupt struct {
+	RunID     string
+	ToolCalls []*PendingToolCall
+}
+
+type PendingToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+type DecisionAction string
+
+const (
+	DecisionActionApprove DecisionAction = "approve"
+	DecisionActionReject  DecisionAction = "reject"
+	DecisionActionEdit    DecisionAction = "edit" // 使用修改后的参数执行
+)
+
+// ToolDecision 用户对工具调用的决定
+type ToolDecision struct {
+	Action    DecisionAction
+	Arguments string
+	Reason    string
+}
+
+// ResumeRequest 恢复中断的运行，Decisions的key为工具调用ID
+type ResumeRequest struct {
+	RequestID string
+	User      string
+	RunID     string
+	Decisions map[string]*ToolDecision
+	Reasoning ReasoningMode
+}
+
+// RecoveredRun 实例重启后恢复的运行
+type RecoveredRun struct {
+	RunID     string
+	RequestID string // 运行开始时的请求ID，恢复后的事件仍然可以通过该请求ID回放
+	User      string
+	Events    *schema.StreamReader[*AgentRespEvent]
+}
+
 type AgentRespEvent struct {
 	EventType       EventType
+	AgentID         string // 产生事件的智能体，监督者模式下为专家智能体
+	Node            string // 产生事件的图节点，运行级别的事件为空
 	ChatModelAnswer *schema.StreamReader[*schema.Message]
+	RunInfo         *RunInfo
+	Suggestions     []string
+	Interrupt       *Interrupt
+	Handoff         *Handoff
+	Plan            *Plan
+	ToolProgress    *ToolProgress
+	ToolsMessage    []*schema.Message // 工具节点执行结束后各工具的输出
+	Structured      json.RawMessage
+	Usage           *Usage
+}
+
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
 }
@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/policy"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+func init() {
+	_ = compose.RegisterSerializableType[agentState]("ai_agent_state")
+	_ = compose.RegisterSerializableType[entity.ToolDecision]("ai_agent_tool_decision")
+	_ = compose.RegisterSerializableType[entity.AgentRequest]("ai_agent_request")
+}
+
+const messageOfApprovalUnavailable = "The tool call requires user approval, which is not available in this mode."
+
+type runKey struct{}
+
+// withRun 工具执行时从ctx中获取运行信息，用于审批策略和审计日志
+func withRun(ctx context.Context, run *bean.AgentRun) context.Context {
+	return context.WithValue(ctx, runKey{}, run)
+}
+
+func runFromContext(ctx context.Context) *bean.AgentRun {
+	if run, ok := ctx.Value(runKey{}).(*bean.AgentRun); ok {
+		return run
+	}
+	return &bean.AgentRun{}
+}
+
+// toolAuthorizer 执行前按审批策略判定工具调用：允许时直接执行，拒绝时把原因返回给模型，
+// 需要确认时中断运行，恢复后按用户的决定执行
+type toolAuthorizer struct {
+	name          string
+	risk          string
+	approval      bool // 智能体定义要求确认
+	interruptible bool // 计划执行模式不能中断，需要确认时拒绝
+	engine        policy.Engine
+}
+
+type approvalTool struct {
+	tool.InvokableTool
+	*toolAuthorizer
+}
+
+type approvalStreamableTool struct {
+	tool.StreamableTool
+	*toolAuthorizer
+}
+
+// wrapApprovalTools 包装所有工具，approvalTools中的工具总是需要确认
+func wrapApprovalTools(ctx context.Context, agentTools []tool.BaseTool, approvalTools []string, interruptible bool, engine policy.Engine) ([]tool.BaseTool, error) {
+	wrapped := make([]tool.BaseTool, 0, len(agentTools))
+	for _, t := range agentTools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		authorizer := &toolAuthorizer{
+			name:          info.Name,
+			risk:          policy.Risk(info),
+			approval:      slices.Contains(approvalTools, info.Name),
+			interruptible: interruptible,
+			engine:        engine,
+		}
+
+		switch v := t.(type) {
+		case tool.InvokableTool:
+			wrapped = append(wrapped, &approvalTool{InvokableTool: v, toolAuthorizer: authorizer})
+		case tool.StreamableTool:
+			if authorizer.approval {
+				return nil, fmt.Errorf("approval tool '%s' is not invokable", info.Name)
+			}
+			wrapped = append(wrapped, &approvalStreamableTool{StreamableTool: v, toolAuthorizer: authorizer})
+		default:
+			return nil, fmt.Errorf("tool '%s' is neither invokable nor streamable", info.Name)
+		}
+	}
+	return wrapped, nil
+}
+
+func (t *approvalTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	arguments, denied, err := t.authorize(ctx, argumentsInJSON)
+	if err != nil {
+		return "", err
+	}
+	if denied != "" {
+		return denied, nil
+	}
+	return t.InvokableTool.InvokableRun(ctx, arguments, opts...)
+}
+
+func (t *approvalStreamableTool) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	arguments, denied, err := t.authorize(ctx, argumentsInJSON)
+	if err != nil {
+		return nil, err
+	}
+	if denied != "" {
+		return schema.StreamReaderFromArray([]string{denied}), nil
+	}
+	return t.StreamableTool.StreamableRun(ctx, arguments, opts...)
+}
+
+// authorize 返回执行使用的参数，拒绝时返回给模型的原因不为空
+func (t *toolAuthorizer) authorize(ctx context.Context, argumentsInJSON string) (string, string, error) {
+	callID := compose.GetToolCallID(ctx)
+
+	var decision *entity.ToolDecision
+	err := compose.ProcessState[*agentState](ctx, func(_ context.Context, state *agentState) error {
+		// 决定只用于本次恢复，避免之后相同ID的调用被自动处理
+		decision = state.Decisions[callID]
+		delete(state.Decisions, callID)
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	run := runFromContext(ctx)
+	call := &policy.ToolCall{
+		RunID:      run.RunID,
+		ToolCallID: callID,
+		User:       run.User,
+		AgentID:    run.AgentID,
+		Tool:       t.name,
+		Risk:       t.risk,
+		Arguments:  argumentsInJSON,
+	}
+
+	// 恢复运行时按用户的决定执行
+	if decision != nil {
+		t.engine.RecordUserDecision(call, decision)
+		switch decision.Action {
+		case entity.DecisionActionReject:
+			if decision.Reason != "" {
+				return "", fmt.Sprintf("The user rejected this tool call. Reason: %s", decision.Reason), nil
+			}
+			return "", "The user rejected this tool call.", nil
+		case entity.DecisionActionEdit:
+			// 修改后的参数重新按策略判定并记录审计日志，用户不能绕过拒绝的规则
+			edited := *call
+			edited.Arguments = decision.Arguments
+			if result := t.engine.Evaluate(&edited); result.Action == policy.ActionDeny {
+				return "", result.Message, nil
+			}
+			argumentsInJSON = decision.Arguments
+		}
+		return argumentsInJSON, "", nil
+	}
+
+	var result *policy.Decision
+	if t.approval {
+		result = &policy.Decision{Action: policy.ActionApprove, Rule: policy.RuleOfApprovalTools}
+		t.engine.Record(call, bean.AuditSourcePolicy, result)
+	} else {
+		result = t.engine.Evaluate(call)
+	}
+
+	switch result.Action {
+	case policy.ActionAllow:
+		return argumentsInJSON, "", nil
+	case policy.ActionDeny:
+		return "", result.Message, nil
+	}
+	if !t.interruptible {
+		return "", messageOfApprovalUnavailable, nil
+	}
+	return "", "", compose.NewInterruptAndRerunErr(&entity.PendingToolCall{
+		ID:        callID,
+		Name:      t.name,
+		Arguments: argumentsInJSON,
+	})
+}
+
+// buildInterrupt 从中断信息中取出等待确认的工具调用
+func buildInterrupt(runID string, info *compose.InterruptInfo) *entity.Interrupt {
+	interrupt := &entity.Interrupt{RunID: runID}
+
+	extra, ok := info.RerunNodesExtra[keyOfToolsNode].(*compose.ToolsInterruptAndRerunExtra)
+	if !ok {
+		return interrupt
+	}
+	for _, id := range extra.RerunTools {
+		if pending, ok := extra.RerunExtraMap[id].(*entity.PendingToolCall); ok {
+			interrupt.ToolCalls = append(interrupt.ToolCalls, pending)
+		}
+	}
+	return interrupt
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/file"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrImageAttachmentsUnsupported 流程图的节点通过模板引用用户输入的文本，无法发送图片
+var ErrImageAttachmentsUnsupported = errors.New("image attachments are not supported in flow mode")
+
+// withAttachments 把附件加入用户输入。支持图片的模型通过MultiContent发送图片，
+// 其他附件和不支持图片的模型只发送提取的文本，Content保持为用户输入的文本，用于检索知识库等
+func withAttachments(req *entity.AgentRequest, vision bool) *entity.AgentRequest {
+	if len(req.Attachments) == 0 || req.Input == nil {
+		return req
+	}
+
+	var (
+		texts  = []string{req.Input.Content}
+		images []schema.ChatMessagePart
+	)
+	for _, a := range req.Attachments {
+		if vision && file.IsImage(a.ContentType) {
+			images = append(images, schema.ChatMessagePart{
+				Type: schema.ChatMessagePartTypeImageURL,
+				ImageURL: &schema.ChatMessageImageURL{
+					URL:      fmt.Sprintf("data:%s;base64,%s", a.ContentType, base64.StdEncoding.EncodeToString(a.Data)),
+					MIMEType: a.ContentType,
+				},
+			})
+			continue
+		}
+		texts = append(texts, attachmentText(a))
+	}
+
+	input := *req.Input
+	if len(images) == 0 {
+		input.Content = strings.Join(texts, "\n\n")
+	} else {
+		input.MultiContent = append([]schema.ChatMessagePart{{Type: schema.ChatMessagePartTypeText, Text: strings.Join(texts, "\n\n")}}, images...)
+	}
+	withInput := *req
+	withInput.Input = &input
+	return &withInput
+}
+
+func attachmentText(a *entity.Attachment) string {
+	if text, ok := file.ExtractText(a.ContentType, a.Data); ok {
+		return fmt.Sprintf("附件%s的内容：\n%s", a.Name, text)
+	}
+	return fmt.Sprintf("附件%s的类型为%s，当前模型无法读取", a.Name, a.ContentType)
+}
+
+// inputText 用户输入和附件中提取的文本，发送图片时完整的文本在MultiContent中
+func inputText(input *schema.Message) string {
+	for _, part := range input.MultiContent {
+		if part.Type == schema.ChatMessagePartTypeText {
+			return part.Text
+		}
+	}
+	return input.Content
+}
+
+// withText 替换用户输入的文本，保留附件中的图片
+func withText(input *schema.Message, text string) *schema.Message {
+	msg := schema.UserMessage(text)
+	for _, part := range input.MultiContent {
+		if part.Type != schema.ChatMessagePartTypeText {
+			msg.MultiContent = append(msg.MultiContent, part)
+		}
+	}
+	if len(msg.MultiContent) > 0 {
+		msg.MultiContent = append([]schema.ChatMessagePart{{Type: schema.ChatMessagePartTypeText, Text: text}}, msg.MultiContent...)
+	}
+	return msg
+}
+
+func hasImages(input *schema.Message) bool {
+	return slices.ContainsFunc(input.MultiContent, func(part schema.ChatMessagePart) bool {
+		return part.Type == schema.ChatMessagePartTypeImageURL
+	})
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAttachments(t *testing.T) {
+	req := &entity.AgentRequest{
+		Input: schema.UserMessage("图片里是什么"),
+		Attachments: []*entity.Attachment{
+			{Name: "a.png", ContentType: "image/png", Data: []byte("png")},
+			{Name: "b.txt", ContentType: "text/plain", Data: []byte("北京晴")},
+		},
+	}
+
+	// 支持图片的模型
+	withInput := withAttachments(req, true)
+	assert.Equal(t, "图片里是什么", withInput.Input.Content)
+	if assert.Len(t, withInput.Input.MultiContent, 2) {
+		assert.Equal(t, "图片里是什么\n\n附件b.txt的内容：\n北京晴", withInput.Input.MultiContent[0].Text)
+		assert.Equal(t, "data:image/png;base64,cG5n", withInput.Input.MultiContent[1].ImageURL.URL)
+	}
+
+	// 不支持图片的模型只使用文本
+	withInput = withAttachments(req, false)
+	assert.Empty(t, withInput.Input.MultiContent)
+	assert.Equal(t, "图片里是什么\n\n附件a.png的类型为image/png，当前模型无法读取\n\n附件b.txt的内容：\n北京晴", withInput.Input.Content)
+
+	// 原请求不变
+	assert.Equal(t, "图片里是什么", req.Input.Content)
+	assert.Empty(t, req.Input.MultiContent)
+}
+
+func TestWithText(t *testing.T) {
+	req := &entity.AgentRequest{
+		Input:       schema.UserMessage("图片里是什么"),
+		Attachments: []*entity.Attachment{{Name: "a.png", ContentType: "image/png", Data: []byte("png")}},
+	}
+
+	// 替换文本时保留图片
+	input := withAttachments(req, true).Input
+	assert.True(t, hasImages(input))
+	msg := withText(input, "当前步骤："+inputText(input))
+	assert.Equal(t, "当前步骤：图片里是什么", msg.Content)
+	if assert.Len(t, msg.MultiContent, 2) {
+		assert.Equal(t, "当前步骤：图片里是什么", msg.MultiContent[0].Text)
+		assert.Equal(t, "data:image/png;base64,cG5n", msg.MultiContent[1].ImageURL.URL)
+	}
+
+	// 没有图片时只有文本
+	input = withAttachments(req, false).Input
+	assert.False(t, hasImages(input))
+	msg = withText(input, inputText(input))
+	assert.Equal(t, "图片里是什么\n\n附件a.png的类型为image/png，当前模型无法读取", msg.Content)
+	assert.Empty(t, msg.MultiContent)
+}
@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/cloudwego/eino/schema"
+)
+
+var ErrClientToolsUnsupported = errors.New("client tools are only supported in react mode")
+
+// clientToolInfos 客户端声明的工具只提供给模型，不加入tools_node
+func clientToolInfos(def *bean.AgentDefinition, options *entity.ModelOptions) ([]*schema.ToolInfo, error) {
+	if options == nil || len(options.Tools) == 0 {
+		return nil, nil
+	}
+	if def.Mode == bean.AgentModePlanExecute || def.Mode == bean.AgentModeFlow {
+		return nil, ErrClientToolsUnsupported
+	}
+
+	infos := make([]*schema.ToolInfo, 0, len(options.Tools))
+	for _, t := range options.Tools {
+		info := &schema.ToolInfo{Name: t.Name, Desc: t.Description}
+		if t.Parameters != nil {
+			info.ParamsOneOf = schema.NewParamsOneOfByJSONSchema(t.Parameters)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// findToolCall 检查整个流，返回模型是否调用了工具，以及是否调用了客户端的工具
+func findToolCall(sr *schema.StreamReader[*schema.Message], clientTools []string) (bool, bool, error) {
+	defer sr.Close()
+	toolCall := false
+	for {
+		msg, err := sr.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return toolCall, false, nil
+			}
+			return false, false, err
+		}
+
+		for _, call := range msg.ToolCalls {
+			toolCall = true
+			if slices.Contains(clientTools, call.Function.Name) {
+				return true, true, nil
+			}
+		}
+	}
+}
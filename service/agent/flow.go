@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	keyOfFlowNode = "flow_node"
+
+	// placeholderOfQuery flow模式下用户输入的文本，便于fstring模板直接引用
+	placeholderOfQuery = "query"
+)
+
+// buildFlowRunner prompt_variables -> flow_node，flow_node为声明式定义的图，
+// 图中输出回答的模型节点命名为chat_model_node，由回调作为回答流式输出
+func (sa *singleAgentImpl) buildFlowRunner(ctx context.Context, def *bean.AgentDefinition, pv *promptVariables) (compose.Runnable[*entity.AgentRequest, *schema.Message], error) {
+	// tools节点的工具与其他模式一样按策略判定并限制执行时间，流程不能中断等待确认
+	wrapTools := func(ctx context.Context, nodeTools []tool.BaseTool) ([]tool.BaseTool, error) {
+		nodeTools, err := wrapApprovalTools(ctx, nodeTools, def.ApprovalTools, false, sa.PolicyEngine)
+		if err != nil {
+			return nil, err
+		}
+		return guardTools(ctx, nodeTools)
+	}
+	flowGraph, err := sa.FlowManager.Graph(def.Flow, KeyofChatModelNode, wrapTools)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := func(ctx context.Context, req *entity.AgentRequest) (map[string]any, error) {
+		vs, err := pv.AssemblePromptVariables(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Input != nil {
+			vs[placeholderOfQuery] = inputText(req.Input)
+		}
+		return vs, nil
+	}
+
+	// 工具的确认需要读取运行状态，流程图作为子图共享该状态
+	g := compose.NewGraph[*entity.AgentRequest, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *agentState {
+		return &agentState{ReturnDirectlyToolIndex: -1}
+	}))
+	_ = g.AddLambdaNode(keyOfPromptVariables, compose.InvokableLambda(variables), compose.WithNodeName(keyOfPromptVariables))
+	_ = g.AddGraphNode(keyOfFlowNode, flowGraph, compose.WithNodeName(keyOfFlowNode))
+	_ = g.AddEdge(compose.START, keyOfPromptVariables)
+	_ = g.AddEdge(keyOfPromptVariables, keyOfFlowNode)
+	_ = g.AddEdge(keyOfFlowNode, compose.END)
+
+	compileOpts := []compose.GraphCompileOption{compose.WithCheckPointStore(sa.CheckpointStore)}
+	if maxRunSteps := constants.Prop.Agent.MaxRunSteps; maxRunSteps > 0 {
+		compileOpts = append(compileOpts, compose.WithMaxRunSteps(maxRunSteps))
+	}
+	return g.Compile(ctx, compileOpts...)
+}
@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/caiflower/ai-agent/constants"
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
+	mockdefinition "github.com/caiflower/ai-agent/internal/mock/definition"
+	mockexperiment "github.com/caiflower/ai-agent/internal/mock/experiment"
+	mockflow "github.com/caiflower/ai-agent/internal/mock/flow"
+	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	mockpolicy "github.com/caiflower/ai-agent/internal/mock/policy"
+	mockprompt "github.com/caiflower/ai-agent/internal/mock/prompt"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/checkpoint"
+	"github.com/caiflower/ai-agent/service/flow"
+	"github.com/caiflower/ai-agent/service/knowledge"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/policy"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/ai-agent/service/toolkit"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAgentFlow(t *testing.T) {
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(&chatmodel.MockChatModel{}, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-flow").Return(&bean.AgentDefinition{
+		AgentID: "agent-flow",
+		Name:    "flow",
+		Mode:    bean.AgentModeFlow,
+		Flow:    "weather",
+	}, nil)
+
+	// 回答节点以chat_model_node命名时作为回答流式输出
+	flowManager := mockflow.NewMockManager(ctl)
+	flowManager.EXPECT().Graph("weather", KeyofChatModelNode, gomock.Any()).DoAndReturn(func(name, answerNodeName string, _ flow.ToolWrapper) (*compose.Graph[map[string]any, *schema.Message], error) {
+		g := compose.NewGraph[map[string]any, *schema.Message]()
+		_ = g.AddChatTemplateNode("template", prompt.FromMessages(schema.FString, schema.UserMessage("{query}")))
+		_ = g.AddChatModelNode("answer", &chatmodel.MockChatModel{}, compose.WithNodeName(answerNodeName))
+		_ = g.AddEdge(compose.START, "template")
+		_ = g.AddEdge("template", "answer")
+		_ = g.AddEdge("answer", compose.END)
+		return g, nil
+	})
+
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, bean.RunStateFinished, run.State)
+		assert.Equal(t, "the weather is good", run.Answer)
+		return 1, nil
+	})
+
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("agent-flow", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      toolkit.NewRegistry(),
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		FlowManager:       flowManager,
+	}
+
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		AgentID:      "agent-flow",
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+
+	answer := ""
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.Equal(t, io.EOF, recvErr)
+			break
+		}
+		if event.EventType == entity.EventTypeOfChatModelAnswer {
+			for {
+				chunk, chunkErr := event.ChatModelAnswer.Recv()
+				if chunkErr != nil {
+					break
+				}
+				answer += chunk.Content
+			}
+		}
+	}
+	assert.Equal(t, "the weather is good", answer)
+}
+
+// TestAgentFlowImageAttachments 流程图无法发送图片，开始运行前拒绝，文本附件仍然可以使用
+func TestAgentFlowImageAttachments(t *testing.T) {
+	profiles := constants.Prop.ModelProfiles
+	constants.Prop.ModelProfiles = []constants.ModelProfileConfig{{Name: "vision", Protocol: string(chatmodel.ProtocolMock), Vision: true}}
+	defer func() { constants.Prop.ModelProfiles = profiles }()
+
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(&chatmodel.MockChatModel{}, nil)
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-flow").Return(&bean.AgentDefinition{
+		AgentID:      "agent-flow",
+		Name:         "flow",
+		Mode:         bean.AgentModeFlow,
+		Flow:         "weather",
+		ModelProfile: "vision",
+	}, nil)
+	flowManager := mockflow.NewMockManager(ctl)
+	flowManager.EXPECT().Graph("weather", KeyofChatModelNode, gomock.Any()).DoAndReturn(func(name, answerNodeName string, _ flow.ToolWrapper) (*compose.Graph[map[string]any, *schema.Message], error) {
+		g := compose.NewGraph[map[string]any, *schema.Message]()
+		_ = g.AddChatTemplateNode("template", prompt.FromMessages(schema.FString, schema.UserMessage("{query}")))
+		_ = g.AddChatModelNode("answer", &chatmodel.MockChatModel{}, compose.WithNodeName(answerNodeName))
+		_ = g.AddEdge(compose.START, "template")
+		_ = g.AddEdge("template", "answer")
+		_ = g.AddEdge("answer", compose.END)
+		return g, nil
+	})
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("agent-flow", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      toolkit.NewRegistry(),
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		FlowManager:       flowManager,
+	}
+
+	_, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		AgentID:      "agent-flow",
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+		Attachments:  []*entity.Attachment{{Name: "map.png", ContentType: "image/png", Data: []byte("png")}},
+	})
+	assert.ErrorIs(t, err, ErrImageAttachmentsUnsupported)
+}
+
+// TestAgentFlowTools 流程中tools节点的工具同样按策略判定
+func TestAgentFlowTools(t *testing.T) {
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(&chatmodel.MockChatModel{}, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-flow").Return(&bean.AgentDefinition{
+		AgentID: "agent-flow",
+		Name:    "flow",
+		Mode:    bean.AgentModeFlow,
+		Flow:    "weather",
+	}, nil)
+
+	called := false
+	registry := toolkit.NewRegistry()
+	weatherTool, err := utils.InferTool("get_weather", "get weather of a city", func(ctx context.Context, input *weatherInput) (string, error) {
+		called = true
+		return "sunny", nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, registry.Register(weatherTool))
+
+	// call -> tools -> collect -> answer，collect记录工具的输出
+	var toolOutput string
+	flowManager := mockflow.NewMockManager(ctl)
+	flowManager.EXPECT().Graph("weather", KeyofChatModelNode, gomock.Any()).DoAndReturn(func(name, answerNodeName string, wrapTools flow.ToolWrapper) (*compose.Graph[map[string]any, *schema.Message], error) {
+		nodeTools, err := wrapTools(context.Background(), []tool.BaseTool{weatherTool})
+		if err != nil {
+			return nil, err
+		}
+		toolsNode, err := compose.NewToolNode(context.Background(), &compose.ToolsNodeConfig{Tools: nodeTools})
+		if err != nil {
+			return nil, err
+		}
+		call := func(ctx context.Context, _ map[string]any) (*schema.Message, error) {
+			return schema.AssistantMessage("", []schema.ToolCall{{
+				ID:       "call_1",
+				Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"beijing"}`},
+			}}), nil
+		}
+		collect := func(ctx context.Context, msgs []*schema.Message) ([]*schema.Message, error) {
+			toolOutput = msgs[0].Content
+			return []*schema.Message{schema.UserMessage("What's the weather like in Beijing?")}, nil
+		}
+
+		g := compose.NewGraph[map[string]any, *schema.Message]()
+		_ = g.AddLambdaNode("call", compose.InvokableLambda(call))
+		_ = g.AddToolsNode("tools", toolsNode)
+		_ = g.AddLambdaNode("collect", compose.InvokableLambda(collect))
+		_ = g.AddChatModelNode("answer", &chatmodel.MockChatModel{}, compose.WithNodeName(answerNodeName))
+		_ = g.AddEdge(compose.START, "call")
+		_ = g.AddEdge("call", "tools")
+		_ = g.AddEdge("tools", "collect")
+		_ = g.AddEdge("collect", "answer")
+		_ = g.AddEdge("answer", compose.END)
+		return g, nil
+	})
+
+	engine := mockpolicy.NewMockEngine(ctl)
+	engine.EXPECT().Evaluate(gomock.Any()).DoAndReturn(func(call *policy.ToolCall) *policy.Decision {
+		assert.Equal(t, "get_weather", call.Tool)
+		return &policy.Decision{Action: policy.ActionDeny, Message: "get_weather is not allowed"}
+	})
+
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, bean.RunStateFinished, run.State)
+		return 1, nil
+	})
+
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("agent-flow", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      toolkit.NewRegistry(),
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		FlowManager:       flowManager,
+		PolicyEngine:      engine,
+	}
+
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		AgentID:      "agent-flow",
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "the weather is good", receiveAnswer(t, sr))
+	assert.False(t, called)
+	assert.Equal(t, "get_weather is not allowed", toolOutput)
+}
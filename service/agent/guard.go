@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	defaultToolTimeout     = 30 * time.Second
+	defaultMaxParallelTool = 4
+)
+
+// toolGuard 限制一次运行中同时执行的工具数和每个工具的执行时间，
+// 工具失败、超时或panic时把原因作为工具消息返回给模型，不中断整个运行
+type toolGuard struct {
+	name    string
+	timeout time.Duration
+	sem     chan struct{} // 同一次运行的工具共享
+}
+
+type guardedInvokableTool struct {
+	tool.InvokableTool
+	guard *toolGuard
+}
+
+type guardedStreamableTool struct {
+	tool.StreamableTool
+	guard *toolGuard
+}
+
+// guardTools 包装工具，每次运行创建一组新的包装
+func guardTools(ctx context.Context, agentTools []tool.BaseTool) ([]tool.BaseTool, error) {
+	if len(agentTools) == 0 {
+		return agentTools, nil
+	}
+
+	maxParallel := constants.Prop.Tool.MaxParallel
+	if maxParallel <= 0 {
+		maxParallel = defaultMaxParallelTool
+	}
+	sem := make(chan struct{}, maxParallel)
+
+	guarded := make([]tool.BaseTool, 0, len(agentTools))
+	for _, t := range agentTools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		guard := &toolGuard{name: info.Name, timeout: toolTimeout(info.Name), sem: sem}
+		switch v := t.(type) {
+		case tool.InvokableTool:
+			guarded = append(guarded, &guardedInvokableTool{InvokableTool: v, guard: guard})
+		case tool.StreamableTool:
+			guarded = append(guarded, &guardedStreamableTool{StreamableTool: v, guard: guard})
+		default:
+			return nil, fmt.Errorf("tool '%s' is neither invokable nor streamable", info.Name)
+		}
+	}
+	return guarded, nil
+}
+
+func toolTimeout(name string) time.Duration {
+	if timeout, found := constants.Prop.Tool.Timeouts[name]; found && timeout > 0 {
+		return timeout
+	}
+	if constants.Prop.Tool.Timeout > 0 {
+		return constants.Prop.Tool.Timeout
+	}
+	return defaultToolTimeout
+}
+
+func (t *guardedInvokableTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	output, err := guardRun(ctx, t.guard, func(ctx context.Context) (string, error) {
+		return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	}, nil, nil)
+	if err != nil {
+		return t.guard.handle(ctx, err)
+	}
+	return output, nil
+}
+
+// StreamableRun 开始输出前超时时返回超时消息，输出期间超时由工具通过ctx结束输出
+func (t *guardedStreamableTool) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	output, err := guardRun(ctx, t.guard, func(ctx context.Context) (*schema.StreamReader[string], error) {
+		return t.StreamableTool.StreamableRun(ctx, argumentsInJSON, opts...)
+	}, releaseOnClose, (*schema.StreamReader[string]).Close)
+	if err != nil {
+		content, err := t.guard.handle(ctx, err)
+		if err != nil {
+			return nil, err
+		}
+		return schema.StreamReaderFromArray([]string{content}), nil
+	}
+	return output, nil
+}
+
+// releaseOnClose 输出结束或被关闭后释放工具的ctx和并发名额
+func releaseOnClose(sr *schema.StreamReader[string], release func()) *schema.StreamReader[string] {
+	out, sw := schema.Pipe[string](1)
+	safego.Go(func() {
+		defer func() {
+			sr.Close()
+			sw.Close()
+			release()
+		}()
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if closed := sw.Send(chunk, err); closed || err != nil {
+				return
+			}
+		}
+	})
+	return out
+}
+
+type guardResult[T any] struct {
+	output T
+	err    error
+}
+
+// guardRun 等待并发名额后执行fn，超时后不再等待工具返回。并发名额和工具的ctx在工具真正返回后才释放，
+// 超时未返回的工具仍然占用名额。hold不为空时输出交给hold，在输出结束后释放；
+// 调用方已经不再等待时由discard丢弃输出
+func guardRun[T any](ctx context.Context, g *toolGuard, fn func(ctx context.Context) (T, error),
+	hold func(output T, release func()) T, discard func(output T),
+) (T, error) {
+	var zero T
+	select {
+	case g.sem <- struct{}{}:
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	release := sync.OnceFunc(func() {
+		cancel()
+		<-g.sem
+	})
+	done := make(chan guardResult[T], 1)
+	safego.Go(func() {
+		result := guardResult[T]{}
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("tool panic. Tool: %s, Panic: %v\n%s", g.name, r, string(debug.Stack()))
+				result = guardResult[T]{err: fmt.Errorf("panic: %v", r)}
+			}
+			if result.err == nil && hold != nil {
+				result.output = hold(result.output, release)
+				done <- result
+				return
+			}
+			// 先返回结果再释放，避免调用方把释放ctx当作超时
+			done <- result
+			release()
+		}()
+		result.output, result.err = fn(ctx)
+	})
+
+	select {
+	case result := <-done:
+		return result.output, result.err
+	case <-ctx.Done():
+		if discard != nil {
+			safego.Go(func() {
+				if result := <-done; result.err == nil {
+					discard(result.output)
+				}
+			})
+		}
+		return zero, ctx.Err()
+	}
+}
+
+// handle 中断错误和运行被取消需要返回给图，其他错误转换为工具消息
+func (g *toolGuard) handle(ctx context.Context, err error) (string, error) {
+	if _, ok := compose.IsInterruptRerunError(err); ok {
+		return "", err
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Warn("tool timed out. Tool: %s, Timeout: %s", g.name, g.timeout)
+		return fmt.Sprintf("Tool %s timed out after %s.", g.name, g.timeout), nil
+	}
+	logger.Warn("tool failed. Tool: %s, Error: %v", g.name, err)
+	return fmt.Sprintf("Tool %s failed: %v", g.name, err), nil
+}
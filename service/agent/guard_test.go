@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardTools(t *testing.T) {
+	maxParallel, timeouts := constants.Prop.Tool.MaxParallel, constants.Prop.Tool.Timeouts
+	constants.Prop.Tool.MaxParallel = 2
+	constants.Prop.Tool.Timeouts = map[string]time.Duration{"slow": 50 * time.Millisecond}
+	defer func() {
+		constants.Prop.Tool.MaxParallel = maxParallel
+		constants.Prop.Tool.Timeouts = timeouts
+	}()
+
+	var running, maxRunning atomic.Int32
+	newTool := func(name string, fn func(ctx context.Context) (string, error)) tool.BaseTool {
+		t, _ := utils.InferTool(name, name, func(ctx context.Context, input *weatherInput) (string, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			return fn(ctx)
+		})
+		return t
+	}
+	ok := newTool("ok", func(ctx context.Context) (string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return "sunny", nil
+	})
+	slow := newTool("slow", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	panicked := newTool("panicked", func(ctx context.Context) (string, error) {
+		panic("boom")
+	})
+	failed := newTool("failed", func(ctx context.Context) (string, error) {
+		return "", errors.New("service unavailable")
+	})
+	interrupted := newTool("interrupted", func(ctx context.Context) (string, error) {
+		return "", compose.NewInterruptAndRerunErr("need approval")
+	})
+
+	guarded, err := guardTools(context.Background(), []tool.BaseTool{ok, slow, panicked, failed, interrupted})
+	assert.Nil(t, err)
+	run := func(i int) (string, error) {
+		return guarded[i].(tool.InvokableTool).InvokableRun(context.Background(), `{"city":"beijing"}`)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := run(0)
+			assert.Nil(t, err)
+			assert.Equal(t, "sunny", output)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+
+	output, err := run(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tool slow timed out after 50ms.", output)
+
+	output, err = run(2)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tool panicked failed: panic: boom", output)
+
+	output, err = run(3)
+	assert.Nil(t, err)
+	assert.Contains(t, output, "service unavailable")
+
+	_, err = run(4)
+	_, isInterrupt := compose.IsInterruptRerunError(err)
+	assert.True(t, isInterrupt)
+}
+
+// TestGuardToolsSlot 超时后仍未返回的工具和未结束输出的流式工具继续占用并发名额
+func TestGuardToolsSlot(t *testing.T) {
+	maxParallel, timeouts := constants.Prop.Tool.MaxParallel, constants.Prop.Tool.Timeouts
+	constants.Prop.Tool.MaxParallel = 1
+	constants.Prop.Tool.Timeouts = map[string]time.Duration{"hung": 20 * time.Millisecond}
+	defer func() {
+		constants.Prop.Tool.MaxParallel = maxParallel
+		constants.Prop.Tool.Timeouts = timeouts
+	}()
+
+	unblock := make(chan struct{})
+	hung, _ := utils.InferTool("hung", "hung", func(ctx context.Context, input *weatherInput) (string, error) {
+		<-unblock
+		return "sunny", nil
+	})
+	ok, _ := utils.InferTool("ok", "ok", func(ctx context.Context, input *weatherInput) (string, error) {
+		return "sunny", nil
+	})
+	streaming, _ := utils.InferStreamTool("streaming", "streaming", func(ctx context.Context, input *weatherInput) (*schema.StreamReader[string], error) {
+		return schema.StreamReaderFromArray([]string{"sun", "ny"}), nil
+	})
+	guarded, err := guardTools(context.Background(), []tool.BaseTool{hung, ok, streaming})
+	assert.Nil(t, err)
+	runOK := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_, err := guarded[1].(tool.InvokableTool).InvokableRun(ctx, `{"city":"beijing"}`)
+		return err
+	}
+
+	output, err := guarded[0].(tool.InvokableTool).InvokableRun(context.Background(), `{"city":"beijing"}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tool hung timed out after 20ms.", output)
+	assert.ErrorIs(t, runOK(50*time.Millisecond), context.DeadlineExceeded)
+	close(unblock)
+	assert.Nil(t, runOK(time.Second))
+
+	sr, err := guarded[2].(tool.StreamableTool).StreamableRun(context.Background(), `{"city":"beijing"}`)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, runOK(50*time.Millisecond), context.DeadlineExceeded)
+	sr.Close()
+	assert.Nil(t, runOK(time.Second))
+}
+
+// TestGuardToolsCancelled 运行被取消时返回ctx的错误，不作为工具失败返回给模型
+func TestGuardToolsCancelled(t *testing.T) {
+	slow, err := utils.InferTool("slow", "slow", func(ctx context.Context, input *weatherInput) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.Nil(t, err)
+	guarded, err := guardTools(context.Background(), []tool.BaseTool{slow})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	output, err := guarded[0].(tool.InvokableTool).InvokableRun(ctx, `{"city":"beijing"}`)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, output)
+}
+
+// TestGuardStreamableTool 流式工具输出结束后释放ctx
+func TestGuardStreamableTool(t *testing.T) {
+	toolCtx := make(chan context.Context, 1)
+	streaming, err := utils.InferStreamTool("streaming", "streaming", func(ctx context.Context, input *weatherInput) (*schema.StreamReader[string], error) {
+		toolCtx <- ctx
+		return schema.StreamReaderFromArray([]string{"sun", "ny"}), nil
+	})
+	assert.Nil(t, err)
+	guarded, err := guardTools(context.Background(), []tool.BaseTool{streaming})
+	assert.Nil(t, err)
+
+	sr, err := guarded[0].(tool.StreamableTool).StreamableRun(context.Background(), `{"city":"beijing"}`)
+	assert.Nil(t, err)
+	ctx := <-toolCtx
+	assert.Nil(t, ctx.Err())
+
+	output := ""
+	for {
+		chunk, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.ErrorIs(t, recvErr, io.EOF)
+			break
+		}
+		output += chunk
+	}
+	sr.Close()
+	assert.Equal(t, "sunny", output)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("tool context not released")
+	}
+}
@@ -0,0 +1,358 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	keyOfPlannerNode     = "planner_node"
+	keyOfExecutorNode    = "executor_node"
+	keyOfReplannerNode   = "replanner_node"
+	keyOfSynthesizerNode = "synthesizer_node"
+
+	maxPlanSteps = 6
+	maxReplans   = 2
+	// maxPlanRunSteps 计划执行图最多运行的节点数：规划、每次规划的全部步骤、每次重新规划、汇总和回答
+	maxPlanRunSteps = 1 + maxPlanSteps*(maxReplans+1) + (maxReplans + 1) + 2
+)
+
+func init() {
+	_ = compose.RegisterSerializableType[planState]("ai_agent_plan_state")
+	_ = compose.RegisterSerializableType[stepResult]("ai_agent_step_result")
+	_ = compose.RegisterSerializableType[entity.Plan]("ai_agent_plan")
+}
+
+const plannerSystemPrompt = `你是%s的任务规划器，负责把用户的请求拆分为依次执行的步骤。%s
+可以使用的工具：
+%s
+要求：
+- 步骤不超过%d个，每个步骤是一条明确的指令
+- 简单的请求只需要一个步骤
+- 只输出JSON，格式为{"steps": ["步骤1", "步骤2"]}`
+
+const replannerSystemPrompt = `你是%s的任务规划器。执行计划时有步骤失败了，请根据已完成步骤的结果和失败原因，重新规划剩余的步骤。
+要求：
+- 只规划尚未完成的步骤，步骤不超过%d个
+- 已有的结果足够回答用户的请求时返回空列表
+- 只输出JSON，格式为{"steps": ["步骤1", "步骤2"]}`
+
+const synthesizerSystemPrompt = `你是%s。%s
+根据以下步骤的执行结果回答用户的请求，不要提及计划和步骤本身。`
+
+type planOutput struct {
+	Steps []string `json:"steps"`
+}
+
+// planState 计划执行模式的状态
+type planState struct {
+	Request *entity.AgentRequest
+	Results []*stepResult
+	Failure string // 最近一次失败的步骤和原因，重新规划后清空
+	Replans int
+}
+
+type stepResult struct {
+	Step   string
+	Result string
+}
+
+// planExecutor 规划器输出步骤，执行器使用ReAct图逐步执行，失败时重新规划，最后由chat_model_node汇总回答
+type planExecutor struct {
+	def       *bean.AgentDefinition
+	planner   model.BaseChatModel
+	executor  compose.Runnable[*entity.AgentRequest, *schema.Message]
+	toolInfos []*schema.ToolInfo
+}
+
+// buildPlanExecuteRunner 执行器是不保存checkpoint的ReAct图，规划器使用与回答相同的模型，要求输出JSON
+func (sa *singleAgentImpl) buildPlanExecuteRunner(ctx context.Context, def *bean.AgentDefinition, protocol chatmodel.Protocol, cfg *chatmodel.Config,
+	tpl *bean.PromptTemplate, pv *promptVariables, chatModel model.ToolCallingChatModel, agentTools []tool.BaseTool,
+) (compose.Runnable[*entity.AgentRequest, *schema.Message], error) {
+	// 步骤的结果只用于汇总，不要求符合回答的格式
+	executorModel := chatModel
+	if cfg.ResponseSchema != nil {
+		executorCfg := *cfg
+		executorCfg.ResponseSchema = nil
+		m, err := sa.Factory.CreateChatModel(protocol, &executorCfg)
+		if err != nil {
+			return nil, err
+		}
+		executorModel = m
+	}
+	executor, err := buildGraph(ctx, tpl.Content, pv, executorModel, agentTools, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plannerCfg := *cfg
+	disable := false
+	plannerCfg.Thinking = &disable
+	plannerCfg.JSONOutput = true
+	planner, err := sa.Factory.CreateChatModel(protocol, &plannerCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	toolInfos := make([]*schema.ToolInfo, 0, len(agentTools))
+	for _, t := range agentTools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		toolInfos = append(toolInfos, info)
+	}
+
+	pe := &planExecutor{def: def, planner: planner, executor: executor, toolInfos: toolInfos}
+	return buildPlanExecuteGraph(ctx, pe, chatModel, sa.CheckpointStore)
+}
+
+// buildPlanExecuteGraph planner_node -> executor_node(循环) -> synthesizer_node -> chat_model_node，
+// 步骤失败时经过replanner_node重新规划剩余步骤
+func buildPlanExecuteGraph(ctx context.Context, pe *planExecutor, chatModel model.BaseChatModel, store compose.CheckPointStore) (compose.Runnable[*entity.AgentRequest, *schema.Message], error) {
+	var (
+		g = compose.NewGraph[*entity.AgentRequest, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *planState {
+			return &planState{}
+		}))
+		compileOpts = []compose.GraphCompileOption{compose.WithNodeTriggerMode(compose.AnyPredecessor), compose.WithMaxRunSteps(maxPlanRunSteps)}
+	)
+
+	if store != nil {
+		// 每个步骤执行前保存checkpoint，实例重启后从未完成的步骤继续
+		compileOpts = append(compileOpts, compose.WithCheckPointStore(store), compose.WithInterruptBeforeNodes([]string{keyOfExecutorNode, KeyofChatModelNode}))
+	}
+
+	_ = g.AddLambdaNode(keyOfPlannerNode, compose.InvokableLambda(pe.plan), compose.WithNodeName(keyOfPlannerNode))
+	_ = g.AddLambdaNode(keyOfExecutorNode, compose.InvokableLambda(pe.execute), compose.WithNodeName(keyOfExecutorNode))
+	_ = g.AddLambdaNode(keyOfReplannerNode, compose.InvokableLambda(pe.replan), compose.WithNodeName(keyOfReplannerNode))
+	_ = g.AddLambdaNode(keyOfSynthesizerNode, compose.InvokableLambda(pe.synthesize), compose.WithNodeName(keyOfSynthesizerNode))
+	_ = g.AddChatModelNode(KeyofChatModelNode, chatModel, compose.WithNodeName(KeyofChatModelNode))
+
+	// 没有剩余步骤时汇总回答
+	nextStep := func(ctx context.Context, plan *entity.Plan) (string, error) {
+		if plan.Completed < len(plan.Steps) {
+			return keyOfExecutorNode, nil
+		}
+		return keyOfSynthesizerNode, nil
+	}
+	executed := func(ctx context.Context, plan *entity.Plan) (string, error) {
+		failed := false
+		err := compose.ProcessState[*planState](ctx, func(_ context.Context, state *planState) error {
+			failed = state.Failure != ""
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if failed {
+			return keyOfReplannerNode, nil
+		}
+		return nextStep(ctx, plan)
+	}
+	steps := map[string]bool{keyOfExecutorNode: true, keyOfSynthesizerNode: true}
+
+	_ = g.AddEdge(compose.START, keyOfPlannerNode)
+	_ = g.AddBranch(keyOfPlannerNode, compose.NewGraphBranch(nextStep, steps))
+	_ = g.AddBranch(keyOfExecutorNode, compose.NewGraphBranch(executed, map[string]bool{keyOfExecutorNode: true, keyOfReplannerNode: true, keyOfSynthesizerNode: true}))
+	_ = g.AddBranch(keyOfReplannerNode, compose.NewGraphBranch(nextStep, steps))
+	_ = g.AddEdge(keyOfSynthesizerNode, KeyofChatModelNode)
+	_ = g.AddEdge(KeyofChatModelNode, compose.END)
+
+	return g.Compile(ctx, compileOpts...)
+}
+
+// plan 规划步骤，规划失败时把整个请求作为一个步骤
+func (pe *planExecutor) plan(ctx context.Context, req *entity.AgentRequest) (*entity.Plan, error) {
+	err := compose.ProcessState[*planState](ctx, func(_ context.Context, state *planState) error {
+		state.Request = req
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tools strings.Builder
+	for _, info := range pe.toolInfos {
+		tools.WriteString(fmt.Sprintf("- %s: %s\n", info.Name, info.Desc))
+	}
+	if tools.Len() == 0 {
+		tools.WriteString("无\n")
+	}
+	persona := ""
+	if pe.def.Persona != "" {
+		persona = "\n" + pe.def.Persona
+	}
+
+	messages := []*schema.Message{schema.SystemMessage(fmt.Sprintf(plannerSystemPrompt, pe.def.Name, persona, tools.String(), maxPlanSteps))}
+	messages = append(messages, req.History...)
+	messages = append(messages, req.Input)
+
+	steps, err := pe.generateSteps(ctx, messages)
+	if err != nil || len(steps) == 0 {
+		logger.Warn("plan failed, execute the request as one step. Error: %v", err)
+		steps = []string{inputText(req.Input)}
+	}
+	return &entity.Plan{Steps: steps}, nil
+}
+
+// execute 使用ReAct图执行当前步骤，失败时记录原因交给replanner_node
+func (pe *planExecutor) execute(ctx context.Context, plan *entity.Plan) (*entity.Plan, error) {
+	var (
+		req     *entity.AgentRequest
+		results []*stepResult
+		step    = plan.Steps[plan.Completed]
+	)
+	err := compose.ProcessState[*planState](ctx, func(_ context.Context, state *planState) error {
+		req, results = state.Request, state.Results
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var input strings.Builder
+	input.WriteString(fmt.Sprintf("用户的请求：%s\n", inputText(req.Input)))
+	if len(results) > 0 {
+		input.WriteString("已完成的步骤：\n")
+		input.WriteString(formatStepResults(results))
+	}
+	input.WriteString(fmt.Sprintf("当前步骤：%s\n只完成当前步骤，输出这一步的结果。", step))
+
+	// 附件中的图片随每个步骤发送
+	stepReq := *req
+	stepReq.Input = withText(req.Input, input.String())
+	out, execErr := pe.executor.Invoke(ctx, &stepReq)
+
+	next := *plan
+	err = compose.ProcessState[*planState](ctx, func(_ context.Context, state *planState) error {
+		if execErr != nil {
+			logger.Warn("execute plan step failed. Step: %s, Error: %v", step, execErr)
+			state.Failure = fmt.Sprintf("步骤「%s」失败：%v", step, execErr)
+			return nil
+		}
+		state.Results = append(state.Results, &stepResult{Step: step, Result: out.Content})
+		next.Completed++
+		return nil
+	})
+	return &next, err
+}
+
+// replan 重新规划剩余的步骤，超过次数后直接汇总已有的结果
+func (pe *planExecutor) replan(ctx context.Context, plan *entity.Plan) (*entity.Plan, error) {
+	var (
+		req     *entity.AgentRequest
+		results []*stepResult
+		failure string
+		replans int
+	)
+	err := compose.ProcessState[*planState](ctx, func(_ context.Context, state *planState) error {
+		req, results, failure = state.Request, state.Results, state.Failure
+		state.Failure = ""
+		state.Replans++
+		replans = state.Replans
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	next := &entity.Plan{Steps: plan.Steps[:plan.Completed:plan.Completed], Completed: plan.Completed}
+	if replans > maxReplans {
+		logger.Warn("replan exceeds %d times, synthesize the completed steps", maxReplans)
+		return next, nil
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("用户的请求：%s\n", inputText(req.Input)))
+	if len(results) > 0 {
+		content.WriteString("已完成的步骤：\n")
+		content.WriteString(formatStepResults(results))
+	}
+	content.WriteString(failure)
+
+	steps, err := pe.generateSteps(ctx, []*schema.Message{
+		schema.SystemMessage(fmt.Sprintf(replannerSystemPrompt, pe.def.Name, maxPlanSteps)),
+		schema.UserMessage(content.String()),
+	})
+	if err != nil {
+		logger.Warn("replan failed, synthesize the completed steps. Error: %v", err)
+		return next, nil
+	}
+	next.Steps = append(next.Steps, steps...)
+	return next, nil
+}
+
+// synthesize 汇总各步骤的结果，作为chat_model_node的输入
+func (pe *planExecutor) synthesize(ctx context.Context, _ *entity.Plan) ([]*schema.Message, error) {
+	var (
+		req     *entity.AgentRequest
+		results []*stepResult
+	)
+	err := compose.ProcessState[*planState](ctx, func(_ context.Context, state *planState) error {
+		req, results = state.Request, state.Results
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []*schema.Message{schema.SystemMessage(fmt.Sprintf(synthesizerSystemPrompt, pe.def.Name, pe.def.Persona))}
+	messages = append(messages, req.History...)
+	return append(messages, withText(req.Input, fmt.Sprintf("%s\n\n步骤的执行结果：\n%s", inputText(req.Input), formatStepResults(results)))), nil
+}
+
+func (pe *planExecutor) generateSteps(ctx context.Context, messages []*schema.Message) ([]string, error) {
+	msg, err := pe.planner.Generate(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlan(msg.Content)
+}
+
+func formatStepResults(results []*stepResult) string {
+	var sb strings.Builder
+	for i, r := range results {
+		sb.WriteString(fmt.Sprintf("%d. %s\n%s\n", i+1, r.Step, r.Result))
+	}
+	return sb.String()
+}
+
+// parsePlan 解析规划器输出的JSON，兼容推理内容和代码块包裹
+func parsePlan(content string) ([]string, error) {
+	p := &thinkParser{}
+	_, content = p.parse(content)
+	_, rest := p.flush()
+	content += rest
+
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("plan not found in model output")
+	}
+
+	output := &planOutput{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), output); err != nil {
+		return nil, err
+	}
+
+	steps := make([]string, 0, len(output.Steps))
+	for _, step := range output.Steps {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+		if len(steps) == maxPlanSteps {
+			break
+		}
+	}
+	return steps, nil
+}
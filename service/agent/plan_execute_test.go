@@ -0,0 +1,227 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/caiflower/ai-agent/constants"
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
+	mockdefinition "github.com/caiflower/ai-agent/internal/mock/definition"
+	mockexperiment "github.com/caiflower/ai-agent/internal/mock/experiment"
+	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	mockprompt "github.com/caiflower/ai-agent/internal/mock/prompt"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/checkpoint"
+	"github.com/caiflower/ai-agent/service/knowledge"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/ai-agent/service/toolkit"
+	"github.com/caiflower/common-tools/pkg/tools"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParsePlan(t *testing.T) {
+	steps, err := parsePlan("```json\n{\"steps\": [\"查询天气\", \" \", \"推荐景点\"]}\n```")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"查询天气", "推荐景点"}, steps)
+
+	_, err = parsePlan("查询天气")
+	assert.NotNil(t, err)
+}
+
+// planChatModel 规划器返回步骤，执行器返回步骤的结果，"book hotel"失败，汇总时流式输出回答
+type planChatModel struct {
+	model.ToolCallingChatModel
+	replanned bool
+	images    int // 输入包含图片的调用次数
+}
+
+func (m *planChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	system := input[0].Content
+	last := input[len(input)-1].Content
+	if hasImages(input[len(input)-1]) {
+		m.images++
+	}
+	switch {
+	case strings.Contains(system, "重新规划"):
+		m.replanned = true
+		return schema.AssistantMessage(`{"steps": ["find hostel"]}`, nil), nil
+	case strings.Contains(system, "任务规划器"):
+		return schema.AssistantMessage(`{"steps": ["check weather", "book hotel"]}`, nil), nil
+	case strings.Contains(last, "当前步骤：book hotel"):
+		return nil, errors.New("hotel service unavailable")
+	case strings.Contains(last, "当前步骤：check weather"):
+		return schema.AssistantMessage("sunny", nil), nil
+	case strings.Contains(last, "当前步骤：find hostel"):
+		// 执行器可以看到已完成步骤的结果
+		if !strings.Contains(last, "check weather\nsunny") {
+			return nil, errors.New("missing completed steps")
+		}
+		return schema.AssistantMessage("hostel booked", nil), nil
+	}
+	return nil, errors.New("unexpected input")
+}
+
+func (m *planChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	last := input[len(input)-1].Content
+	if !strings.Contains(last, "sunny") || !strings.Contains(last, "hostel booked") {
+		return nil, errors.New("missing step results")
+	}
+	if hasImages(input[len(input)-1]) {
+		m.images++
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{
+		schema.AssistantMessage("sunny, ", nil),
+		schema.AssistantMessage("hostel booked", nil),
+	}), nil
+}
+
+func TestAgentPlanExecute(t *testing.T) {
+	profiles := constants.Prop.ModelProfiles
+	constants.Prop.ModelProfiles = []constants.ModelProfileConfig{{Name: "vision", Protocol: string(chatmodel.ProtocolMock), Vision: true}}
+	defer func() { constants.Prop.ModelProfiles = profiles }()
+
+	ctl := gomock.NewController(t)
+	chatModel := &planChatModel{}
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(chatModel, nil).Times(2)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-travel").Return(&bean.AgentDefinition{
+		AgentID:      "agent-travel",
+		Name:         "travel",
+		Mode:         bean.AgentModePlanExecute,
+		ModelProfile: "vision",
+	}, nil)
+
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, bean.RunStateFinished, run.State)
+		assert.Equal(t, "sunny, hostel booked", run.Answer)
+		return 1, nil
+	})
+
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("agent-travel", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      toolkit.NewRegistry(),
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+	}
+
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		AgentID:      "agent-travel",
+		Input:        schema.UserMessage("Plan a trip to Beijing"),
+		ChatProtocol: chatmodel.ProtocolMock,
+		Attachments:  []*entity.Attachment{{Name: "map.png", ContentType: "image/png", Data: []byte("png")}},
+	})
+	assert.Nil(t, err)
+
+	var (
+		plans  []*entity.Plan
+		answer string
+	)
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.Equal(t, io.EOF, recvErr)
+			break
+		}
+		switch event.EventType {
+		case entity.EventTypeOfPlan:
+			plans = append(plans, event.Plan)
+		case entity.EventTypeOfChatModelAnswer:
+			for {
+				chunk, chunkErr := event.ChatModelAnswer.Recv()
+				if chunkErr != nil {
+					break
+				}
+				answer += chunk.Content
+			}
+		}
+	}
+
+	assert.True(t, chatModel.replanned)
+	// 规划、三次执行步骤和汇总时发送图片，重新规划时只发送文本
+	assert.Equal(t, 5, chatModel.images)
+	assert.Equal(t, []*entity.Plan{
+		{Steps: []string{"check weather", "book hotel"}},
+		{Steps: []string{"check weather", "find hostel"}, Completed: 1},
+	}, plans)
+	assert.Equal(t, "sunny, hostel booked", answer)
+}
+
+// fullPlanChatModel 每次规划都输出最多的步骤，最后一个步骤总是失败
+type fullPlanChatModel struct {
+	model.ToolCallingChatModel
+}
+
+func (m *fullPlanChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	steps := make([]string, 0, maxPlanSteps)
+	for i := 1; i <= maxPlanSteps; i++ {
+		steps = append(steps, fmt.Sprintf("step %d", i))
+	}
+	return schema.AssistantMessage(tools.ToJson(&planOutput{Steps: steps}), nil), nil
+}
+
+func (m *fullPlanChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("done", nil)}), nil
+}
+
+// TestPlanExecuteMaxReplans 每次规划的步骤都执行到最后才失败，重新规划超过次数后仍然汇总回答
+func TestPlanExecuteMaxReplans(t *testing.T) {
+	ctx := context.Background()
+	for _, store := range []compose.CheckPointStore{nil, checkpoint.NewMemoryStore()} {
+		executed := 0
+		executor, err := compose.NewChain[*entity.AgentRequest, *schema.Message]().
+			AppendLambda(compose.InvokableLambda(func(ctx context.Context, req *entity.AgentRequest) (*schema.Message, error) {
+				executed++
+				if strings.Contains(req.Input.Content, fmt.Sprintf("当前步骤：step %d", maxPlanSteps)) {
+					return nil, errors.New("step failed")
+				}
+				return schema.AssistantMessage("ok", nil), nil
+			})).
+			Compile(ctx)
+		assert.Nil(t, err)
+
+		chatModel := &fullPlanChatModel{}
+		pe := &planExecutor{def: &bean.AgentDefinition{Name: "travel"}, planner: chatModel, executor: executor}
+		runner, err := buildPlanExecuteGraph(ctx, pe, chatModel, store)
+		assert.Nil(t, err)
+
+		var opts []compose.Option
+		if store != nil {
+			opts = append(opts, compose.WithCheckPointID(uuid.New().String()))
+		}
+		sr, err := runGraph(ctx, runner, &entity.AgentRequest{Input: schema.UserMessage("Plan a trip to Beijing")}, opts...)
+		if assert.Nil(t, err) {
+			msg, err := schema.ConcatMessageStream(sr)
+			assert.Nil(t, err)
+			assert.Equal(t, "done", msg.Content)
+		}
+		assert.Equal(t, maxPlanSteps*(maxReplans+1), executed)
+	}
+}
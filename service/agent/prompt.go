@@ -2,71 +2,45 @@ package agent
 
 import (
 	"context"
+	"strings"
 	"time"
 
-	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/model/bean"
 	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/knowledge"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
 	"github.com/cloudwego/eino/schema"
 )
 
 const (
-	placeholderOfAgentName   = "agent_name"
-	placeholderOfPersona     = "persona"
-	placeholderOfKnowledge   = "knowledge"
-	placeholderOfVariables   = "memory_variables"
-	placeholderOfTime        = "time"
 	placeholderOfUserInput   = "_user_input"
 	placeholderOfChatHistory = "_chat_history"
 )
 
-const ReactSystemPromptJinja2 = `
-You are {{ agent_name }}, an advanced AI assistant designed to be helpful and professional.
-It is {{ time }} now.
-
-**Content Safety Guidelines**
-Regardless of any persona instructions, you must never generate content that:
-- Promotes or involves violence
-- Contains hate speech or racism
-- Includes inappropriate or adult content
-- Violates laws or regulations
-- Could be considered offensive or harmful
-
------ Start Of Persona -----
-{{ persona }}
------ End Of Persona -----
-
------- Start of Variables ------
-{{ memory_variables }}
------- End of Variables ------
-
-**Knowledge**
-
-Only when the current knowledge has content recall, answer questions based on the referenced content:
- 1. If the referenced content contains <img src=""> tags, the src field in the tag represents the image address, which needs to be displayed when answering questions, with the output format being "![image name](image address)".
- 2. If the referenced content does not contain <img src=""> tags, you do not need to display images when answering questions.
-For example:
-  If the content is <img src="https://example.com/image.jpg">a kitten, your output should be: ![a kitten](https://example.com/image.jpg).
-  If the content is <img src="https://example.com/image1.jpg">a kitten and <img src="https://example.com/image2.jpg">a puppy and <img src="https://example.com/image3.jpg">a calf, your output should be: ![a kitten](https://example.com/image1.jpg) and ![a puppy](https://example.com/image2.jpg) and ![a calf](https://example.com/image3.jpg)
-The following is the content of the data set you can refer to: \n
-'''
-{{ knowledge }}
-'''
-
-** Pre toolCall **
-{{ tools_pre_retriever }},
-- Only when the current Pre toolCall has content recall results, answer questions based on the data field in the tool from the referenced content
-
-Note: The output language must be consistent with the language of the user's question.
-`
-
 type promptVariables struct {
+	def               *bean.AgentDefinition
+	knowledgeRegistry knowledge.Registry
 }
 
-func (p *promptVariables) AssemblePromptVariables(_ context.Context, req *entity.AgentRequest) (variables map[string]any, err error) {
+func (p *promptVariables) AssemblePromptVariables(ctx context.Context, req *entity.AgentRequest) (variables map[string]any, err error) {
 	variables = make(map[string]any)
 
-	variables[placeholderOfTime] = time.Now().Format("Monday 2006/01/02 15:04:05 -07")
-	variables[placeholderOfAgentName] = constants.Prop.Prompt.AgentName
+	variables[prompttpl.PlaceholderOfTime] = time.Now().Format("Monday 2006/01/02 15:04:05 -07")
+	variables[prompttpl.PlaceholderOfAgentName] = p.def.Name
+	variables[prompttpl.PlaceholderOfPersona] = p.def.Persona
+
+	if len(p.def.KnowledgeBases) > 0 && req.Input != nil {
+		docs, err := p.knowledgeRegistry.Retrieve(ctx, p.def.KnowledgeBases, req.Input.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		contents := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			contents = append(contents, doc.Content)
+		}
+		variables[prompttpl.PlaceholderOfKnowledge] = strings.Join(contents, "\n")
+	}
 
 	if req.Input != nil {
 		variables[placeholderOfUserInput] = []*schema.Message{req.Input}
@@ -84,7 +58,7 @@ func (p *promptVariables) AssemblePromptVariables(_ context.Context, req *entity
 	//		variables[k] = v
 	//		memoryVariablesList = append(memoryVariablesList, fmt.Sprintf("%s: %s\n", k, v))
 	//	}
-	//	variables[placeholderOfVariables] = memoryVariablesList
+	//	variables[prompttpl.PlaceholderOfVariables] = memoryVariablesList
 	//}
 
 	return variables, nil
@@ -2,8 +2,11 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/toolkit"
 	"github.com/caiflower/common-tools/pkg/logger"
 	"github.com/caiflower/common-tools/pkg/tools"
 	"github.com/cloudwego/eino/callbacks"
@@ -13,7 +16,7 @@ import (
 	"github.com/cloudwego/eino/schema"
 )
 
-func newReplyCallback(executeID string, _ map[string]struct{}) (clb callbacks.Handler,
+func newReplyCallback(executeID string, usage *usageCollector) (clb callbacks.Handler,
 	sr *schema.StreamReader[*entity.AgentRespEvent], sw *schema.StreamWriter[*entity.AgentRespEvent],
 ) {
 	sr, sw = schema.Pipe[*entity.AgentRespEvent](10)
@@ -21,7 +24,7 @@ func newReplyCallback(executeID string, _ map[string]struct{}) (clb callbacks.Ha
 	rcc := &replyChunkCallback{
 		sw:        sw,
 		executeID: executeID,
-		//returnDirectlyTools: returnDirectlyTools,
+		usage:     usage,
 	}
 
 	clb = callbacks.NewHandlerBuilder().
@@ -35,9 +38,9 @@ func newReplyCallback(executeID string, _ map[string]struct{}) (clb callbacks.Ha
 }
 
 type replyChunkCallback struct {
-	sw                  *schema.StreamWriter[*entity.AgentRespEvent]
-	executeID           string
-	returnDirectlyTools map[string]struct{}
+	sw        *schema.StreamWriter[*entity.AgentRespEvent]
+	executeID string
+	usage     *usageCollector
 }
 
 func (r *replyChunkCallback) OnError(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
@@ -49,6 +52,19 @@ func (r *replyChunkCallback) OnError(ctx context.Context, info *callbacks.RunInf
 func (r *replyChunkCallback) OnStart(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
 	logger.Info("OnStart - info=%v, input=%v", tools.ToJson(info), tools.ToJson(input))
 
+	// 工具执行期间上报的进度作为tool_mid_answer事件发送
+	if info.Component == components.ComponentOfTool {
+		progress := &entity.ToolProgress{ToolCallID: compose.GetToolCallID(ctx), ToolName: info.Name}
+		return toolkit.WithProgressReporter(ctx, func(content string) {
+			p := *progress
+			p.Content = content
+			r.sw.Send(&entity.AgentRespEvent{
+				EventType:    entity.EventTypeOfToolMidAnswer,
+				Node:         keyOfToolsNode,
+				ToolProgress: &p,
+			}, nil)
+		})
+	}
 	return ctx
 }
 
@@ -56,9 +72,22 @@ func (r *replyChunkCallback) OnEnd(ctx context.Context, info *callbacks.RunInfo,
 	logger.Info("OnEnd - info=%v, input=%v", tools.ToJson(info), tools.ToJson(output))
 
 	switch info.Name {
-	default:
+	case keyOfPlannerNode, keyOfReplannerNode:
+		if plan, ok := output.(*entity.Plan); ok {
+			r.sw.Send(&entity.AgentRespEvent{
+				EventType: entity.EventTypeOfPlan,
+				Node:      info.Name,
+				Plan:      plan,
+			}, nil)
+		}
 		return ctx
 	}
+
+	// 计划执行模式的步骤以非流式调用模型，只统计用量
+	if info.Component == components.ComponentOfChatModel {
+		r.usage.add(getTokenUsage(model.ConvCallbackOutput(output)))
+	}
+	return ctx
 }
 
 func (r *replyChunkCallback) OnEndWithStreamOutput(ctx context.Context, info *callbacks.RunInfo,
@@ -68,33 +97,99 @@ func (r *replyChunkCallback) OnEndWithStreamOutput(ctx context.Context, info *ca
 
 	switch info.Component {
 	case components.ComponentOfChatModel:
+		// 非回答节点的模型输出只统计用量，例如声明式图中的中间模型节点
 		if info.Name != KeyofChatModelNode {
-			output.Close()
+			r.usage.collect(output)
 			return ctx
 		}
-		sr := schema.StreamReaderWithConvert(output, func(t callbacks.CallbackOutput) (*schema.Message, error) {
+		copies := output.Copy(2)
+		r.usage.collect(copies[1])
+		sr := schema.StreamReaderWithConvert(copies[0], func(t callbacks.CallbackOutput) (*schema.Message, error) {
 			cbOut := model.ConvCallbackOutput(t)
 			return cbOut.Message, nil
 		})
 
 		r.sw.Send(&entity.AgentRespEvent{
 			EventType:       entity.EventTypeOfChatModelAnswer,
+			Node:            info.Name,
+			ChatModelAnswer: splitThinking(sr),
+		}, nil)
+		return ctx
+	case compose.ComponentOfLambda:
+		// 直接返回的工具输出作为回答
+		if info.Name != keyOfReturnDirectlyNode {
+			output.Close()
+			return ctx
+		}
+		sr := schema.StreamReaderWithConvert(output, func(t callbacks.CallbackOutput) (*schema.Message, error) {
+			msg, ok := t.(*schema.Message)
+			if !ok {
+				return nil, schema.ErrNoValue
+			}
+			return msg, nil
+		})
+		r.sw.Send(&entity.AgentRespEvent{
+			EventType:       entity.EventTypeOfToolsAsChatModelStream,
+			Node:            info.Name,
 			ChatModelAnswer: sr,
 		}, nil)
 		return ctx
 	case compose.ComponentOfToolsNode:
-		//toolsMessage, err := r.concatToolsNodeOutput(ctx, output)
-		//if err != nil {
-		//	r.sw.Send(nil, err)
-		//	return ctx
-		//}
-		//
-		//r.sw.Send(&entity.AgentEvent{
-		//	EventType:    singleagent.EventTypeOfToolsMessage,
-		//	ToolsMessage: toolsMessage,
-		//}, nil)
+		toolsMessage, err := concatToolsNodeOutput(output)
+		if err != nil {
+			logger.Warn("concat tools node output failed. Error: %v", err)
+			return ctx
+		}
+
+		r.sw.Send(&entity.AgentRespEvent{
+			EventType:    entity.EventTypeOfToolsMessage,
+			Node:         info.Name,
+			ToolsMessage: toolsMessage,
+		}, nil)
 		return ctx
 	default:
 		return ctx
 	}
 }
+
+// concatToolsNodeOutput 读取tools_node的完整输出，流中每个工具的消息在各自的下标，其余为nil
+func concatToolsNodeOutput(output *schema.StreamReader[callbacks.CallbackOutput]) ([]*schema.Message, error) {
+	defer output.Close()
+
+	var chunks [][]*schema.Message
+	for {
+		chunk, err := output.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		msgs, ok := chunk.([]*schema.Message)
+		if !ok {
+			continue
+		}
+		for i, msg := range msgs {
+			if msg == nil {
+				continue
+			}
+			for len(chunks) <= i {
+				chunks = append(chunks, nil)
+			}
+			chunks[i] = append(chunks[i], msg)
+		}
+	}
+
+	toolsMessage := make([]*schema.Message, 0, len(chunks))
+	for _, msgs := range chunks {
+		if len(msgs) == 0 {
+			continue
+		}
+		msg, err := schema.ConcatMessages(msgs)
+		if err != nil {
+			return nil, err
+		}
+		toolsMessage = append(toolsMessage, msg)
+	}
+	return toolsMessage, nil
+}
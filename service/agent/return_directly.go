@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"slices"
+
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+const keyOfReturnDirectlyNode = "return_directly_node"
+
+// getReturnDirectlyToolIndex 返回第一个直接返回工具在工具调用中的下标，没有时为-1。
+// 部分模型的工具调用没有ID，按下标对应tools_node的输出
+func getReturnDirectlyToolIndex(input *schema.Message, returnDirectlyTools []string) int {
+	return slices.IndexFunc(input.ToolCalls, func(toolCall schema.ToolCall) bool {
+		return slices.Contains(returnDirectlyTools, toolCall.Function.Name)
+	})
+}
+
+// addReturnDirectly tools_node调用了直接返回的工具时经过return_directly_node结束，
+// 该工具的输出作为回答，不再请求模型；否则回到chat_model_node
+func addReturnDirectly(g *compose.Graph[*entity.AgentRequest, *schema.Message]) {
+	returnDirectly := func(ctx context.Context, sr *schema.StreamReader[[]*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
+		var index int
+		err := compose.ProcessState[*agentState](ctx, func(_ context.Context, state *agentState) error {
+			index = state.ReturnDirectlyToolIndex
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// tools_node的流式输出中每个工具的消息在各自的下标，其余为nil
+		return schema.StreamReaderWithConvert(sr, func(msgs []*schema.Message) (*schema.Message, error) {
+			if index < len(msgs) && msgs[index] != nil {
+				return schema.AssistantMessage(msgs[index].Content, nil), nil
+			}
+			return nil, schema.ErrNoValue
+		}), nil
+	}
+
+	toolsBranch := func(ctx context.Context, sr *schema.StreamReader[[]*schema.Message]) (string, error) {
+		sr.Close()
+
+		next := KeyofChatModelNode
+		err := compose.ProcessState[*agentState](ctx, func(_ context.Context, state *agentState) error {
+			if state.ReturnDirectlyToolIndex >= 0 {
+				next = keyOfReturnDirectlyNode
+			}
+			return nil
+		})
+		return next, err
+	}
+
+	_ = g.AddLambdaNode(keyOfReturnDirectlyNode, compose.TransformableLambda(returnDirectly), compose.WithNodeName(keyOfReturnDirectlyNode))
+	_ = g.AddBranch(keyOfToolsNode, compose.NewStreamGraphBranch(toolsBranch, map[string]bool{KeyofChatModelNode: true, keyOfReturnDirectlyNode: true}))
+	_ = g.AddEdge(keyOfReturnDirectlyNode, compose.END)
+}
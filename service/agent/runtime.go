@@ -1,11 +1,20 @@
 package agent
 
 import (
+	"context"
+
 	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/bean"
 	"github.com/caiflower/ai-agent/model/entity"
 	"github.com/cloudwego/eino/schema"
 )
 
 type Runtime interface {
-	Run(*entity.AgentRequest) (*schema.StreamReader[*apiv1.ChatEvent], error)
+	// Run ctx结束后中止运行，已经生成的回答仍然记录
+	Run(context.Context, *entity.AgentRequest) (*schema.StreamReader[*apiv1.ChatEvent], error)
+	Resume(context.Context, *entity.ResumeRequest) (*schema.StreamReader[*apiv1.ChatEvent], error)
+	// Cancel 中止本实例上请求ID对应的运行，只有发起请求的用户可以取消
+	Cancel(requestID, user string) error
+	Recover() ([]*entity.RecoveredRun, error)
+	GetRun(runID, user string) (*bean.AgentRun, error)
 }
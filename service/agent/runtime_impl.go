@@ -1,72 +1,123 @@
 package agent
 
 import (
+	"context"
 	"errors"
 	"io"
+	"sync"
 
 	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	"github.com/caiflower/ai-agent/model/bean"
 	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/definition"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
 	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/pkg/safego"
 	"github.com/cloudwego/eino/schema"
 )
 
+var ErrChatNotRunning = errors.New("chat is not running")
+
 type agentRuntime struct {
-	SingleAgent SingleAgent `autowired:""`
+	SingleAgent       SingleAgent        `autowired:""`
+	DefinitionManager definition.Manager `autowired:""`
+	Factory           chatmodel.Factory  `autowired:""`
+	running           sync.Map           // 本实例正在运行的请求，key为请求ID
+}
+
+// runningChat 按请求ID注册的运行
+type runningChat struct {
+	user   string
+	cancel context.CancelFunc
 }
 
 func NewAgentRuntime() Runtime {
 	return &agentRuntime{}
 }
 
-func (r *agentRuntime) Run(request *entity.AgentRequest) (*schema.StreamReader[*apiv1.ChatEvent], error) {
-	//var (
-	//	mainChan = make(chan *entity.AgentRespEvent, 100)
-	//)
+// Run 定义了专家智能体时由监督者路由，否则直接运行单智能体
+func (r *agentRuntime) Run(ctx context.Context, request *entity.AgentRequest) (*schema.StreamReader[*apiv1.ChatEvent], error) {
+	def, err := r.DefinitionManager.Get(request.AgentID)
+	if err != nil {
+		logger.Error("get agent definition failed. Error: %v", err)
+		return nil, err
+	}
 
-	singleAgentSr, err := r.SingleAgent.StreamExecute(request)
+	ctx, chat := r.register(ctx, request.RequestID, request.User)
+	var sr *schema.StreamReader[*apiv1.ChatEvent]
+	if len(def.SubAgents) > 0 {
+		sr, err = r.supervise(ctx, def, request)
+	} else {
+		sr, err = r.SingleAgent.StreamExecute(ctx, request)
+	}
 	if err != nil {
 		logger.Error("runtime run failed. Error: %v", err)
+		r.unregister(request.RequestID, chat)
+		return nil, err
+	}
+
+	return r.track(request.RequestID, chat, sr), nil
+}
+
+func (r *agentRuntime) Resume(ctx context.Context, request *entity.ResumeRequest) (*schema.StreamReader[*apiv1.ChatEvent], error) {
+	ctx, chat := r.register(ctx, request.RequestID, request.User)
+	singleAgentSr, err := r.SingleAgent.Resume(ctx, request)
+	if err != nil {
+		logger.Error("runtime resume failed. Error: %v", err)
+		r.unregister(request.RequestID, chat)
 		return nil, err
 	}
 
-	return singleAgentSr, nil
-	//sr, sw := schema.Pipe[*apiv1.ChatEvent](10)
-	//
-	//safego.Go(func() {
-	//	r.pull(mainChan, singleAgentSr)
-	//})
-	//
-	//safego.Go(func() {
-	//	r.push(mainChan)
-	//})
-	//
-	//return sr, nil
+	return r.track(request.RequestID, chat, singleAgentSr), nil
 }
 
-func (r *agentRuntime) pull(ch chan<- *entity.AgentRespEvent, sr *schema.StreamReader[*apiv1.ChatEvent]) {
-	for {
-		recv, recvErr := sr.Recv()
-		if recvErr != nil {
-			if errors.Is(recvErr, io.EOF) {
+func (r *agentRuntime) Cancel(requestID, user string) error {
+	v, ok := r.running.Load(requestID)
+	if !ok || v.(*runningChat).user != user {
+		return ErrChatNotRunning
+	}
+	v.(*runningChat).cancel()
+	return nil
+}
+
+func (r *agentRuntime) register(ctx context.Context, requestID, user string) (context.Context, *runningChat) {
+	ctx, cancel := context.WithCancel(ctx)
+	chat := &runningChat{user: user, cancel: cancel}
+	if requestID != "" {
+		r.running.Store(requestID, chat)
+	}
+	return ctx, chat
+}
+
+func (r *agentRuntime) unregister(requestID string, chat *runningChat) {
+	chat.cancel()
+	r.running.CompareAndDelete(requestID, chat)
+}
+
+// track 转发运行的事件，事件流结束后取消注册
+func (r *agentRuntime) track(requestID string, chat *runningChat, sr *schema.StreamReader[*apiv1.ChatEvent]) *schema.StreamReader[*apiv1.ChatEvent] {
+	out, sw := schema.Pipe[*apiv1.ChatEvent](10)
+	safego.Go(func() {
+		defer r.unregister(requestID, chat)
+		defer sw.Close()
+		defer sr.Close()
+		for {
+			event, err := sr.Recv()
+			if err == io.EOF {
 				return
 			}
-		}
-		switch recv.EventType {
-		case entity.EventTypeOfChatModelAnswer:
-			for {
-				message, recvErr := recv.ChatModelAnswer.Recv()
-				if recvErr != nil {
-
-				}
-				logger.Info("message: %v", message)
+			if closed := sw.Send(event, err); closed || err != nil {
+				return
 			}
-		default:
-			logger.Warn("unknown eventType: %s", recv.EventType)
 		}
-	}
-
+	})
+	return out
 }
 
-func (r *agentRuntime) push(ch <-chan *entity.AgentRespEvent) {
+func (r *agentRuntime) Recover() ([]*entity.RecoveredRun, error) {
+	return r.SingleAgent.Recover()
+}
 
+func (r *agentRuntime) GetRun(runID, user string) (*bean.AgentRun, error) {
+	return r.SingleAgent.GetRun(runID, user)
 }
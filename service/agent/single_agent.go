@@ -1,11 +1,21 @@
 package agent
 
 import (
+	"context"
+
+	"github.com/caiflower/ai-agent/model/bean"
 	entity "github.com/caiflower/ai-agent/model/entity"
 	"github.com/cloudwego/eino/schema"
 )
 
 //go:generate mockgen -destination ../../internal/mock/agent/sigle_agent_mock.go -package agent -source single_agent.go
 type SingleAgent interface {
-	StreamExecute(req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error)
+	// StreamExecute ctx结束后中止运行并发送取消事件
+	StreamExecute(ctx context.Context, req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error)
+	// Resume 按用户对工具调用的决定恢复中断的运行
+	Resume(ctx context.Context, req *entity.ResumeRequest) (*schema.StreamReader[*entity.AgentRespEvent], error)
+	// Recover 实例重启后恢复上次退出时未完成的运行，没有checkpoint的运行标记为失败
+	Recover() ([]*entity.RecoveredRun, error)
+	// GetRun 查询用户的运行记录
+	GetRun(runID, user string) (*bean.AgentRun, error)
 }
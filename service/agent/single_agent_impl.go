@@ -2,16 +2,33 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
 	entity "github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/checkpoint"
+	"github.com/caiflower/ai-agent/service/definition"
+	"github.com/caiflower/ai-agent/service/experiment"
+	"github.com/caiflower/ai-agent/service/flow"
+	"github.com/caiflower/ai-agent/service/knowledge"
 	"github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/policy"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/ai-agent/service/toolkit"
 	"github.com/caiflower/common-tools/pkg/logger"
 	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/caiflower/common-tools/pkg/tools"
+	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
@@ -21,56 +38,334 @@ const (
 	KeyofChatModelNode   = "chat_model_node"
 	keyOfPromptVariables = "prompt_variables"
 	keyOfPromptTemplate  = "prompt_template"
+	keyOfToolsNode       = "tools_node"
+
+	defaultMaxRunSteps = 20
+)
+
+// agentState 图运行期间的状态，保存发送给模型的完整消息列表
+type agentState struct {
+	Messages  []*schema.Message
+	Decisions map[string]*entity.ToolDecision // 用户对待确认工具调用的决定，key为工具调用ID
+	// ReturnDirectlyToolIndex 模型调用了直接返回的工具时为该调用的下标，该调用的输出作为回答；否则为-1
+	ReturnDirectlyToolIndex int
+}
+
+var (
+	ErrRunNotFound       = errors.New("run not found")
+	ErrRunNotInterrupted = errors.New("run is not interrupted")
+	ErrCheckpointExpired = errors.New("checkpoint expired")
 )
 
 type singleAgentImpl struct {
-	Factory chatmodel.Factory `autowired:""`
+	Factory           chatmodel.Factory  `autowired:""`
+	DefinitionManager definition.Manager `autowired:""`
+	ToolRegistry      toolkit.Registry   `autowired:""`
+	KnowledgeRegistry knowledge.Registry `autowired:""`
+	PromptManager     prompttpl.Manager  `autowired:""`
+	AgentRunDao       dao.AgentRunDao    `autowired:""`
+	ExperimentManager experiment.Manager `autowired:""`
+	CheckpointStore   checkpoint.Store   `autowired:""`
+	FlowManager       flow.Manager       `autowired:""`
+	PolicyEngine      policy.Engine      `autowired:""`
 }
 
 func NewSingleAgent() SingleAgent {
 	return &singleAgentImpl{}
 }
 
-func (sa *singleAgentImpl) StreamExecute(req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
-	var (
-		g           = compose.NewGraph[*entity.AgentRequest, *schema.Message]()
-		composeOpts []compose.Option
-		pv          = promptVariables{}
-		ctx         = context.Background()
-		pt          = prompt.FromMessages(
-			schema.Jinja2,
-			schema.SystemMessage(ReactSystemPromptJinja2),
-			schema.MessagesPlaceholder(placeholderOfChatHistory, true),
-			schema.MessagesPlaceholder(placeholderOfUserInput, false),
-		)
-		executeID = uuid.New()
-	)
+func (sa *singleAgentImpl) StreamExecute(ctx context.Context, req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
+	def, err := sa.DefinitionManager.Get(req.AgentID)
+	if err != nil {
+		logger.Error("get agent definition failed. Error: %v", err)
+		return nil, err
+	}
 
-	//callback handle
-	hdl, sr, sw := newReplyCallback(executeID.String(), nil)
-	composeOpts = append(composeOpts, compose.WithCallbacks(hdl))
+	assignment, err := sa.ExperimentManager.Assign(def.AgentID, req.User)
+	if err != nil {
+		logger.Error("assign experiment failed. Error: %v", err)
+		return nil, err
+	}
+	if assignment != nil && assignment.Variant.ModelProfile != "" {
+		if req.ModelProfile != "" {
+			// 请求指定的模型优先于变体的模型，变体不生效时不计入实验，避免混入其他模型的数据
+			assignment = nil
+		} else {
+			overridden := *def
+			overridden.ModelProfile = assignment.Variant.ModelProfile
+			def = &overridden
+		}
+	}
 
-	chatModel, err := sa.Factory.CreateChatModel(req.ChatProtocol, buildConfig(req))
+	tpl, err := sa.getPromptTemplate(assignment)
+	if err != nil {
+		logger.Error("get prompt template failed. Error: %v", err)
+		return nil, err
+	}
+
+	exec, err := sa.prepare(def, req, tpl)
+	if err != nil {
+		return nil, err
+	}
+	withInput := withAttachments(req, exec.cfg.Vision)
+	if def.Mode == bean.AgentModeFlow && hasImages(withInput.Input) {
+		return nil, ErrImageAttachmentsUnsupported
+	}
+
+	exec.run = &bean.AgentRun{
+		RunID:          uuid.New().String(),
+		RequestID:      req.RequestID,
+		User:           req.User,
+		AgentID:        def.AgentID,
+		ConversationID: req.ConversationID,
+		PromptName:     tpl.Name,
+		PromptVersion:  tpl.Version,
+		ModelProfile:   modelProfile(def, req),
+		Model:          exec.cfg.Model,
+		ChatProtocol:   string(req.ChatProtocol),
+		State:          bean.RunStateRunning,
+		Instance:       constants.InstanceName(),
+		Input:          req.Input.Content,
+	}
+	if req.Options != nil {
+		exec.run.Options = tools.ToJson(req.Options)
+	}
+	if assignment != nil {
+		exec.run.Experiment = assignment.ExperimentID
+		exec.run.Variant = assignment.Variant.Name
+	}
+	if err = sa.AgentRunDao.Insert(exec.run); err != nil {
+		logger.Error("record agent run failed. Error: %v", err)
+		return nil, err
+	}
+
+	// 运行记录中的输入为用户输入的文本，不包含附件
+	return sa.execute(ctx, exec, withInput), nil
+}
+
+func (sa *singleAgentImpl) Resume(ctx context.Context, req *entity.ResumeRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
+	run, err := sa.GetRun(req.RunID, req.User)
+	if err != nil {
+		return nil, err
+	}
+	if run.State != bean.RunStateInterrupted {
+		return nil, ErrRunNotInterrupted
+	}
+
+	exec, agentReq, err := sa.restore(run, req.RequestID, req.Reasoning)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一个中断只允许恢复一次
+	rows, err := sa.AgentRunDao.Claim(run.RunID, bean.RunStateInterrupted, constants.InstanceName())
+	if err != nil {
+		logger.Error("claim agent run failed. Error: %v", err)
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrRunNotInterrupted
+	}
+	run.State = bean.RunStateRunning
+
+	return sa.execute(ctx, exec, agentReq, compose.WithStateModifier(func(ctx context.Context, path compose.NodePath, state any) error {
+		s, ok := state.(*agentState)
+		if !ok {
+			return fmt.Errorf("unexpected state type %T", state)
+		}
+		s.Decisions = req.Decisions
+		return nil
+	})), nil
+}
+
+func (sa *singleAgentImpl) Recover() ([]*entity.RecoveredRun, error) {
+	instance := constants.InstanceName()
+	runs, err := sa.AgentRunDao.ListByInstance(instance, bean.RunStateRunning)
+	if err != nil {
+		logger.Error("list unfinished agent runs failed. Error: %v", err)
+		return nil, err
+	}
+
+	var recovered []*entity.RecoveredRun
+	for _, run := range runs {
+		// 恢复的运行没有请求参数，不输出推理内容
+		exec, agentReq, err := sa.restore(run, run.RequestID, entity.ReasoningModeHide)
+		if err != nil {
+			logger.Error("recover agent run failed. RunID: %s, Error: %v", run.RunID, err)
+			if _, err = sa.AgentRunDao.UpdateState(run.RunID, bean.RunStateRunning, bean.RunStateFailed); err != nil {
+				logger.Error("update agent run state failed. RunID: %s, Error: %v", run.RunID, err)
+			}
+			continue
+		}
+
+		logger.Info("recover agent run. RunID: %s", run.RunID)
+		recovered = append(recovered, &entity.RecoveredRun{RunID: run.RunID, RequestID: run.RequestID, User: run.User, Events: sa.execute(context.Background(), exec, agentReq)})
+	}
+	return recovered, nil
+}
+
+func (sa *singleAgentImpl) GetRun(runID, user string) (*bean.AgentRun, error) {
+	run, err := sa.AgentRunDao.GetByRunID(runID)
+	if err != nil {
+		logger.Error("get agent run failed. Error: %v", err)
+		return nil, err
+	}
+	if run == nil || run.User != user {
+		return nil, ErrRunNotFound
+	}
+	return run, nil
+}
+
+// restore 使用运行开始时的模型和提示词版本重建图，checkpoint不存在时无法恢复
+func (sa *singleAgentImpl) restore(run *bean.AgentRun, requestID string, reasoning entity.ReasoningMode) (*execution, *entity.AgentRequest, error) {
+	if _, found, err := sa.CheckpointStore.Get(context.Background(), run.RunID); err != nil {
+		logger.Error("get checkpoint failed. Error: %v", err)
+		return nil, nil, err
+	} else if !found {
+		return nil, nil, ErrCheckpointExpired
+	}
+
+	def, err := sa.DefinitionManager.Get(run.AgentID)
+	if err != nil {
+		logger.Error("get agent definition failed. Error: %v", err)
+		return nil, nil, err
+	}
+	overridden := *def
+	overridden.ModelProfile = run.ModelProfile
+	def = &overridden
+
+	tpl, err := sa.PromptManager.GetVersion(run.PromptName, run.PromptVersion)
+	if err != nil {
+		logger.Error("get prompt template failed. Error: %v", err)
+		return nil, nil, err
+	}
+
+	agentReq := &entity.AgentRequest{
+		RequestID:      requestID,
+		User:           run.User,
+		AgentID:        run.AgentID,
+		ConversationID: run.ConversationID,
+		ChatProtocol:   chatmodel.Protocol(run.ChatProtocol),
+		Reasoning:      reasoning,
+	}
+	if run.Options != "" {
+		agentReq.Options = &entity.ModelOptions{}
+		if err = json.Unmarshal([]byte(run.Options), agentReq.Options); err != nil {
+			logger.Error("unmarshal run options failed. RunID: %s, Error: %v", run.RunID, err)
+			return nil, nil, err
+		}
+	}
+	exec, err := sa.prepare(def, agentReq, tpl)
+	if err != nil {
+		return nil, nil, err
+	}
+	exec.run = run
+	return exec, agentReq, nil
+}
+
+// execution 一次运行需要的模型配置、图和运行记录，新建和恢复运行共用
+type execution struct {
+	def      *bean.AgentDefinition
+	protocol chatmodel.Protocol
+	cfg      *chatmodel.Config
+	runner   compose.Runnable[*entity.AgentRequest, *schema.Message]
+	run      *bean.AgentRun
+}
+
+func (sa *singleAgentImpl) prepare(def *bean.AgentDefinition, req *entity.AgentRequest, tpl *bean.PromptTemplate) (*execution, error) {
+	ctx := context.Background()
+
+	protocol, cfg, err := buildConfig(def, req)
+	if err != nil {
+		logger.Error("build chat model config failed. Error: %v", err)
+		return nil, err
+	}
+
+	// ReAct的chat_model_node还要调用工具，不约束输出格式，回答由structure校验和修正；
+	// 计划执行模式的chat_model_node只汇总回答，按格式输出
+	modelCfg := cfg
+	if def.Mode != bean.AgentModePlanExecute && cfg.ResponseSchema != nil {
+		reactCfg := *cfg
+		reactCfg.ResponseSchema = nil
+		modelCfg = &reactCfg
+	}
+	chatModel, err := sa.Factory.CreateChatModel(protocol, modelCfg)
 	if err != nil {
 		logger.Error("create cmodel failed. Error: %v", err)
 		return nil, err
 	}
 
-	_ = g.AddLambdaNode(keyOfPromptVariables, compose.InvokableLambda[*entity.AgentRequest, map[string]any](pv.AssemblePromptVariables), compose.WithNodeName(keyOfPromptVariables))
-	_ = g.AddChatTemplateNode(keyOfPromptTemplate, pt, compose.WithNodeName(keyOfPromptTemplate))
-	_ = g.AddChatModelNode(KeyofChatModelNode, chatModel, compose.WithNodeName(KeyofChatModelNode))
+	clientTools, err := clientToolInfos(def, req.Options)
+	if err != nil {
+		return nil, err
+	}
 
-	_ = g.AddEdge(compose.START, keyOfPromptVariables)
-	_ = g.AddEdge(keyOfPromptVariables, keyOfPromptTemplate)
-	_ = g.AddEdge(keyOfPromptTemplate, KeyofChatModelNode)
-	_ = g.AddEdge(KeyofChatModelNode, compose.END)
+	agentTools, err := sa.ToolRegistry.GetTools(def.Tools)
+	if err != nil {
+		logger.Error("get agent tools failed. Error: %v", err)
+		return nil, err
+	}
+	// 计划执行模式的步骤不能中断等待确认
+	agentTools, err = wrapApprovalTools(ctx, agentTools, def.ApprovalTools, def.Mode != bean.AgentModePlanExecute, sa.PolicyEngine)
+	if err != nil {
+		logger.Error("wrap approval tools failed. Error: %v", err)
+		return nil, err
+	}
+	agentTools, err = guardTools(ctx, agentTools)
+	if err != nil {
+		logger.Error("guard agent tools failed. Error: %v", err)
+		return nil, err
+	}
 
-	runner, err := g.Compile(ctx)
+	pv := &promptVariables{def: def, knowledgeRegistry: sa.KnowledgeRegistry}
+	var runner compose.Runnable[*entity.AgentRequest, *schema.Message]
+	switch def.Mode {
+	case bean.AgentModePlanExecute:
+		runner, err = sa.buildPlanExecuteRunner(ctx, def, protocol, cfg, tpl, pv, chatModel, agentTools)
+	case bean.AgentModeFlow:
+		runner, err = sa.buildFlowRunner(ctx, def, pv)
+	default:
+		runner, err = buildGraph(ctx, tpl.Content, pv, chatModel, agentTools, def.ReturnDirectlyTools, clientTools, sa.CheckpointStore)
+	}
 	if err != nil {
 		logger.Error("compile graph failed. Error: %v", err)
 		return nil, err
 	}
 
+	return &execution{
+		def:      def,
+		protocol: protocol,
+		cfg:      cfg,
+		runner:   runner,
+	}, nil
+}
+
+// execute 异步运行图，运行结束、中断、取消或失败后记录运行结果
+func (sa *singleAgentImpl) execute(ctx context.Context, exec *execution, req *entity.AgentRequest, opts ...compose.Option) *schema.StreamReader[*entity.AgentRespEvent] {
+	var (
+		runCtx      = withRun(ctx, exec.run)
+		run         = exec.run
+		composeOpts = opts
+	)
+
+	//callback handle
+	usage := &usageCollector{}
+	hdl, sr, sw := newReplyCallback(run.RunID, usage)
+	composeOpts = append(composeOpts, compose.WithCallbacks(hdl), compose.WithCheckPointID(run.RunID))
+	if modelOpts := modelOptions(req.Options); len(modelOpts) > 0 {
+		composeOpts = append(composeOpts, compose.WithChatModelOption(modelOpts...))
+	}
+
+	sw.Send(&entity.AgentRespEvent{
+		EventType: entity.EventTypeOfRunInfo,
+		RunInfo: &entity.RunInfo{
+			RunID:      run.RunID,
+			AgentID:    run.AgentID,
+			Experiment: run.Experiment,
+			Variant:    run.Variant,
+		},
+	}, nil)
+
 	safego.Go(func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -81,18 +376,336 @@ func (sa *singleAgentImpl) StreamExecute(req *entity.AgentRequest) (*schema.Stre
 			sw.Close()
 		}()
 
-		_, _ = runner.Stream(ctx, req, composeOpts...)
+		// 恢复运行时只记录本次的回答，由UpdateResult追加到原记录
+		run.Answer = ""
+		run.Pending = ""
+		out, err := runGraph(runCtx, exec.runner, req, composeOpts...)
+		if err != nil {
+			if info, ok := compose.ExtractInterruptInfo(err); ok {
+				interrupt := buildInterrupt(run.RunID, info)
+				run.State = bean.RunStateInterrupted
+				run.Pending = tools.ToJson(interrupt.ToolCalls)
+				sw.Send(&entity.AgentRespEvent{
+					EventType: entity.EventTypeOfInterrupt,
+					Interrupt: interrupt,
+				}, nil)
+				sa.recordResult(run, usage)
+				sendUsage(sw, run)
+			} else if ctx.Err() != nil {
+				sa.recordCancelled(sw, run, usage)
+			} else {
+				logger.Error("run graph failed. Error: %v", err)
+				run.State = bean.RunStateFailed
+				sw.Send(nil, err)
+				sa.recordResult(run, usage)
+			}
+			return
+		}
+		// 图的输出即最终回答，记录时去掉推理内容
+		var (
+			answer    strings.Builder
+			p         = &thinkParser{}
+			toolCall  bool
+			streamErr error
+		)
+		for {
+			chunk, recvErr := out.Recv()
+			if recvErr != nil {
+				if recvErr != io.EOF {
+					streamErr = recvErr
+				}
+				break
+			}
+			toolCall = toolCall || len(chunk.ToolCalls) > 0
+			_, content := p.parse(chunk.Content)
+			answer.WriteString(content)
+		}
+		_, content := p.flush()
+		answer.WriteString(content)
+		out.Close()
+
+		// 回答生成中被取消时记录已经生成的部分
+		if streamErr != nil && ctx.Err() != nil {
+			run.Answer = answer.String()
+			sa.recordCancelled(sw, run, usage)
+			return
+		}
+
+		// 调用客户端工具时没有回答，不需要校验格式
+		if exec.cfg.ResponseSchema != nil && !toolCall {
+			structured, structureErr := sa.structure(runCtx, exec, answer.String(), usage)
+			if structureErr != nil {
+				if ctx.Err() != nil {
+					run.Answer = answer.String()
+					sa.recordCancelled(sw, run, usage)
+					return
+				}
+				logger.Error("structure answer failed. Error: %v", structureErr)
+				run.State = bean.RunStateFailed
+				run.Answer = answer.String()
+				sw.Send(nil, structureErr)
+				sa.recordResult(run, usage)
+				return
+			}
+			sw.Send(&entity.AgentRespEvent{
+				EventType:  entity.EventTypeOfStructured,
+				Structured: structured,
+			}, nil)
+		}
+
+		if exec.def.Suggest && answer.Len() > 0 {
+			suggestions, suggestErr := sa.suggest(exec.protocol, exec.cfg, run.Input, answer.String())
+			if suggestErr != nil {
+				logger.Warn("generate suggestions failed. Error: %v", suggestErr)
+			} else if len(suggestions) > 0 {
+				sw.Send(&entity.AgentRespEvent{
+					EventType:   entity.EventTypeOfSuggest,
+					Suggestions: suggestions,
+				}, nil)
+			}
+		}
+
+		run.State = bean.RunStateFinished
+		run.Answer = answer.String()
+		sa.recordResult(run, usage)
+		sendUsage(sw, run)
+		if err = sa.CheckpointStore.Delete(runCtx, run.RunID); err != nil {
+			logger.Warn("delete checkpoint failed. Error: %v", err)
+		}
 	})
 
-	return sr, nil
+	return sr
+}
+
+// runGraph 运行图直到结束或等待用户确认，在节点边界中断时checkpoint已经保存，立即继续运行
+func runGraph(ctx context.Context, runner compose.Runnable[*entity.AgentRequest, *schema.Message], req *entity.AgentRequest, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+	for step := 0; ; step++ {
+		out, err := runner.Stream(ctx, req, opts...)
+		info, ok := compose.ExtractInterruptInfo(err)
+		if !ok || len(info.RerunNodes) > 0 {
+			return out, err
+		}
+		// checkpoint丢失时图会从头运行，限制继续的次数
+		maxRunSteps := constants.Prop.Agent.MaxRunSteps
+		if maxRunSteps <= 0 {
+			maxRunSteps = defaultMaxRunSteps
+		}
+		// 计划执行图在每个步骤前中断，继续的次数不少于计划执行图的节点数
+		if step >= max(maxRunSteps, maxPlanRunSteps) {
+			return nil, compose.ErrExceedMaxSteps
+		}
+	}
+}
+
+// recordResult 记录本次运行的回答和token用量，恢复运行时在原记录上累加
+func (sa *singleAgentImpl) recordResult(run *bean.AgentRun, usage *usageCollector) {
+	tokenUsage := usage.wait()
+	run.PromptTokens = tokenUsage.PromptTokens
+	run.CompletionTokens = tokenUsage.CompletionTokens
+	if _, err := sa.AgentRunDao.UpdateResult(run); err != nil {
+		logger.Error("record agent run result failed. Error: %v", err)
+	}
+}
+
+// recordCancelled 取消的运行不能再恢复，删除checkpoint
+func (sa *singleAgentImpl) recordCancelled(sw *schema.StreamWriter[*entity.AgentRespEvent], run *bean.AgentRun, usage *usageCollector) {
+	logger.Info("agent run cancelled. RunID: %s", run.RunID)
+	run.State = bean.RunStateCancelled
+	sw.Send(&entity.AgentRespEvent{EventType: entity.EventTypeOfCancelled}, nil)
+	sa.recordResult(run, usage)
+	sendUsage(sw, run)
+	if err := sa.CheckpointStore.Delete(context.Background(), run.RunID); err != nil {
+		logger.Warn("delete checkpoint failed. Error: %v", err)
+	}
+}
+
+// sendUsage 发送本次运行的token用量，恢复运行时不包含恢复前的用量
+func sendUsage(sw *schema.StreamWriter[*entity.AgentRespEvent], run *bean.AgentRun) {
+	sw.Send(&entity.AgentRespEvent{
+		EventType: entity.EventTypeOfUsage,
+		Usage:     &entity.Usage{PromptTokens: run.PromptTokens, CompletionTokens: run.CompletionTokens},
+	}, nil)
+}
+
+// getPromptTemplate 命中的实验变体指定了提示词版本时使用该版本，否则使用生效版本
+func (sa *singleAgentImpl) getPromptTemplate(assignment *experiment.Assignment) (*bean.PromptTemplate, error) {
+	if assignment != nil && assignment.Variant.PromptVersion != nil {
+		return sa.PromptManager.GetVersion(prompttpl.TemplateOfReactSystem, *assignment.Variant.PromptVersion)
+	}
+	return sa.PromptManager.GetActive(prompttpl.TemplateOfReactSystem)
 }
 
-func buildConfig(req *entity.AgentRequest) (cfg *chatmodel.Config) {
-	cfg = &chatmodel.Config{}
+// buildGraph 根据智能体定义组装图: prompt_variables -> prompt_template -> chat_model_node，
+// 绑定了工具时，chat_model_node与tools_node构成ReAct循环，直到模型不再调用工具、调用了直接返回的工具或调用了客户端的工具
+func buildGraph(ctx context.Context, systemPrompt string, pv *promptVariables, chatModel model.ToolCallingChatModel, agentTools []tool.BaseTool,
+	returnDirectlyTools []string, clientTools []*schema.ToolInfo, store compose.CheckPointStore,
+) (compose.Runnable[*entity.AgentRequest, *schema.Message], error) {
+	var (
+		g = compose.NewGraph[*entity.AgentRequest, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *agentState {
+			return &agentState{ReturnDirectlyToolIndex: -1}
+		}))
+		pt = prompt.FromMessages(
+			schema.Jinja2,
+			schema.SystemMessage(systemPrompt),
+			schema.MessagesPlaceholder(placeholderOfChatHistory, true),
+			schema.MessagesPlaceholder(placeholderOfUserInput, false),
+		)
+		compileOpts = []compose.GraphCompileOption{compose.WithNodeTriggerMode(compose.AnyPredecessor)}
+	)
+
+	if maxRunSteps := constants.Prop.Agent.MaxRunSteps; maxRunSteps > 0 {
+		compileOpts = append(compileOpts, compose.WithMaxRunSteps(maxRunSteps))
+	}
+	if store != nil {
+		// 在模型和工具节点执行前保存checkpoint，实例重启后从最近的节点边界继续
+		interruptNodes := []string{KeyofChatModelNode}
+		if len(agentTools) > 0 || len(clientTools) > 0 {
+			interruptNodes = append(interruptNodes, keyOfToolsNode)
+		}
+		compileOpts = append(compileOpts, compose.WithCheckPointStore(store), compose.WithInterruptBeforeNodes(interruptNodes))
+	}
+
+	modelPreHandle := func(ctx context.Context, input []*schema.Message, state *agentState) ([]*schema.Message, error) {
+		state.Messages = append(state.Messages, input...)
+		return state.Messages, nil
+	}
+
+	_ = g.AddLambdaNode(keyOfPromptVariables, compose.InvokableLambda[*entity.AgentRequest, map[string]any](pv.AssemblePromptVariables), compose.WithNodeName(keyOfPromptVariables))
+	_ = g.AddChatTemplateNode(keyOfPromptTemplate, pt, compose.WithNodeName(keyOfPromptTemplate))
+
+	if len(agentTools) == 0 && len(clientTools) == 0 {
+		_ = g.AddChatModelNode(KeyofChatModelNode, chatModel, compose.WithStatePreHandler(modelPreHandle), compose.WithNodeName(KeyofChatModelNode))
+
+		_ = g.AddEdge(compose.START, keyOfPromptVariables)
+		_ = g.AddEdge(keyOfPromptVariables, keyOfPromptTemplate)
+		_ = g.AddEdge(keyOfPromptTemplate, KeyofChatModelNode)
+		_ = g.AddEdge(KeyofChatModelNode, compose.END)
+		return g.Compile(ctx, compileOpts...)
+	}
+
+	toolInfos := make([]*schema.ToolInfo, 0, len(agentTools)+len(clientTools))
+	for _, t := range agentTools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		toolInfos = append(toolInfos, info)
+	}
+	clientToolNames := make([]string, 0, len(clientTools))
+	for _, info := range clientTools {
+		toolInfos = append(toolInfos, info)
+		clientToolNames = append(clientToolNames, info.Name)
+	}
+
+	toolCallingModel, err := chatModel.WithTools(toolInfos)
+	if err != nil {
+		return nil, err
+	}
+
+	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: agentTools})
+	if err != nil {
+		return nil, err
+	}
+
+	toolsPreHandle := func(ctx context.Context, input *schema.Message, state *agentState) (*schema.Message, error) {
+		// 中断恢复后tools_node重新执行，输入为空
+		if input == nil {
+			return state.Messages[len(state.Messages)-1], nil
+		}
+		state.Messages = append(state.Messages, input)
+		state.ReturnDirectlyToolIndex = getReturnDirectlyToolIndex(input, returnDirectlyTools)
+		return input, nil
+	}
+
+	_ = g.AddChatModelNode(KeyofChatModelNode, toolCallingModel, compose.WithStatePreHandler(modelPreHandle), compose.WithNodeName(KeyofChatModelNode))
+	_ = g.AddToolsNode(keyOfToolsNode, toolsNode, compose.WithStatePreHandler(toolsPreHandle), compose.WithNodeName(keyOfToolsNode))
+
+	_ = g.AddEdge(compose.START, keyOfPromptVariables)
+	_ = g.AddEdge(keyOfPromptVariables, keyOfPromptTemplate)
+	_ = g.AddEdge(keyOfPromptTemplate, KeyofChatModelNode)
+	_ = g.AddBranch(KeyofChatModelNode, compose.NewStreamGraphBranch(func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (string, error) {
+		isToolCall, isClientToolCall, err := findToolCall(sr, clientToolNames)
+		if err != nil {
+			return "", err
+		}
+		// 客户端的工具由客户端执行，模型的输出作为回答结束运行
+		if isToolCall && !isClientToolCall {
+			return keyOfToolsNode, nil
+		}
+		return compose.END, nil
+	}, map[string]bool{keyOfToolsNode: true, compose.END: true}))
+	if len(returnDirectlyTools) == 0 {
+		_ = g.AddEdge(keyOfToolsNode, KeyofChatModelNode)
+	} else {
+		addReturnDirectly(g)
+	}
+
+	return g.Compile(ctx, compileOpts...)
+}
+
+func buildConfig(def *bean.AgentDefinition, req *entity.AgentRequest) (chatmodel.Protocol, *chatmodel.Config, error) {
+	if name := modelProfile(def, req); name != "" {
+		profile, found := constants.GetModelProfile(name)
+		if !found {
+			return "", nil, fmt.Errorf("model profile not found, name=%s", name)
+		}
+		return chatmodel.Protocol(profile.Protocol), &chatmodel.Config{
+			BaseURL:        profile.Url,
+			Model:          profile.Model,
+			Timeout:        profile.Timeout,
+			Thinking:       thinking(req),
+			Vision:         profile.Vision,
+			ResponseSchema: responseSchema(def, req),
+		}, nil
+	}
+
+	cfg := &chatmodel.Config{Thinking: thinking(req), ResponseSchema: responseSchema(def, req)}
 	switch req.ChatProtocol {
 	case chatmodel.ProtocolOllama:
 		cfg.BaseURL = constants.Prop.OLlama.Url
 		cfg.Model = constants.Prop.OLlama.Model
+		cfg.Vision = constants.Prop.OLlama.Vision
+	}
+	return req.ChatProtocol, cfg, nil
+}
+
+// modelProfile 请求指定的模型配置优先于智能体定义
+func modelProfile(def *bean.AgentDefinition, req *entity.AgentRequest) string {
+	if req.ModelProfile != "" {
+		return req.ModelProfile
+	}
+	return def.ModelProfile
+}
+
+// modelOptions 请求指定的采样参数，作用于图中所有的模型节点
+func modelOptions(options *entity.ModelOptions) []model.Option {
+	if options == nil {
+		return nil
+	}
+	var opts []model.Option
+	if options.Temperature != nil {
+		opts = append(opts, model.WithTemperature(*options.Temperature))
+	}
+	if options.TopP != nil {
+		opts = append(opts, model.WithTopP(*options.TopP))
+	}
+	if options.MaxTokens != nil {
+		opts = append(opts, model.WithMaxTokens(*options.MaxTokens))
+	}
+	if len(options.Stop) > 0 {
+		opts = append(opts, model.WithStop(options.Stop))
+	}
+	if options.ToolChoice != "" {
+		opts = append(opts, model.WithToolChoice(options.ToolChoice))
+	}
+	return opts
+}
+
+// thinking 请求关闭推理时显式关闭模型的思考模式，否则使用模型默认行为
+func thinking(req *entity.AgentRequest) *bool {
+	if req.Reasoning != entity.ReasoningModeDisable {
+		return nil
 	}
-	return
+	disable := false
+	return &disable
 }
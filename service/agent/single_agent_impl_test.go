@@ -1,53 +1,1093 @@
 package agent
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/caiflower/ai-agent/constants"
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
+	mockdefinition "github.com/caiflower/ai-agent/internal/mock/definition"
+	mockexperiment "github.com/caiflower/ai-agent/internal/mock/experiment"
 	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	mockpolicy "github.com/caiflower/ai-agent/internal/mock/policy"
+	mockprompt "github.com/caiflower/ai-agent/internal/mock/prompt"
+	"github.com/caiflower/ai-agent/model/bean"
 	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/ai-agent/service/checkpoint"
+	"github.com/caiflower/ai-agent/service/definition"
+	"github.com/caiflower/ai-agent/service/experiment"
+	"github.com/caiflower/ai-agent/service/flow"
+	"github.com/caiflower/ai-agent/service/knowledge"
 	chatmodel "github.com/caiflower/ai-agent/service/model"
-	"github.com/caiflower/common-tools/pkg/bean"
+	"github.com/caiflower/ai-agent/service/policy"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/ai-agent/service/toolkit"
+	beanx "github.com/caiflower/common-tools/pkg/bean"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool/utils"
 	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/ollama/ollama/api"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
+func TestMain(m *testing.M) {
+	constants.Prop.Checkpoint.TTL = time.Minute
+	os.Exit(m.Run())
+}
+
 func TestAgentStreamExecute(t *testing.T) {
 	agent := NewSingleAgent()
 	ctl := gomock.NewController(t)
 	factory := mockchatmodel.NewMockFactory(ctl)
 	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(&chatmodel.MockChatModel{}, nil)
-	bean.AddBean(agent)
-	bean.AddBean(factory)
-	bean.Ioc()
+	beanx.AddBean(agent)
+	beanx.AddBean(factory)
+	beanx.AddBean(mockdao.NewMockAgentDefinitionDao(ctl))
+	beanx.AddBean(toolkit.NewRegistry())
+	beanx.AddBean(knowledge.NewRegistry())
+	beanx.AddBean(flow.NewRegistry())
+	beanx.AddBean(flow.NewManager())
+	beanx.AddBean(policy.NewEngine())
+	beanx.AddBean(mockdao.NewMockToolAuditLogDao(ctl))
+	beanx.AddBean(definition.NewManager())
+	promptTemplateDao := mockdao.NewMockPromptTemplateDao(ctl)
+	promptTemplateDao.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(nil, nil)
+	beanx.AddBean(promptTemplateDao)
+	beanx.AddBean(prompttpl.NewManager())
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, "What's the weather like in Beijing?", run.Input)
+		assert.Equal(t, "the weather is good", run.Answer)
+		return 1, nil
+	})
+	beanx.AddBean(agentRunDao)
+	experimentDao := mockdao.NewMockExperimentDao(ctl)
+	experimentDao.EXPECT().ListRunning().Return(nil, nil)
+	beanx.AddBean(experimentDao)
+	beanx.AddBean(experiment.NewManager())
+	beanx.AddBean(checkpoint.NewMemoryStore())
+	beanx.Ioc()
 
-	sr, apiError := agent.StreamExecute(&entity.AgentRequest{
+	sr, apiError := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		User:         "test-user",
 		Input:        schema.UserMessage("What's the weather like in Beijing?"),
 		ChatProtocol: chatmodel.ProtocolMock,
 	})
 	assert.Equal(t, apiError, nil)
 
-	agentEventSr, err := sr.Recv()
-	if err != nil {
-		if err == io.EOF {
-			return
+	assert.Equal(t, "the weather is good", receiveAnswer(t, sr))
+}
+
+func TestAgentStreamExecuteWithTools(t *testing.T) {
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(&toolCallingChatModel{}, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-weather").Return(&bean.AgentDefinition{
+		AgentID: "agent-weather",
+		Name:    "weather",
+		Tools:   []string{"get_weather"},
+	}, nil)
+
+	registry := toolkit.NewRegistry()
+	weatherTool, err := utils.InferTool("get_weather", "get weather of a city", func(ctx context.Context, input *weatherInput) (string, error) {
+		toolkit.ReportProgress(ctx, "querying "+input.City)
+		return "sunny", nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, registry.Register(weatherTool))
+
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Version: 2,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+
+	runID := ""
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) error {
+		assert.Equal(t, "agent-weather", run.AgentID)
+		assert.Equal(t, prompttpl.TemplateOfReactSystem, run.PromptName)
+		assert.Equal(t, 2, run.PromptVersion)
+		runID = run.RunID
+		return nil
+	})
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, runID, run.RunID)
+		assert.Equal(t, "the weather is sunny", run.Answer)
+		// 两次模型调用的用量累加
+		assert.Equal(t, 20, run.PromptTokens)
+		assert.Equal(t, 8, run.CompletionTokens)
+		return 1, nil
+	})
+
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("agent-weather", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      registry,
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		PolicyEngine:      allowAllPolicy(ctl),
+	}
+
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		AgentID:      "agent-weather",
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+
+	var (
+		answer   string
+		progress []*entity.ToolProgress
+		results  []*schema.Message
+	)
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.Equal(t, io.EOF, recvErr)
+			break
 		}
+		switch event.EventType {
+		case entity.EventTypeOfToolMidAnswer:
+			progress = append(progress, event.ToolProgress)
+		case entity.EventTypeOfToolsMessage:
+			results = append(results, event.ToolsMessage...)
+		case entity.EventTypeOfChatModelAnswer:
+			for {
+				chunk, chunkErr := event.ChatModelAnswer.Recv()
+				if chunkErr != nil {
+					break
+				}
+				answer += chunk.Content
+			}
+		}
+	}
+	assert.Equal(t, "the weather is sunny", answer)
+	assert.Equal(t, []*entity.ToolProgress{{ToolCallID: "call_1", ToolName: "get_weather", Content: "querying beijing"}}, progress)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "call_1", results[0].ToolCallID)
+		assert.Equal(t, "get_weather", results[0].ToolName)
+		assert.Equal(t, "sunny", results[0].Content)
+	}
+}
+
+// TestAgentStreamExecuteExperimentModelProfile 变体覆盖模型配置时，请求指定了模型配置的运行不计入实验
+func TestAgentStreamExecuteExperimentModelProfile(t *testing.T) {
+	profiles := constants.Prop.ModelProfiles
+	constants.Prop.ModelProfiles = []constants.ModelProfileConfig{
+		{Name: "variant", Protocol: string(chatmodel.ProtocolMock), Model: "variant-model"},
+		{Name: "request", Protocol: string(chatmodel.ProtocolMock), Model: "request-model"},
+	}
+	defer func() { constants.Prop.ModelProfiles = profiles }()
+
+	tests := []struct {
+		name         string
+		modelProfile string
+		wantProfile  string
+		wantModel    string
+		wantVariant  string
+	}{
+		{name: "variant", wantProfile: "variant", wantModel: "variant-model", wantVariant: "b"},
+		{name: "request", modelProfile: "request", wantProfile: "request", wantModel: "request-model"},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			factory := mockchatmodel.NewMockFactory(ctl)
+			factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{Model: tt.wantModel}).Return(&chatmodel.MockChatModel{}, nil)
 
+			definitionManager := mockdefinition.NewMockManager(ctl)
+			definitionManager.EXPECT().Get("agent-weather").Return(&bean.AgentDefinition{AgentID: "agent-weather"}, nil)
+
+			promptManager := mockprompt.NewMockManager(ctl)
+			promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+				Name:    prompttpl.TemplateOfReactSystem,
+				Content: prompttpl.ReactSystemPromptJinja2,
+			}, nil)
+
+			agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+			agentRunDao.EXPECT().Insert(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) error {
+				assert.Equal(t, tt.wantProfile, run.ModelProfile)
+				assert.Equal(t, tt.wantModel, run.Model)
+				assert.Equal(t, tt.wantVariant, run.Variant)
+				if tt.wantVariant == "" {
+					assert.Equal(t, "", run.Experiment)
+				} else {
+					assert.Equal(t, "exp-1", run.Experiment)
+				}
+				return nil
+			})
+			agentRunDao.EXPECT().UpdateResult(gomock.Any()).Return(int64(1), nil)
+
+			experimentManager := mockexperiment.NewMockManager(ctl)
+			experimentManager.EXPECT().Assign("agent-weather", "test-user").Return(&experiment.Assignment{
+				ExperimentID: "exp-1",
+				Variant:      &bean.ExperimentVariant{Name: "b", Weight: 1, ModelProfile: "variant"},
+			}, nil)
+
+			agent := &singleAgentImpl{
+				Factory:           factory,
+				DefinitionManager: definitionManager,
+				ToolRegistry:      toolkit.NewRegistry(),
+				KnowledgeRegistry: knowledge.NewRegistry(),
+				PromptManager:     promptManager,
+				AgentRunDao:       agentRunDao,
+				ExperimentManager: experimentManager,
+				CheckpointStore:   checkpoint.NewMemoryStore(),
+			}
+
+			sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+				AgentID:      "agent-weather",
+				User:         "test-user",
+				ModelProfile: tt.modelProfile,
+				Input:        schema.UserMessage("What's the weather like in Beijing?"),
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, "the weather is good", receiveAnswer(t, sr))
+		})
+	}
+}
+
+// TestAgentStreamExecuteOllamaToolCallID ollama的工具调用没有ID，进度、工具结果和模型回答中的工具调用ID一致
+func TestAgentStreamExecuteOllamaToolCallID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &api.ChatRequest{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(req))
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		msg := `{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"beijing"}}}]}`
+		if last := req.Messages[len(req.Messages)-1]; last.Role == "tool" {
+			msg = fmt.Sprintf(`{"role":"assistant","content":"the weather is %s"}`, last.Content)
+		}
+		_, _ = fmt.Fprintf(w, `{"model":"qwen3","message":%s,"done":false}`+"\n", msg)
+		_, _ = fmt.Fprintln(w, `{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`)
+	}))
+	defer srv.Close()
+
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).DoAndReturn(func(chatmodel.Protocol, *chatmodel.Config) (model.ToolCallingChatModel, error) {
+		return chatmodel.NewDefaultFactory().CreateChatModel(chatmodel.ProtocolOllama, &chatmodel.Config{BaseURL: srv.URL, Model: "qwen3"})
+	})
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-weather").Return(&bean.AgentDefinition{
+		AgentID: "agent-weather",
+		Name:    "weather",
+		Tools:   []string{"get_weather"},
+	}, nil)
+
+	registry := toolkit.NewRegistry()
+	weatherTool, err := utils.InferTool("get_weather", "get weather of a city", func(ctx context.Context, input *weatherInput) (string, error) {
+		toolkit.ReportProgress(ctx, "querying "+input.City)
+		return "sunny", nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, registry.Register(weatherTool))
+
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, bean.RunStateFinished, run.State)
+		assert.Equal(t, "the weather is sunny", run.Answer)
+		return 1, nil
+	})
+
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("agent-weather", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      registry,
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		PolicyEngine:      allowAllPolicy(ctl),
+	}
+
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		AgentID:      "agent-weather",
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+
+	var (
+		toolCalls []schema.ToolCall
+		progress  []*entity.ToolProgress
+		results   []*schema.Message
+	)
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.Equal(t, io.EOF, recvErr)
+			break
+		}
+		switch event.EventType {
+		case entity.EventTypeOfToolMidAnswer:
+			progress = append(progress, event.ToolProgress)
+		case entity.EventTypeOfToolsMessage:
+			results = append(results, event.ToolsMessage...)
+		case entity.EventTypeOfChatModelAnswer:
+			for {
+				chunk, chunkErr := event.ChatModelAnswer.Recv()
+				if chunkErr != nil {
+					break
+				}
+				toolCalls = append(toolCalls, chunk.ToolCalls...)
+			}
+		}
+	}
+	if !assert.Len(t, toolCalls, 1) || !assert.Len(t, progress, 1) || !assert.Len(t, results, 1) {
+		return
+	}
+	assert.NotEmpty(t, toolCalls[0].ID)
+	assert.Equal(t, toolCalls[0].ID, progress[0].ToolCallID)
+	assert.Equal(t, toolCalls[0].ID, results[0].ToolCallID)
+}
+
+// TestAgentStreamExecuteClientTools 模型调用客户端的工具时结束运行，工具调用作为回答返回
+func TestAgentStreamExecuteClientTools(t *testing.T) {
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(&toolCallingChatModel{}, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("").Return(&bean.AgentDefinition{Name: "assistant"}, nil)
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) error {
+		assert.Contains(t, run.Options, `"temperature":0.5`)
+		assert.Contains(t, run.Options, `"name":"get_weather"`)
+		return nil
+	})
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, bean.RunStateFinished, run.State)
+		return 1, nil
+	})
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      toolkit.NewRegistry(),
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		PolicyEngine:      allowAllPolicy(ctl),
+	}
+
+	temperature := float32(0.5)
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+		Options: &entity.ModelOptions{
+			Temperature: &temperature,
+			Tools: []*entity.ClientTool{{
+				Name:        "get_weather",
+				Description: "get weather of a city",
+				Parameters:  &jsonschema.Schema{Type: "object", Required: []string{"city"}},
+			}},
+		},
+	})
+	assert.Nil(t, err)
+
+	var (
+		toolCalls []schema.ToolCall
+		usage     *entity.Usage
+	)
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.Equal(t, io.EOF, recvErr)
+			break
+		}
+		switch event.EventType {
+		case entity.EventTypeOfToolsMessage:
+			assert.Fail(t, "client tool should not be executed")
+		case entity.EventTypeOfUsage:
+			usage = event.Usage
+		case entity.EventTypeOfChatModelAnswer:
+			for {
+				chunk, chunkErr := event.ChatModelAnswer.Recv()
+				if chunkErr != nil {
+					break
+				}
+				toolCalls = append(toolCalls, chunk.ToolCalls...)
+			}
+		}
+	}
+	if assert.Len(t, toolCalls, 1) {
+		assert.Equal(t, "get_weather", toolCalls[0].Function.Name)
+	}
+	assert.Equal(t, &entity.Usage{PromptTokens: 8, CompletionTokens: 3}, usage)
+}
+
+// TestAgentStreamExecuteCancel 回答生成中取消，记录已经生成的部分
+func TestAgentStreamExecuteCancel(t *testing.T) {
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(&cancellableChatModel{}, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("").Return(&bean.AgentDefinition{Name: "assistant"}, nil)
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, bean.RunStateCancelled, run.State)
+		assert.Equal(t, "the weather", run.Answer)
+		return 1, nil
+	})
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      toolkit.NewRegistry(),
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		PolicyEngine:      allowAllPolicy(ctl),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sr, err := agent.StreamExecute(ctx, &entity.AgentRequest{
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+
+	var events []entity.EventType
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.Equal(t, io.EOF, recvErr)
+			break
+		}
+		events = append(events, event.EventType)
+		if event.EventType == entity.EventTypeOfChatModelAnswer {
+			chunk, _ := event.ChatModelAnswer.Recv()
+			assert.Equal(t, "the weather", chunk.Content)
+			cancel()
+			event.ChatModelAnswer.Close()
+		}
+	}
+	assert.Equal(t, []entity.EventType{
+		entity.EventTypeOfRunInfo,
+		entity.EventTypeOfChatModelAnswer,
+		entity.EventTypeOfCancelled,
+		entity.EventTypeOfUsage,
+	}, events)
+}
+
+// TestAgentStreamExecuteReturnDirectly 直接返回的工具输出作为回答，不再请求模型
+func TestAgentStreamExecuteReturnDirectly(t *testing.T) {
+	t.Run("with id", func(t *testing.T) {
+		testAgentStreamExecuteReturnDirectly(t, &toolCallingChatModel{})
+	})
+	// 工具调用没有ID时按下标取直接返回工具的输出
+	t.Run("without id", func(t *testing.T) {
+		testAgentStreamExecuteReturnDirectly(t, &toolCallingChatModel{withoutID: true})
+	})
+}
+
+func testAgentStreamExecuteReturnDirectly(t *testing.T, chatModel model.ToolCallingChatModel) {
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(chatModel, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-weather").Return(&bean.AgentDefinition{
+		AgentID:             "agent-weather",
+		Name:                "weather",
+		Tools:               []string{"get_weather"},
+		ReturnDirectlyTools: []string{"get_weather"},
+	}, nil)
+
+	registry := toolkit.NewRegistry()
+	weatherTool, err := utils.InferTool("get_weather", "get weather of a city", func(ctx context.Context, input *weatherInput) (string, error) {
+		return "sunny", nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, registry.Register(weatherTool))
+
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		assert.Equal(t, bean.RunStateFinished, run.State)
+		assert.Equal(t, "sunny", run.Answer)
+		// 只有调用工具的一次模型调用
+		assert.Equal(t, 8, run.PromptTokens)
+		assert.Equal(t, 3, run.CompletionTokens)
+		return 1, nil
+	})
+
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign("agent-weather", "").Return(nil, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      registry,
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+		PolicyEngine:      allowAllPolicy(ctl),
+	}
+
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		AgentID:      "agent-weather",
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+
+	var types []entity.EventType
+	answer := ""
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			assert.Equal(t, io.EOF, recvErr)
+			break
+		}
+		types = append(types, event.EventType)
+		if event.EventType == entity.EventTypeOfToolsAsChatModelStream {
+			for {
+				chunk, chunkErr := event.ChatModelAnswer.Recv()
+				if chunkErr != nil {
+					break
+				}
+				answer += chunk.Content
+			}
+		}
+	}
+	assert.Contains(t, types, entity.EventTypeOfToolsAsChatModelStream)
+	assert.Equal(t, "sunny", answer)
+}
+
+func TestAgentStreamExecuteWithReasoning(t *testing.T) {
+	ctl := gomock.NewController(t)
+	disable := false
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{Thinking: &disable}).Return(&thinkingChatModel{}, nil)
+
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(&bean.PromptTemplate{
+		Name:    prompttpl.TemplateOfReactSystem,
+		Content: prompttpl.ReactSystemPromptJinja2,
+	}, nil)
+
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(run *bean.AgentRun) (int64, error) {
+		// 记录的回答不包含推理内容
+		assert.Equal(t, "the weather is good", run.Answer)
+		return 1, nil
+	})
+
+	experimentManager := mockexperiment.NewMockManager(ctl)
+	experimentManager.EXPECT().Assign(definition.DefaultAgentID, "").Return(nil, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("").Return(&bean.AgentDefinition{AgentID: definition.DefaultAgentID, Name: "default"}, nil)
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      toolkit.NewRegistry(),
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		ExperimentManager: experimentManager,
+		CheckpointStore:   checkpoint.NewMemoryStore(),
+	}
+
+	sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+		Reasoning:    entity.ReasoningModeDisable,
+	})
+	assert.Nil(t, err)
+
+	reasoning, answer := "", ""
+	for {
+		event, err := sr.Recv()
+		if err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+		if event.EventType != entity.EventTypeOfChatModelAnswer {
+			continue
+		}
+		for {
+			chunk, err := event.ChatModelAnswer.Recv()
+			if err != nil {
+				assert.Equal(t, io.EOF, err)
+				break
+			}
+			reasoning += chunk.ReasoningContent
+			answer += chunk.Content
+		}
+	}
+	assert.Equal(t, "the user asks about the weather", reasoning)
+	assert.Equal(t, "the weather is good", answer)
+}
+
+func TestAgentResume(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision *entity.ToolDecision
+		called   bool
+		answer   string
+	}{
+		{
+			name:     "approve",
+			decision: &entity.ToolDecision{Action: entity.DecisionActionApprove},
+			called:   true,
+			answer:   "the weather is sunny",
+		},
+		{
+			name:     "reject",
+			decision: &entity.ToolDecision{Action: entity.DecisionActionReject, Reason: "not now"},
+			answer:   "the weather is The user rejected this tool call. Reason: not now",
+		},
+		{
+			name:     "edit",
+			decision: &entity.ToolDecision{Action: entity.DecisionActionEdit, Arguments: `{"city":"hangzhou"}`},
+			called:   true,
+			answer:   "the weather is sunny",
+		},
+		{
+			// 修改后的参数仍然按策略判定
+			name:     "edit denied",
+			decision: &entity.ToolDecision{Action: entity.DecisionActionEdit, Arguments: `{"city":"shanghai"}`},
+			answer:   "the weather is shanghai is not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			factory := mockchatmodel.NewMockFactory(ctl)
+			factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(&toolCallingChatModel{}, nil).Times(2)
+
+			definitionManager := mockdefinition.NewMockManager(ctl)
+			definitionManager.EXPECT().Get("agent-weather").Return(&bean.AgentDefinition{
+				AgentID:       "agent-weather",
+				Name:          "weather",
+				Tools:         []string{"get_weather"},
+				ApprovalTools: []string{"get_weather"},
+			}, nil).Times(2)
+
+			called := false
+			registry := toolkit.NewRegistry()
+			weatherTool, err := utils.InferTool("get_weather", "get weather of a city", func(ctx context.Context, input *weatherInput) (string, error) {
+				called = true
+				return "sunny", nil
+			})
+			assert.Nil(t, err)
+			assert.Nil(t, registry.Register(weatherTool))
+
+			tpl := &bean.PromptTemplate{Name: prompttpl.TemplateOfReactSystem, Version: 1, Content: prompttpl.ReactSystemPromptJinja2}
+			promptManager := mockprompt.NewMockManager(ctl)
+			promptManager.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(tpl, nil)
+			promptManager.EXPECT().GetVersion(prompttpl.TemplateOfReactSystem, 1).Return(tpl, nil)
+
+			var run *bean.AgentRun
+			agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+			agentRunDao.EXPECT().Insert(gomock.Any()).DoAndReturn(func(r *bean.AgentRun) error {
+				assert.Equal(t, bean.RunStateRunning, r.State)
+				copied := *r
+				run = &copied
+				return nil
+			})
+			gomock.InOrder(
+				agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(r *bean.AgentRun) (int64, error) {
+					assert.Equal(t, bean.RunStateInterrupted, r.State)
+					run.State = r.State
+					return 1, nil
+				}),
+				agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(r *bean.AgentRun) (int64, error) {
+					assert.Equal(t, bean.RunStateFinished, r.State)
+					assert.Equal(t, tt.answer, r.Answer)
+					return 1, nil
+				}),
+			)
+			agentRunDao.EXPECT().GetByRunID(gomock.Any()).DoAndReturn(func(runID string) (*bean.AgentRun, error) {
+				return run, nil
+			})
+			agentRunDao.EXPECT().Claim(gomock.Any(), bean.RunStateInterrupted, gomock.Any()).Return(int64(1), nil)
+
+			experimentManager := mockexperiment.NewMockManager(ctl)
+			experimentManager.EXPECT().Assign("agent-weather", "test-user").Return(nil, nil)
+
+			evaluations := 0
+			if tt.decision.Action == entity.DecisionActionEdit {
+				evaluations = 1
+			}
+			engine := mockpolicy.NewMockEngine(ctl)
+			engine.EXPECT().Evaluate(gomock.Any()).DoAndReturn(func(call *policy.ToolCall) *policy.Decision {
+				if call.Arguments == `{"city":"shanghai"}` {
+					return &policy.Decision{Action: policy.ActionDeny, Message: "shanghai is not allowed"}
+				}
+				return &policy.Decision{Action: policy.ActionAllow}
+			}).Times(evaluations)
+			engine.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			engine.EXPECT().RecordUserDecision(gomock.Any(), tt.decision)
+
+			store := checkpoint.NewMemoryStore()
+			agent := &singleAgentImpl{
+				Factory:           factory,
+				DefinitionManager: definitionManager,
+				ToolRegistry:      registry,
+				KnowledgeRegistry: knowledge.NewRegistry(),
+				PromptManager:     promptManager,
+				AgentRunDao:       agentRunDao,
+				ExperimentManager: experimentManager,
+				CheckpointStore:   store,
+				PolicyEngine:      engine,
+			}
+
+			sr, err := agent.StreamExecute(context.Background(), &entity.AgentRequest{
+				User:         "test-user",
+				AgentID:      "agent-weather",
+				Input:        schema.UserMessage("What's the weather like in Beijing?"),
+				ChatProtocol: chatmodel.ProtocolMock,
+			})
+			assert.Nil(t, err)
+
+			var interrupt *entity.Interrupt
+			for {
+				event, err := sr.Recv()
+				if err != nil {
+					assert.Equal(t, io.EOF, err)
+					break
+				}
+				if event.EventType == entity.EventTypeOfChatModelAnswer {
+					event.ChatModelAnswer.Close()
+				}
+				if event.EventType == entity.EventTypeOfInterrupt {
+					interrupt = event.Interrupt
+				}
+			}
+			assert.NotNil(t, interrupt)
+			assert.Equal(t, run.RunID, interrupt.RunID)
+			assert.Equal(t, []*entity.PendingToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"beijing"}`}}, interrupt.ToolCalls)
+			assert.False(t, called)
+
+			sr, err = agent.Resume(context.Background(), &entity.ResumeRequest{
+				User:      "test-user",
+				RunID:     interrupt.RunID,
+				Decisions: map[string]*entity.ToolDecision{"call_1": tt.decision},
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.answer, receiveAnswer(t, sr))
+			assert.Equal(t, tt.called, called)
+
+			_, found, _ := store.Get(context.Background(), interrupt.RunID)
+			assert.False(t, found)
+		})
+	}
+}
+
+func TestAgentRecover(t *testing.T) {
+	ctl := gomock.NewController(t)
+	store := checkpoint.NewMemoryStore()
+
+	calls := 0
+	registry := toolkit.NewRegistry()
+	weatherTool, err := utils.InferTool("get_weather", "get weather of a city", func(ctx context.Context, input *weatherInput) (string, error) {
+		calls++
+		return "sunny", nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, registry.Register(weatherTool))
+
+	def := &bean.AgentDefinition{AgentID: "agent-weather", Name: "weather", Tools: []string{"get_weather"}}
+	tpl := &bean.PromptTemplate{Name: prompttpl.TemplateOfReactSystem, Version: 1, Content: prompttpl.ReactSystemPromptJinja2}
+
+	// 第一个实例在工具执行后、第二次调用模型时退出
+	blocking := &blockingChatModel{entered: make(chan struct{}), release: make(chan struct{})}
+	crashedFactory := mockchatmodel.NewMockFactory(ctl)
+	crashedFactory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(blocking, nil)
+	crashedDefinition := mockdefinition.NewMockManager(ctl)
+	crashedDefinition.EXPECT().Get("agent-weather").Return(def, nil)
+	crashedPrompt := mockprompt.NewMockManager(ctl)
+	crashedPrompt.EXPECT().GetActive(prompttpl.TemplateOfReactSystem).Return(tpl, nil)
+	crashedExperiment := mockexperiment.NewMockManager(ctl)
+	crashedExperiment.EXPECT().Assign("agent-weather", "test-user").Return(nil, nil)
+
+	var run *bean.AgentRun
+	crashedRunDao := mockdao.NewMockAgentRunDao(ctl)
+	crashedRunDao.EXPECT().Insert(gomock.Any()).DoAndReturn(func(r *bean.AgentRun) error {
+		copied := *r
+		run = &copied
+		return nil
+	})
+	crashedRunDao.EXPECT().UpdateResult(gomock.Any()).Return(int64(1), nil).AnyTimes()
+
+	crashed := &singleAgentImpl{
+		Factory:           crashedFactory,
+		DefinitionManager: crashedDefinition,
+		ToolRegistry:      registry,
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     crashedPrompt,
+		AgentRunDao:       crashedRunDao,
+		ExperimentManager: crashedExperiment,
+		CheckpointStore:   store,
+		PolicyEngine:      allowAllPolicy(ctl),
+	}
+	crashedSr, err := crashed.StreamExecute(context.Background(), &entity.AgentRequest{
+		User:         "test-user",
+		AgentID:      "agent-weather",
+		Input:        schema.UserMessage("What's the weather like in Beijing?"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+	<-blocking.entered
+	assert.Equal(t, 1, calls)
+
+	// 重启后的实例从checkpoint继续，没有checkpoint的运行标记为失败
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(&toolCallingChatModel{}, nil)
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-weather").Return(def, nil)
+	promptManager := mockprompt.NewMockManager(ctl)
+	promptManager.EXPECT().GetVersion(prompttpl.TemplateOfReactSystem, 1).Return(tpl, nil)
+
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().ListByInstance(constants.InstanceName(), bean.RunStateRunning).Return([]*bean.AgentRun{run, {RunID: "run-lost", State: bean.RunStateRunning}}, nil)
+	agentRunDao.EXPECT().UpdateState("run-lost", bean.RunStateRunning, bean.RunStateFailed).Return(int64(1), nil)
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).DoAndReturn(func(r *bean.AgentRun) (int64, error) {
+		assert.Equal(t, run.RunID, r.RunID)
+		assert.Equal(t, bean.RunStateFinished, r.State)
+		assert.Equal(t, "the weather is sunny", r.Answer)
+		return 1, nil
+	})
+
+	agent := &singleAgentImpl{
+		Factory:           factory,
+		DefinitionManager: definitionManager,
+		ToolRegistry:      registry,
+		KnowledgeRegistry: knowledge.NewRegistry(),
+		PromptManager:     promptManager,
+		AgentRunDao:       agentRunDao,
+		CheckpointStore:   store,
+		PolicyEngine:      allowAllPolicy(ctl),
+	}
+	recovered, err := agent.Recover()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(recovered))
+	assert.Equal(t, run.RunID, recovered[0].RunID)
+	assert.Equal(t, "the weather is sunny", receiveAnswer(t, recovered[0].Events))
+	// 工具在退出前已经执行，恢复后不会重复执行
+	assert.Equal(t, 1, calls)
+
+	close(blocking.release)
+	for {
+		if _, err = crashedSr.Recv(); err != nil {
+			break
+		}
+	}
+}
+
+func receiveAnswer(t *testing.T, sr *schema.StreamReader[*entity.AgentRespEvent]) string {
 	message := ""
 	for {
-		chunk, err := agentEventSr.ChatModelAnswer.Recv()
+		agentEventSr, err := sr.Recv()
 		if err != nil {
 			if err == io.EOF {
-				break
+				return message
 			}
 			assert.Equal(t, err, nil)
-			return
+			return message
+		}
+		if agentEventSr.EventType != entity.EventTypeOfChatModelAnswer {
+			continue
+		}
+
+		for {
+			chunk, err := agentEventSr.ChatModelAnswer.Recv()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				assert.Equal(t, err, nil)
+				return message
+			}
+
+			message += chunk.Content
+		}
+	}
+}
+
+// allowAllPolicy 审批策略允许所有工具调用
+func allowAllPolicy(ctl *gomock.Controller) policy.Engine {
+	engine := mockpolicy.NewMockEngine(ctl)
+	engine.EXPECT().Evaluate(gomock.Any()).Return(&policy.Decision{Action: policy.ActionAllow}).AnyTimes()
+	engine.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	engine.EXPECT().RecordUserDecision(gomock.Any(), gomock.Any()).AnyTimes()
+	return engine
+}
+
+type weatherInput struct {
+	City string `json:"city"`
+}
+
+// toolCallingChatModel 第一轮调用工具，拿到工具结果后回答
+type toolCallingChatModel struct {
+	withoutID bool // 与ollama一样，工具调用没有ID
+}
+
+func (m *toolCallingChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return nil, nil
+}
+
+func (m *toolCallingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	last := input[len(input)-1]
+	if last.Role == schema.Tool {
+		// 从checkpoint恢复后消息不能重复: system, user, assistant(tool call), tool
+		if len(input) != 4 {
+			return nil, fmt.Errorf("unexpected input messages: %d", len(input))
 		}
+		return schema.StreamReaderFromArray([]*schema.Message{
+			schema.AssistantMessage("the weather is ", nil),
+			withUsage(schema.AssistantMessage(last.Content, nil), 12, 5),
+		}), nil
+	}
 
-		message += chunk.Content
+	toolCall := schema.ToolCall{
+		ID:       "call_1",
+		Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"beijing"}`},
 	}
+	if m.withoutID {
+		toolCall.ID = ""
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{
+		withUsage(schema.AssistantMessage("", []schema.ToolCall{toolCall}), 8, 3),
+	}), nil
+}
+
+// blockingChatModel 第一轮调用工具，第二轮阻塞直到release关闭，模拟实例退出
+type blockingChatModel struct {
+	toolCallingChatModel
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if input[len(input)-1].Role != schema.Tool {
+		return m.toolCallingChatModel.Stream(ctx, input, opts...)
+	}
+	close(m.entered)
+	<-m.release
+	return nil, errors.New("instance exited")
+}
+
+func (m *blockingChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// cancellableChatModel 输出一部分回答后阻塞直到ctx取消
+type cancellableChatModel struct {
+	toolCallingChatModel
+}
+
+func (m *cancellableChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	sr, sw := schema.Pipe[*schema.Message](0)
+	go func() {
+		defer sw.Close()
+		sw.Send(schema.AssistantMessage("the weather", nil), nil)
+		<-ctx.Done()
+		sw.Send(nil, ctx.Err())
+	}()
+	return sr, nil
+}
+
+func (m *cancellableChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// thinkingChatModel 在Content中内联输出推理内容
+type thinkingChatModel struct{}
+
+func (m *thinkingChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return nil, nil
+}
+
+func (m *thinkingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{
+		schema.AssistantMessage("<think>the user asks ", nil),
+		schema.AssistantMessage("about the weather</thi", nil),
+		schema.AssistantMessage("nk>the weather is good", nil),
+	}), nil
+}
+
+func (m *thinkingChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func withUsage(msg *schema.Message, promptTokens, completionTokens int) *schema.Message {
+	msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}}
+	return msg
+}
 
-	assert.Equal(t, "the weather is good", message)
+func (m *toolCallingChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
 }
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/model/bean"
+	entity "github.com/caiflower/ai-agent/model/entity"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+var ErrStructuredOutputInvalid = errors.New("answer doesn't match the response schema")
+
+const repairSystemPrompt = `你负责把助手的回答修正为符合JSON Schema的JSON。
+要求：
+- 保留回答中的信息，不要编造
+- 只输出JSON，不要输出其他内容`
+
+// responseSchema 请求指定的JSON Schema优先于智能体定义
+func responseSchema(def *bean.AgentDefinition, req *entity.AgentRequest) *jsonschema.Schema {
+	if req.Options != nil && req.Options.ResponseSchema != nil {
+		return req.Options.ResponseSchema
+	}
+	return def.ResponseSchema
+}
+
+// structure 校验回答是否符合JSON Schema，不符合时把校验错误交给模型修正，最多修正MaxRepairs次
+func (sa *singleAgentImpl) structure(ctx context.Context, exec *execution, answer string, usage *usageCollector) (json.RawMessage, error) {
+	s, err := chatmodel.CompileSchema(exec.cfg.ResponseSchema)
+	if err != nil {
+		return nil, err
+	}
+	structured, err := parseStructured(s, answer)
+	if err == nil {
+		return structured, nil
+	}
+
+	maxRepairs := constants.Prop.Agent.MaxRepairs
+	if maxRepairs <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrStructuredOutputInvalid, err)
+	}
+	schemaJSON, err2 := json.Marshal(exec.cfg.ResponseSchema)
+	if err2 != nil {
+		return nil, err2
+	}
+	repairCfg := *exec.cfg
+	disable := false
+	repairCfg.Thinking = &disable
+	chatModel, err2 := sa.Factory.CreateChatModel(exec.protocol, &repairCfg)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	for i := 1; i <= maxRepairs; i++ {
+		logger.Warn("answer doesn't match the response schema, repair %d/%d. Error: %v", i, maxRepairs, err)
+		msg, genErr := chatModel.Generate(ctx, []*schema.Message{
+			schema.SystemMessage(repairSystemPrompt),
+			schema.UserMessage(fmt.Sprintf("JSON Schema：\n%s\n\n助手回答：\n%s\n\n校验错误：%v", schemaJSON, answer, err)),
+		})
+		if genErr != nil {
+			return nil, genErr
+		}
+		usage.add(getTokenUsage(&model.CallbackOutput{Message: msg}))
+
+		answer = msg.Content
+		if structured, err = parseStructured(s, answer); err == nil {
+			return structured, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrStructuredOutputInvalid, err)
+}
+
+// parseStructured 去掉推理内容后解析并校验JSON
+func parseStructured(s *openapi3.Schema, content string) (json.RawMessage, error) {
+	p := &thinkParser{}
+	_, content = p.parse(content)
+	_, rest := p.flush()
+	return chatmodel.ValidateJSON(s, content+rest)
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/caiflower/ai-agent/constants"
+	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	"github.com/caiflower/ai-agent/model/bean"
+	entity "github.com/caiflower/ai-agent/model/entity"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const weatherSchema = `{"type": "object", "properties": {"city": {"type": "string"}, "temperature": {"type": "number"}}, "required": ["city", "temperature"]}`
+
+func newWeatherSchema(t *testing.T) *jsonschema.Schema {
+	s := &jsonschema.Schema{}
+	assert.Nil(t, json.Unmarshal([]byte(weatherSchema), s))
+	return s
+}
+
+func TestResponseSchema(t *testing.T) {
+	defSchema, reqSchema := newWeatherSchema(t), newWeatherSchema(t)
+	def := &bean.AgentDefinition{ResponseSchema: defSchema}
+
+	assert.Same(t, defSchema, responseSchema(def, &entity.AgentRequest{}))
+	assert.Same(t, reqSchema, responseSchema(def, &entity.AgentRequest{Options: &entity.ModelOptions{ResponseSchema: reqSchema}}))
+	assert.Nil(t, responseSchema(&bean.AgentDefinition{}, &entity.AgentRequest{Options: &entity.ModelOptions{}}))
+}
+
+func TestParseStructured(t *testing.T) {
+	s, err := chatmodel.CompileSchema(newWeatherSchema(t))
+	assert.Nil(t, err)
+
+	structured, err := parseStructured(s, "<think>想一想</think>```json\n{\"city\": \"北京\", \"temperature\": 25}\n```")
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"city": "北京", "temperature": 25}`, string(structured))
+
+	_, err = parseStructured(s, `{"city": "北京"}`)
+	assert.NotNil(t, err)
+	_, err = parseStructured(s, `{"city": "北京", "temperature": "25度"}`)
+	assert.NotNil(t, err)
+	_, err = parseStructured(s, "北京晴，25度")
+	assert.ErrorIs(t, err, chatmodel.ErrJSONNotFound)
+}
+
+// repairChatModel 依次返回修正后的回答
+type repairChatModel struct {
+	model.ToolCallingChatModel
+	answers []string
+	inputs  [][]*schema.Message
+}
+
+func (m *repairChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.inputs = append(m.inputs, input)
+	answer := m.answers[0]
+	m.answers = m.answers[1:]
+	msg := schema.AssistantMessage(answer, nil)
+	msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}
+	return msg, nil
+}
+
+func TestStructure(t *testing.T) {
+	maxRepairs := constants.Prop.Agent.MaxRepairs
+	constants.Prop.Agent.MaxRepairs = 2
+	defer func() { constants.Prop.Agent.MaxRepairs = maxRepairs }()
+
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	exec := &execution{protocol: chatmodel.ProtocolMock, cfg: &chatmodel.Config{ResponseSchema: newWeatherSchema(t)}}
+	sa := &singleAgentImpl{Factory: factory}
+
+	// 符合格式时不请求模型
+	usage := &usageCollector{}
+	structured, err := sa.structure(context.Background(), exec, `{"city": "北京", "temperature": 25}`, usage)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"city": "北京", "temperature": 25}`, string(structured))
+
+	// 第二次修正后符合格式，修正的用量计入本次运行
+	repair := &repairChatModel{answers: []string{`{"city": "北京"}`, `{"city": "北京", "temperature": 25}`}}
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(repair, nil)
+	structured, err = sa.structure(context.Background(), exec, "北京晴，25度", usage)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"city": "北京", "temperature": 25}`, string(structured))
+	if assert.Len(t, repair.inputs, 2) {
+		assert.Contains(t, repair.inputs[0][1].Content, "北京晴，25度")
+		assert.Contains(t, repair.inputs[1][1].Content, `{"city": "北京"}`)
+	}
+	assert.Equal(t, 30, usage.wait().TotalTokens)
+
+	// 超过修正次数后失败
+	repair = &repairChatModel{answers: []string{"北京晴", "北京晴"}}
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(repair, nil)
+	_, err = sa.structure(context.Background(), exec, "北京晴，25度", usage)
+	assert.ErrorIs(t, err, ErrStructuredOutputInvalid)
+	assert.Len(t, repair.inputs, 2)
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/caiflower/ai-agent/constants"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/cloudwego/eino/schema"
+)
+
+const suggestCount = 3
+
+const suggestSystemPrompt = `你负责根据用户的问题和助手的回答，预测用户接下来最可能追问的问题。
+要求：
+- 给出%d个简短的追问，每个不超过20个字
+- 追问使用与用户问题相同的语言
+- 只输出JSON，格式为{"questions": ["问题1", "问题2", "问题3"]}`
+
+type suggestOutput struct {
+	Questions []string `json:"questions"`
+}
+
+// suggest 回答结束后生成追问建议，超过配置的超时时间后放弃
+func (sa *singleAgentImpl) suggest(protocol chatmodel.Protocol, cfg *chatmodel.Config, question, answer string) ([]string, error) {
+	suggestProtocol, suggestCfg := protocol, *cfg
+	if name := constants.Prop.Suggest.ModelProfile; name != "" {
+		profile, found := constants.GetModelProfile(name)
+		if !found {
+			return nil, fmt.Errorf("model profile not found, name=%s", name)
+		}
+		suggestProtocol = chatmodel.Protocol(profile.Protocol)
+		suggestCfg = chatmodel.Config{BaseURL: profile.Url, Model: profile.Model, Timeout: profile.Timeout}
+	}
+	disable := false
+	suggestCfg.Thinking = &disable
+	suggestCfg.JSONOutput = true
+
+	chatModel, err := sa.Factory.CreateChatModel(suggestProtocol, &suggestCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.Prop.Suggest.Timeout)
+	defer cancel()
+
+	type result struct {
+		msg *schema.Message
+		err error
+	}
+	ch := make(chan result, 1)
+	safego.Go(func() {
+		msg, err := chatModel.Generate(ctx, []*schema.Message{
+			schema.SystemMessage(fmt.Sprintf(suggestSystemPrompt, suggestCount)),
+			schema.UserMessage(fmt.Sprintf("用户问题：%s\n\n助手回答：%s", question, answer)),
+		})
+		ch <- result{msg: msg, err: err}
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return parseSuggestions(res.msg.Content)
+	}
+}
+
+// parseSuggestions 解析模型输出的JSON，兼容推理内容和代码块包裹
+func parseSuggestions(content string) ([]string, error) {
+	p := &thinkParser{}
+	_, content = p.parse(content)
+	_, rest := p.flush()
+	content += rest
+
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("suggestions not found in model output")
+	}
+
+	output := &suggestOutput{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), output); err != nil {
+		return nil, err
+	}
+
+	questions := make([]string, 0, suggestCount)
+	for _, q := range output.Questions {
+		if q = strings.TrimSpace(q); q != "" {
+			questions = append(questions, q)
+		}
+		if len(questions) == suggestCount {
+			break
+		}
+	}
+	return questions, nil
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseSuggestions(t *testing.T) {
+	questions, err := parseSuggestions("<think>想一想</think>```json\n{\"questions\": [\"明天天气怎么样？\", \" \", \"需要带伞吗？\", \"适合跑步吗？\", \"空气质量如何？\"]}\n```")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"明天天气怎么样？", "需要带伞吗？", "适合跑步吗？"}, questions)
+
+	_, err = parseSuggestions("明天天气怎么样？")
+	assert.NotNil(t, err)
+}
+
+func TestSuggestTimeout(t *testing.T) {
+	timeout := constants.Prop.Suggest.Timeout
+	constants.Prop.Suggest.Timeout = 100 * time.Millisecond
+	defer func() { constants.Prop.Suggest.Timeout = timeout }()
+
+	ctl := gomock.NewController(t)
+	disable := false
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{Thinking: &disable, JSONOutput: true}).Return(&slowChatModel{}, nil)
+
+	sa := &singleAgentImpl{Factory: factory}
+	start := time.Now()
+	_, err := sa.suggest(chatmodel.ProtocolMock, &chatmodel.Config{}, "What's the weather like in Beijing?", "the weather is good")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// slowChatModel 忽略ctx，模拟响应很慢的模型
+type slowChatModel struct{}
+
+func (m *slowChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	time.Sleep(2 * time.Second)
+	return schema.AssistantMessage(`{"questions": []}`, nil), nil
+}
+
+func (m *slowChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, nil
+}
+
+func (m *slowChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
@@ -0,0 +1,260 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// routeFinish 监督者认为专家已经完成用户的请求
+const routeFinish = "FINISH"
+
+const supervisorSystemPrompt = `你是%s，负责把用户的请求交给最合适的专家智能体处理。%s
+可选的专家：
+%s
+要求：
+- 每次只选择一个专家
+- 已有专家的回答完整解决了用户的请求时输出FINISH
+- 只输出JSON，格式为{"next": "专家的agent_id或FINISH"}`
+
+type routeOutput struct {
+	Next string `json:"next"`
+}
+
+// supervisor 监督者，每轮对话先选择专家回答，专家回答后再决定是否交给其他专家
+type supervisor struct {
+	def       *bean.AgentDefinition
+	agents    []*bean.AgentDefinition
+	chatModel model.BaseChatModel
+}
+
+// supervise 监督者模式的运行，合并各专家的事件流，每个事件标记产生它的专家
+func (r *agentRuntime) supervise(ctx context.Context, def *bean.AgentDefinition, req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
+	sup, err := r.newSupervisor(def, req)
+	if err != nil {
+		return nil, err
+	}
+
+	agent := sup.route(ctx, req, nil, nil)
+	agentSr, err := r.SingleAgent.StreamExecute(ctx, sup.request(req, agent, nil))
+	if err != nil {
+		logger.Error("supervisor hand off to '%s' failed. Error: %v", agent.AgentID, err)
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[*entity.AgentRespEvent](10)
+	safego.Go(func() {
+		defer sw.Close()
+
+		var (
+			answers     []*schema.Message
+			maxHandoffs = constants.Prop.Agent.MaxHandoffs
+		)
+		for handoffs := 1; ; handoffs++ {
+			runID, ok := forwardEvents(sw, agent, agentSr, handoffs == 1)
+			if !ok {
+				return
+			}
+
+			run, err := r.SingleAgent.GetRun(runID, req.User)
+			if err != nil {
+				logger.Error("get agent run failed. Error: %v", err)
+				sw.Send(nil, err)
+				return
+			}
+			answers = append(answers, &schema.Message{Role: schema.Assistant, Name: agent.Name, Content: run.Answer})
+
+			if handoffs >= maxHandoffs {
+				return
+			}
+			next := sup.route(ctx, req, answers, agent)
+			if next == nil {
+				return
+			}
+			agent = next
+			if agentSr, err = r.SingleAgent.StreamExecute(ctx, sup.request(req, agent, answers)); err != nil {
+				logger.Error("supervisor hand off to '%s' failed. Error: %v", agent.AgentID, err)
+				sw.Send(nil, err)
+				return
+			}
+		}
+	})
+
+	return sr, nil
+}
+
+func (r *agentRuntime) newSupervisor(def *bean.AgentDefinition, req *entity.AgentRequest) (*supervisor, error) {
+	agents := make([]*bean.AgentDefinition, 0, len(def.SubAgents))
+	for _, agentID := range def.SubAgents {
+		sub, err := r.DefinitionManager.Get(agentID)
+		if err != nil {
+			logger.Error("get sub agent '%s' failed. Error: %v", agentID, err)
+			return nil, err
+		}
+		agents = append(agents, sub)
+	}
+
+	protocol, cfg, err := buildConfig(def, req)
+	if err != nil {
+		logger.Error("build chat model config failed. Error: %v", err)
+		return nil, err
+	}
+	disable := false
+	cfg.Thinking = &disable
+	cfg.JSONOutput = true
+
+	chatModel, err := r.Factory.CreateChatModel(protocol, cfg)
+	if err != nil {
+		logger.Error("create cmodel failed. Error: %v", err)
+		return nil, err
+	}
+
+	return &supervisor{def: def, agents: agents, chatModel: chatModel}, nil
+}
+
+// route 选择下一个专家，返回nil表示结束。第一次选择失败时交给第一个专家，之后失败时结束
+func (s *supervisor) route(ctx context.Context, req *entity.AgentRequest, answers []*schema.Message, last *bean.AgentDefinition) *bean.AgentDefinition {
+	fallback := s.agents[0]
+	if last != nil {
+		fallback = nil
+	}
+
+	messages := []*schema.Message{schema.SystemMessage(s.systemPrompt())}
+	messages = append(messages, req.History...)
+	messages = append(messages, req.Input)
+	for _, answer := range answers {
+		// 部分模型忽略Name，回答前标注专家名称
+		messages = append(messages, schema.AssistantMessage(fmt.Sprintf("[%s] %s", answer.Name, answer.Content), nil))
+	}
+
+	msg, err := s.chatModel.Generate(ctx, messages)
+	if err != nil {
+		logger.Warn("supervisor route failed. Error: %v", err)
+		return fallback
+	}
+	next, err := parseRoute(msg.Content)
+	if err != nil {
+		logger.Warn("parse supervisor route failed. Error: %v", err)
+		return fallback
+	}
+	if next == routeFinish {
+		return fallback
+	}
+
+	idx := slices.IndexFunc(s.agents, func(def *bean.AgentDefinition) bool { return def.AgentID == next })
+	if idx < 0 {
+		logger.Warn("supervisor route to unknown agent '%s'", next)
+		return fallback
+	}
+	// 交给刚回答过的专家没有意义
+	if last != nil && s.agents[idx].AgentID == last.AgentID {
+		return nil
+	}
+	return s.agents[idx]
+}
+
+func (s *supervisor) systemPrompt() string {
+	var agents strings.Builder
+	for _, def := range s.agents {
+		agents.WriteString(fmt.Sprintf("- %s: %s", def.AgentID, def.Name))
+		if def.Description != "" {
+			agents.WriteString("，" + def.Description)
+		}
+		agents.WriteString("\n")
+	}
+	persona := ""
+	if s.def.Persona != "" {
+		persona = "\n" + s.def.Persona
+	}
+	return fmt.Sprintf(supervisorSystemPrompt, s.def.Name, persona, agents.String())
+}
+
+// request 专家的请求，已有专家的回答追加在历史消息之后，未指定回答格式时使用监督者的格式
+func (s *supervisor) request(req *entity.AgentRequest, agent *bean.AgentDefinition, answers []*schema.Message) *entity.AgentRequest {
+	agentReq := *req
+	agentReq.AgentID = agent.AgentID
+	agentReq.History = append(slices.Clone(req.History), answers...)
+	if s.def.ResponseSchema != nil && (req.Options == nil || req.Options.ResponseSchema == nil) {
+		options := entity.ModelOptions{}
+		if req.Options != nil {
+			options = *req.Options
+		}
+		options.ResponseSchema = s.def.ResponseSchema
+		agentReq.Options = &options
+	}
+	return &agentReq
+}
+
+// forwardEvents 转发专家的事件，只有第一个专家的运行信息作为整个运行的运行信息。
+// 专家运行结束时返回运行ID，中断或失败时返回false
+func forwardEvents(sw *schema.StreamWriter[*entity.AgentRespEvent], agent *bean.AgentDefinition, sr *schema.StreamReader[*entity.AgentRespEvent], first bool) (string, bool) {
+	defer sr.Close()
+
+	var (
+		runID       string
+		interrupted bool
+	)
+	for {
+		event, err := sr.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return runID, !interrupted
+			}
+			sw.Send(nil, err)
+			return "", false
+		}
+
+		event.AgentID = agent.AgentID
+		switch event.EventType {
+		case entity.EventTypeOfRunInfo:
+			runID = event.RunInfo.RunID
+			if first {
+				sw.Send(event, nil)
+			}
+			sw.Send(&entity.AgentRespEvent{
+				EventType: entity.EventTypeOfHandoff,
+				AgentID:   agent.AgentID,
+				Handoff:   &entity.Handoff{AgentID: agent.AgentID, Name: agent.Name, RunID: runID},
+			}, nil)
+			continue
+		case entity.EventTypeOfInterrupt, entity.EventTypeOfCancelled:
+			// 恢复中断时只继续该专家的运行，取消后不再交给其他专家
+			interrupted = true
+		}
+		sw.Send(event, nil)
+	}
+}
+
+// parseRoute 解析监督者输出的JSON，兼容推理内容和代码块包裹
+func parseRoute(content string) (string, error) {
+	p := &thinkParser{}
+	_, content = p.parse(content)
+	_, rest := p.flush()
+	content += rest
+
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("route not found in model output")
+	}
+
+	output := &routeOutput{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), output); err != nil {
+		return "", err
+	}
+	if output.Next = strings.TrimSpace(output.Next); output.Next == "" {
+		return "", fmt.Errorf("route is empty")
+	}
+	return output.Next, nil
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caiflower/ai-agent/constants"
+	mockagent "github.com/caiflower/ai-agent/internal/mock/agent"
+	mockdefinition "github.com/caiflower/ai-agent/internal/mock/definition"
+	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseRoute(t *testing.T) {
+	next, err := parseRoute("<think>天气问题</think>```json\n{\"next\": \" agent-weather \"}\n```")
+	assert.Nil(t, err)
+	assert.Equal(t, "agent-weather", next)
+
+	_, err = parseRoute(`{"next": ""}`)
+	assert.NotNil(t, err)
+}
+
+// routeChatModel 依次返回路由结果
+type routeChatModel struct {
+	model.ToolCallingChatModel
+	routes []string
+	inputs [][]*schema.Message
+}
+
+func (m *routeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.inputs = append(m.inputs, input)
+	next := m.routes[0]
+	m.routes = m.routes[1:]
+	return schema.AssistantMessage(`{"next": "`+next+`"}`, nil), nil
+}
+
+func TestSupervise(t *testing.T) {
+	maxHandoffs := constants.Prop.Agent.MaxHandoffs
+	constants.Prop.Agent.MaxHandoffs = 3
+	defer func() { constants.Prop.Agent.MaxHandoffs = maxHandoffs }()
+
+	ctl := gomock.NewController(t)
+	routes := &routeChatModel{routes: []string{"agent-weather", "agent-travel", routeFinish}}
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(routes, nil)
+
+	definitionManager := mockdefinition.NewMockManager(ctl)
+	definitionManager.EXPECT().Get("agent-supervisor").Return(&bean.AgentDefinition{
+		AgentID:   "agent-supervisor",
+		Name:      "supervisor",
+		SubAgents: []string{"agent-weather", "agent-travel"},
+	}, nil)
+	definitionManager.EXPECT().Get("agent-weather").Return(&bean.AgentDefinition{AgentID: "agent-weather", Name: "weather", Description: "查询天气"}, nil)
+	definitionManager.EXPECT().Get("agent-travel").Return(&bean.AgentDefinition{AgentID: "agent-travel", Name: "travel", Description: "规划行程"}, nil)
+
+	singleAgent := mockagent.NewMockSingleAgent(ctl)
+	answers := map[string]string{"agent-weather": "the weather is sunny", "agent-travel": "go hiking"}
+	singleAgent.EXPECT().StreamExecute(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *entity.AgentRequest) (*schema.StreamReader[*entity.AgentRespEvent], error) {
+		if req.AgentID == "agent-travel" {
+			// 后面的专家可以看到前面专家的回答
+			assert.Equal(t, 1, len(req.History))
+			assert.Equal(t, answers["agent-weather"], req.History[0].Content)
+		}
+		return schema.StreamReaderFromArray([]*entity.AgentRespEvent{
+			{EventType: entity.EventTypeOfRunInfo, RunInfo: &entity.RunInfo{RunID: "run-" + req.AgentID, AgentID: req.AgentID}},
+			{EventType: entity.EventTypeOfChatModelAnswer, ChatModelAnswer: schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage(answers[req.AgentID], nil)})},
+		}), nil
+	}).Times(2)
+	singleAgent.EXPECT().GetRun(gomock.Any(), "test-user").DoAndReturn(func(runID, user string) (*bean.AgentRun, error) {
+		agentID := runID[len("run-"):]
+		return &bean.AgentRun{RunID: runID, AgentID: agentID, Answer: answers[agentID]}, nil
+	}).Times(2)
+
+	runtime := &agentRuntime{SingleAgent: singleAgent, DefinitionManager: definitionManager, Factory: factory}
+	sr, err := runtime.Run(context.Background(), &entity.AgentRequest{
+		User:         "test-user",
+		AgentID:      "agent-supervisor",
+		Input:        schema.UserMessage("周末去哪玩？"),
+		ChatProtocol: chatmodel.ProtocolMock,
+	})
+	assert.Nil(t, err)
+
+	var events []string
+	for {
+		event, recvErr := sr.Recv()
+		if recvErr != nil {
+			break
+		}
+		switch event.EventType {
+		case entity.EventTypeOfRunInfo:
+			events = append(events, "run:"+event.RunInfo.RunID)
+		case entity.EventTypeOfHandoff:
+			events = append(events, "handoff:"+event.Handoff.AgentID)
+		case entity.EventTypeOfChatModelAnswer:
+			msg, _ := event.ChatModelAnswer.Recv()
+			events = append(events, event.AgentID+":"+msg.Content)
+		}
+	}
+	assert.Equal(t, []string{
+		"run:run-agent-weather",
+		"handoff:agent-weather",
+		"agent-weather:the weather is sunny",
+		"handoff:agent-travel",
+		"agent-travel:go hiking",
+	}, events)
+	// 最后一次路由时监督者可以看到两个专家的回答
+	assert.Equal(t, 3, len(routes.inputs))
+	assert.Equal(t, 4, len(routes.inputs[2]))
+}
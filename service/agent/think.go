@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"io"
+	"strings"
+
+	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	thinkStartTag = "<think>"
+	thinkEndTag   = "</think>"
+)
+
+// thinkParser 将Content中内联的<think>推理内容拆分出来，标签可能被截断在多个chunk中
+type thinkParser struct {
+	inThink bool
+	pending string
+}
+
+func (p *thinkParser) parse(content string) (reasoning, answer string) {
+	var rb, ab strings.Builder
+	write := func(s string) {
+		if p.inThink {
+			rb.WriteString(s)
+		} else {
+			ab.WriteString(s)
+		}
+	}
+
+	s := p.pending + content
+	p.pending = ""
+	for len(s) > 0 {
+		tag := thinkStartTag
+		if p.inThink {
+			tag = thinkEndTag
+		}
+
+		if i := strings.Index(s, tag); i >= 0 {
+			write(s[:i])
+			s = s[i+len(tag):]
+			p.inThink = !p.inThink
+			continue
+		}
+
+		// 末尾可能是被截断的标签，留到下一个chunk再处理
+		keep := partialSuffix(s, tag)
+		write(s[:len(s)-keep])
+		p.pending = s[len(s)-keep:]
+		break
+	}
+
+	return rb.String(), ab.String()
+}
+
+// flush 流结束时输出剩余的内容
+func (p *thinkParser) flush() (reasoning, answer string) {
+	s := p.pending
+	p.pending = ""
+	if p.inThink {
+		return s, ""
+	}
+	return "", s
+}
+
+func partialSuffix(s, tag string) int {
+	for k := len(tag) - 1; k > 0; k-- {
+		if strings.HasSuffix(s, tag[:k]) {
+			return k
+		}
+	}
+	return 0
+}
+
+// splitThinking 将模型输出中内联的推理内容移动到ReasoningContent
+func splitThinking(sr *schema.StreamReader[*schema.Message]) *schema.StreamReader[*schema.Message] {
+	out, sw := schema.Pipe[*schema.Message](10)
+
+	safego.Go(func() {
+		defer sw.Close()
+		defer sr.Close()
+
+		p := &thinkParser{}
+		for {
+			msg, err := sr.Recv()
+			if err != nil {
+				if err == io.EOF {
+					if reasoning, answer := p.flush(); reasoning != "" || answer != "" {
+						sw.Send(&schema.Message{Role: schema.Assistant, Content: answer, ReasoningContent: reasoning}, nil)
+					}
+					return
+				}
+				sw.Send(nil, err)
+				return
+			}
+			if msg == nil {
+				continue
+			}
+
+			// chunk在多个流拷贝之间共享，不能直接修改
+			reasoning, answer := p.parse(msg.Content)
+			chunk := *msg
+			chunk.Content = answer
+			chunk.ReasoningContent = msg.ReasoningContent + reasoning
+			if closed := sw.Send(&chunk, nil); closed {
+				return
+			}
+		}
+	})
+
+	return out
+}
@@ -0,0 +1,24 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThinkParser(t *testing.T) {
+	p := &thinkParser{}
+
+	var reasoning, answer string
+	for _, chunk := range []string{"<thi", "nk>用户问", "天气</th", "ink>\n\n北京", "晴<", "天"} {
+		r, a := p.parse(chunk)
+		reasoning += r
+		answer += a
+	}
+	r, a := p.flush()
+	reasoning += r
+	answer += a
+
+	assert.Equal(t, "用户问天气", reasoning)
+	assert.Equal(t, "\n\n北京晴<天", answer)
+}
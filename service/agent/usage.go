@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"io"
+	"sync"
+
+	"github.com/caiflower/common-tools/pkg/safego"
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// usageCollector 汇总一次运行中所有模型调用的token用量
+type usageCollector struct {
+	lock  sync.Mutex
+	wg    sync.WaitGroup
+	usage model.TokenUsage
+}
+
+// collect 异步读取模型输出流，流式输出时取最后一次返回的用量
+func (c *usageCollector) collect(sr *schema.StreamReader[callbacks.CallbackOutput]) {
+	c.wg.Add(1)
+	safego.Go(func() {
+		defer c.wg.Done()
+		defer sr.Close()
+
+		var last *model.TokenUsage
+		for {
+			chunk, err := sr.Recv()
+			if err != nil {
+				if err != io.EOF {
+					return
+				}
+				break
+			}
+			if usage := getTokenUsage(model.ConvCallbackOutput(chunk)); usage != nil {
+				last = usage
+			}
+		}
+		if last == nil {
+			return
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.usage.PromptTokens += last.PromptTokens
+		c.usage.CompletionTokens += last.CompletionTokens
+		c.usage.TotalTokens += last.TotalTokens
+	})
+}
+
+// add 非流式调用的用量
+func (c *usageCollector) add(usage *model.TokenUsage) {
+	if usage == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.usage.PromptTokens += usage.PromptTokens
+	c.usage.CompletionTokens += usage.CompletionTokens
+	c.usage.TotalTokens += usage.TotalTokens
+}
+
+// wait 等待所有模型输出读取结束后返回总用量
+func (c *usageCollector) wait() model.TokenUsage {
+	c.wg.Wait()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.usage
+}
+
+// getTokenUsage 模型没有在回调中上报用量时，从消息的ResponseMeta中获取
+func getTokenUsage(cbOut *model.CallbackOutput) *model.TokenUsage {
+	if cbOut == nil {
+		return nil
+	}
+	if cbOut.TokenUsage != nil {
+		return cbOut.TokenUsage
+	}
+	if cbOut.Message != nil && cbOut.Message.ResponseMeta != nil && cbOut.Message.ResponseMeta.Usage != nil {
+		usage := cbOut.Message.ResponseMeta.Usage
+		return &model.TokenUsage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}
+	}
+	return nil
+}
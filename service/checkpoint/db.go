@@ -0,0 +1,67 @@
+package checkpoint
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/common-tools/pkg/logger"
+)
+
+const dbGCInterval = 10 * time.Minute
+
+// dbStore 数据库存储，多实例部署时任意实例都可以恢复运行
+type dbStore struct {
+	AgentCheckpointDao dao.AgentCheckpointDao `autowired:""`
+	lock               sync.Mutex
+	lastGC             time.Time
+}
+
+func NewDBStore() Store {
+	return &dbStore{}
+}
+
+func (s *dbStore) Get(_ context.Context, checkPointID string) ([]byte, bool, error) {
+	cp, err := s.AgentCheckpointDao.Get(checkPointID)
+	if err != nil {
+		return nil, false, err
+	}
+	if cp == nil {
+		return nil, false, nil
+	}
+	return cp.Data, true, nil
+}
+
+func (s *dbStore) Set(_ context.Context, checkPointID string, checkPoint []byte) error {
+	s.tryGC()
+
+	return s.AgentCheckpointDao.Save(&bean.AgentCheckpoint{
+		CheckpointID: checkPointID,
+		Data:         checkPoint,
+		ExpireTime:   time.Now().Add(constants.Prop.Checkpoint.TTL),
+	})
+}
+
+func (s *dbStore) Delete(_ context.Context, checkPointID string) error {
+	_, err := s.AgentCheckpointDao.Delete(checkPointID)
+	return err
+}
+
+// tryGC 每隔dbGCInterval清理一次过期的checkpoint
+func (s *dbStore) tryGC() {
+	now := time.Now()
+	s.lock.Lock()
+	if now.Sub(s.lastGC) < dbGCInterval {
+		s.lock.Unlock()
+		return
+	}
+	s.lastGC = now
+	s.lock.Unlock()
+
+	if _, err := s.AgentCheckpointDao.DeleteExpired(); err != nil {
+		logger.Warn("delete expired checkpoints failed. Error: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+)
+
+const (
+	fileSuffix     = ".ckpt"
+	fileGCInterval = time.Minute
+)
+
+// fileStore 本地文件存储，每个checkpoint一个文件，通过修改时间判断是否过期
+type fileStore struct {
+	dir    string
+	lock   sync.Mutex
+	lastGC time.Time
+}
+
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (s *fileStore) Get(_ context.Context, checkPointID string) ([]byte, bool, error) {
+	path := s.path(checkPointID)
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if s.expired(info, time.Now()) {
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set 先写临时文件再重命名，进程在写入过程中退出时不会留下不完整的checkpoint
+func (s *fileStore) Set(_ context.Context, checkPointID string, checkPoint []byte) error {
+	s.tryGC()
+
+	tmp, err := os.CreateTemp(s.dir, checkPointID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(checkPoint); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(checkPointID))
+}
+
+func (s *fileStore) Delete(_ context.Context, checkPointID string) error {
+	if err := os.Remove(s.path(checkPointID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// tryGC 每隔fileGCInterval清理一次过期的checkpoint
+func (s *fileStore) tryGC() {
+	now := time.Now()
+	s.lock.Lock()
+	if now.Sub(s.lastGC) < fileGCInterval {
+		s.lock.Unlock()
+		return
+	}
+	s.lastGC = now
+	s.lock.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), fileSuffix) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && s.expired(info, now) {
+			_ = os.Remove(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+}
+
+func (s *fileStore) expired(info os.FileInfo, now time.Time) bool {
+	return now.Sub(info.ModTime()) > constants.Prop.Checkpoint.TTL
+}
+
+func (s *fileStore) path(checkPointID string) string {
+	return filepath.Join(s.dir, filepath.Base(checkPointID)+fileSuffix)
+}
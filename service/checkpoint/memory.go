@@ -0,0 +1,62 @@
+package checkpoint
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+)
+
+type memoryItem struct {
+	data     []byte
+	expireAt time.Time
+}
+
+// memoryStore 内存存储，过期的checkpoint在读取和写入时清理
+type memoryStore struct {
+	lock  sync.Mutex
+	items map[string]*memoryItem
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{items: make(map[string]*memoryItem)}
+}
+
+func (s *memoryStore) Get(_ context.Context, checkPointID string) ([]byte, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item, found := s.items[checkPointID]
+	if !found {
+		return nil, false, nil
+	}
+	if time.Now().After(item.expireAt) {
+		delete(s.items, checkPointID)
+		return nil, false, nil
+	}
+	return item.data, true, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, checkPointID string, checkPoint []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for id, item := range s.items {
+		if now.After(item.expireAt) {
+			delete(s.items, id)
+		}
+	}
+
+	s.items[checkPointID] = &memoryItem{data: checkPoint, expireAt: now.Add(constants.Prop.Checkpoint.TTL)}
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, checkPointID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.items, checkPointID)
+	return nil
+}
@@ -0,0 +1,35 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/cloudwego/eino/compose"
+)
+
+const (
+	StoreOfMemory = "memory"
+	StoreOfFile   = "file"
+	StoreOfDB     = "db"
+)
+
+//go:generate mockgen -destination ../../internal/mock/checkpoint/store_mock.go -package checkpoint -source store.go
+type Store interface {
+	compose.CheckPointStore
+	Delete(ctx context.Context, checkPointID string) error
+}
+
+// NewStore 根据配置创建存储，memory存储在实例重启后丢失
+func NewStore() (Store, error) {
+	switch constants.Prop.Checkpoint.Store {
+	case StoreOfMemory:
+		return NewMemoryStore(), nil
+	case StoreOfFile, "":
+		return NewFileStore(constants.Prop.Checkpoint.Dir)
+	case StoreOfDB:
+		return NewDBStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown checkpoint store '%s'", constants.Prop.Checkpoint.Store)
+	}
+}
@@ -0,0 +1,202 @@
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/service/flow"
+	"github.com/caiflower/ai-agent/service/knowledge"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/toolkit"
+	"github.com/caiflower/common-tools/pkg/tools"
+)
+
+// DefaultAgentID 内置智能体，未指定AgentID时使用，由配置文件中的prompt生成
+const DefaultAgentID = "default"
+
+var (
+	ErrAgentNotFound     = errors.New("agent not found")
+	ErrInvalidDefinition = errors.New("invalid agent definition")
+)
+
+//go:generate mockgen -destination ../../internal/mock/definition/manager_mock.go -package definition -source manager.go
+type Manager interface {
+	Get(agentID string) (*bean.AgentDefinition, error)
+	List() ([]*bean.AgentDefinition, error)
+	Create(def *bean.AgentDefinition) error
+	Update(def *bean.AgentDefinition) error
+	Delete(agentID string) error
+}
+
+type manager struct {
+	AgentDefinitionDao dao.AgentDefinitionDao `autowired:""`
+	ToolRegistry       toolkit.Registry       `autowired:""`
+	KnowledgeRegistry  knowledge.Registry     `autowired:""`
+	FlowManager        flow.Manager           `autowired:""`
+}
+
+func NewManager() Manager {
+	return &manager{}
+}
+
+func (m *manager) Get(agentID string) (*bean.AgentDefinition, error) {
+	if agentID == "" || agentID == DefaultAgentID {
+		return defaultDefinition(), nil
+	}
+
+	def, err := m.AgentDefinitionDao.GetByAgentID(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		return nil, ErrAgentNotFound
+	}
+	return def, nil
+}
+
+func (m *manager) List() ([]*bean.AgentDefinition, error) {
+	defs, err := m.AgentDefinitionDao.List()
+	if err != nil {
+		return nil, err
+	}
+	return append([]*bean.AgentDefinition{defaultDefinition()}, defs...), nil
+}
+
+func (m *manager) Create(def *bean.AgentDefinition) error {
+	if err := m.validate(def); err != nil {
+		return err
+	}
+
+	def.AgentID = tools.GenerateId("agent")
+	return m.AgentDefinitionDao.Insert(def)
+}
+
+func (m *manager) Update(def *bean.AgentDefinition) error {
+	if def.AgentID == DefaultAgentID {
+		return fmt.Errorf("%w: built-in agent can't be modified", ErrInvalidDefinition)
+	}
+	if err := m.validate(def); err != nil {
+		return err
+	}
+	if _, err := m.Get(def.AgentID); err != nil {
+		return err
+	}
+
+	_, err := m.AgentDefinitionDao.Update(def)
+	return err
+}
+
+func (m *manager) Delete(agentID string) error {
+	if agentID == DefaultAgentID {
+		return fmt.Errorf("%w: built-in agent can't be deleted", ErrInvalidDefinition)
+	}
+
+	rows, err := m.AgentDefinitionDao.DeleteByAgentID(agentID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAgentNotFound
+	}
+	return nil
+}
+
+func (m *manager) validate(def *bean.AgentDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidDefinition)
+	}
+	if def.ModelProfile != "" {
+		if _, found := constants.GetModelProfile(def.ModelProfile); !found {
+			return fmt.Errorf("%w: model profile '%s' not found", ErrInvalidDefinition, def.ModelProfile)
+		}
+	}
+	for _, name := range def.Tools {
+		if !m.ToolRegistry.Exist(name) {
+			return fmt.Errorf("%w: tool '%s' not found", ErrInvalidDefinition, name)
+		}
+	}
+	switch def.Mode {
+	case "", bean.AgentModeReact:
+	case bean.AgentModePlanExecute:
+		// 计划执行模式逐步运行，不支持等待用户确认，步骤的结果由模型汇总
+		if len(def.ApprovalTools) > 0 {
+			return fmt.Errorf("%w: plan_execute mode doesn't support approval tools", ErrInvalidDefinition)
+		}
+		if len(def.ReturnDirectlyTools) > 0 {
+			return fmt.Errorf("%w: plan_execute mode doesn't support return directly tools", ErrInvalidDefinition)
+		}
+	case bean.AgentModeFlow:
+		// 工具由图中的节点声明，智能体上只能引用已加载的图
+		if len(def.Tools) > 0 || len(def.ApprovalTools) > 0 || len(def.ReturnDirectlyTools) > 0 {
+			return fmt.Errorf("%w: flow mode doesn't support tools", ErrInvalidDefinition)
+		}
+		if !m.FlowManager.Exist(def.Flow) {
+			return fmt.Errorf("%w: flow '%s' not found", ErrInvalidDefinition, def.Flow)
+		}
+	default:
+		return fmt.Errorf("%w: mode '%s' is not supported", ErrInvalidDefinition, def.Mode)
+	}
+	for _, name := range def.ApprovalTools {
+		if !slices.Contains(def.Tools, name) {
+			return fmt.Errorf("%w: approval tool '%s' is not in tools", ErrInvalidDefinition, name)
+		}
+	}
+	for _, name := range def.ReturnDirectlyTools {
+		if !slices.Contains(def.Tools, name) {
+			return fmt.Errorf("%w: return directly tool '%s' is not in tools", ErrInvalidDefinition, name)
+		}
+	}
+	for _, name := range def.KnowledgeBases {
+		if !m.KnowledgeRegistry.Exist(name) {
+			return fmt.Errorf("%w: knowledge base '%s' not found", ErrInvalidDefinition, name)
+		}
+	}
+	if def.ResponseSchema != nil {
+		if _, err := chatmodel.CompileSchema(def.ResponseSchema); err != nil {
+			return fmt.Errorf("%w: response schema is invalid, %v", ErrInvalidDefinition, err)
+		}
+	}
+	return m.validateSubAgents(def)
+}
+
+// validateSubAgents 监督者只负责路由，不绑定工具，专家智能体不能再作为监督者
+func (m *manager) validateSubAgents(def *bean.AgentDefinition) error {
+	if len(def.SubAgents) == 0 {
+		return nil
+	}
+	if len(def.Tools) > 0 {
+		return fmt.Errorf("%w: supervisor can't have tools", ErrInvalidDefinition)
+	}
+	if def.Mode == bean.AgentModePlanExecute || def.Mode == bean.AgentModeFlow {
+		return fmt.Errorf("%w: supervisor can't use %s mode", ErrInvalidDefinition, def.Mode)
+	}
+	for _, agentID := range def.SubAgents {
+		if agentID == def.AgentID {
+			return fmt.Errorf("%w: sub agent '%s' is the agent itself", ErrInvalidDefinition, agentID)
+		}
+		sub, err := m.Get(agentID)
+		if err != nil {
+			if errors.Is(err, ErrAgentNotFound) {
+				return fmt.Errorf("%w: sub agent '%s' not found", ErrInvalidDefinition, agentID)
+			}
+			return err
+		}
+		if len(sub.SubAgents) > 0 {
+			return fmt.Errorf("%w: sub agent '%s' is a supervisor", ErrInvalidDefinition, agentID)
+		}
+	}
+	return nil
+}
+
+func defaultDefinition() *bean.AgentDefinition {
+	return &bean.AgentDefinition{
+		AgentID: DefaultAgentID,
+		Name:    constants.Prop.Prompt.AgentName,
+		Persona: constants.Prop.Prompt.Persona,
+		Suggest: constants.Prop.Suggest.Enable,
+	}
+}
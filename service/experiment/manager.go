@@ -0,0 +1,195 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/service/definition"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/common-tools/pkg/tools"
+)
+
+var (
+	ErrExperimentNotFound = errors.New("experiment not found")
+	ErrInvalidExperiment  = errors.New("invalid experiment")
+)
+
+// Assignment 用户命中的实验变体
+type Assignment struct {
+	ExperimentID string
+	Variant      *bean.ExperimentVariant
+}
+
+//go:generate mockgen -destination ../../internal/mock/experiment/manager_mock.go -package experiment -source manager.go
+type Manager interface {
+	Create(exp *bean.Experiment) error
+	Get(experimentID string) (*bean.Experiment, error)
+	List() ([]*bean.Experiment, error)
+	Stop(experimentID string) error
+	// Assign 按用户哈希分配变体，同一用户在同一实验中总是命中同一变体，没有命中实验时返回nil
+	Assign(agentID, user string) (*Assignment, error)
+	// Report 按变体聚合用户反馈和token用量
+	Report(experimentID string) ([]*bean.AgentRunStat, error)
+}
+
+type manager struct {
+	ExperimentDao     dao.ExperimentDao  `autowired:""`
+	AgentRunDao       dao.AgentRunDao    `autowired:""`
+	DefinitionManager definition.Manager `autowired:""`
+	PromptManager     prompttpl.Manager  `autowired:""`
+}
+
+func NewManager() Manager {
+	return &manager{}
+}
+
+func (m *manager) Create(exp *bean.Experiment) error {
+	if err := m.validate(exp); err != nil {
+		return err
+	}
+
+	running, err := m.ExperimentDao.ListRunning()
+	if err != nil {
+		return err
+	}
+	for _, v := range running {
+		if v.AgentID == exp.AgentID {
+			return fmt.Errorf("%w: experiment '%s' is already running on the agent", ErrInvalidExperiment, v.ExperimentID)
+		}
+	}
+
+	exp.ExperimentID = tools.GenerateId("exp")
+	return m.ExperimentDao.Insert(exp)
+}
+
+func (m *manager) Get(experimentID string) (*bean.Experiment, error) {
+	exp, err := m.ExperimentDao.GetByExperimentID(experimentID)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil {
+		return nil, ErrExperimentNotFound
+	}
+	return exp, nil
+}
+
+func (m *manager) List() ([]*bean.Experiment, error) {
+	return m.ExperimentDao.List()
+}
+
+func (m *manager) Stop(experimentID string) error {
+	rows, err := m.ExperimentDao.Stop(experimentID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrExperimentNotFound
+	}
+	return nil
+}
+
+func (m *manager) Assign(agentID, user string) (*Assignment, error) {
+	if user == "" {
+		return nil, nil
+	}
+
+	running, err := m.ExperimentDao.ListRunning()
+	if err != nil {
+		return nil, err
+	}
+
+	// 优先使用指定了智能体的实验
+	var exp *bean.Experiment
+	for _, v := range running {
+		if v.AgentID == agentID {
+			exp = v
+			break
+		}
+		if v.AgentID == "" && exp == nil {
+			exp = v
+		}
+	}
+	if exp == nil || len(exp.Variants) == 0 {
+		return nil, nil
+	}
+
+	return &Assignment{
+		ExperimentID: exp.ExperimentID,
+		Variant:      pickVariant(exp, user),
+	}, nil
+}
+
+func (m *manager) Report(experimentID string) ([]*bean.AgentRunStat, error) {
+	if _, err := m.Get(experimentID); err != nil {
+		return nil, err
+	}
+
+	return m.AgentRunDao.StatByVariant(experimentID)
+}
+
+func (m *manager) validate(exp *bean.Experiment) error {
+	if exp.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidExperiment)
+	}
+	if exp.AgentID != "" {
+		if _, err := m.DefinitionManager.Get(exp.AgentID); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidExperiment, err.Error())
+		}
+	}
+	if len(exp.Variants) < 2 {
+		return fmt.Errorf("%w: at least two variants are required", ErrInvalidExperiment)
+	}
+
+	names := make(map[string]struct{}, len(exp.Variants))
+	for _, v := range exp.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("%w: variant name is empty", ErrInvalidExperiment)
+		}
+		if _, found := names[v.Name]; found {
+			return fmt.Errorf("%w: duplicate variant '%s'", ErrInvalidExperiment, v.Name)
+		}
+		names[v.Name] = struct{}{}
+
+		if v.Weight <= 0 {
+			return fmt.Errorf("%w: weight of variant '%s' must be positive", ErrInvalidExperiment, v.Name)
+		}
+		if v.ModelProfile != "" {
+			if _, found := constants.GetModelProfile(v.ModelProfile); !found {
+				return fmt.Errorf("%w: model profile '%s' not found", ErrInvalidExperiment, v.ModelProfile)
+			}
+		}
+		if v.PromptVersion != nil {
+			if _, err := m.PromptManager.GetVersion(prompttpl.TemplateOfReactSystem, *v.PromptVersion); err != nil {
+				return fmt.Errorf("%w: prompt version %d of variant '%s': %s", ErrInvalidExperiment, *v.PromptVersion, v.Name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// pickVariant 按实验ID和用户计算哈希，再按权重落到对应的变体
+func pickVariant(exp *bean.Experiment, user string) *bean.ExperimentVariant {
+	total := 0
+	for _, v := range exp.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return exp.Variants[0]
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(exp.ExperimentID + ":" + user))
+	bucket := int(h.Sum32() % uint32(total))
+
+	for _, v := range exp.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return exp.Variants[len(exp.Variants)-1]
+}
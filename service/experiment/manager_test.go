@@ -0,0 +1,61 @@
+package experiment
+
+import (
+	"fmt"
+	"testing"
+
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAssign(t *testing.T) {
+	ctl := gomock.NewController(t)
+
+	global := &bean.Experiment{
+		ExperimentID: "exp-global",
+		Variants: []*bean.ExperimentVariant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1, ModelProfile: "qwen3"},
+		},
+	}
+	weather := &bean.Experiment{
+		ExperimentID: "exp-weather",
+		AgentID:      "agent-weather",
+		Variants: []*bean.ExperimentVariant{
+			{Name: "control", Weight: 3},
+			{Name: "treatment", Weight: 1},
+		},
+	}
+
+	experimentDao := mockdao.NewMockExperimentDao(ctl)
+	experimentDao.EXPECT().ListRunning().Return([]*bean.Experiment{global, weather}, nil).AnyTimes()
+	m := &manager{ExperimentDao: experimentDao}
+
+	// 没有用户时不参与实验
+	assignment, err := m.Assign("default", "")
+	assert.Nil(t, err)
+	assert.Nil(t, assignment)
+
+	// 指定了智能体的实验优先
+	assignment, err = m.Assign("agent-weather", "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "exp-weather", assignment.ExperimentID)
+
+	assignment, err = m.Assign("default", "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "exp-global", assignment.ExperimentID)
+
+	// 同一用户总是命中同一变体，流量大致按权重分配
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		first, _ := m.Assign("agent-weather", user)
+		second, _ := m.Assign("agent-weather", user)
+		assert.Equal(t, first.Variant.Name, second.Variant.Name)
+		counts[first.Variant.Name]++
+	}
+	assert.InDelta(t, 3000, counts["control"], 200)
+	assert.InDelta(t, 1000, counts["treatment"], 200)
+}
@@ -0,0 +1,117 @@
+package feedback
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+)
+
+const (
+	RatingUp   = 1
+	RatingDown = -1
+
+	maxExportLimit = 1000
+)
+
+var (
+	ErrMessageNotFound = errors.New("message not found")
+	ErrInvalidFeedback = errors.New("invalid feedback")
+)
+
+// Record 导出的反馈记录，包含对应的提问和回答
+type Record struct {
+	Feedback *bean.MessageFeedback
+	Input    string
+	Answer   string
+}
+
+//go:generate mockgen -destination ../../internal/mock/feedback/manager_mock.go -package feedback -source manager.go
+type Manager interface {
+	// Submit 提交反馈，同一用户对同一消息重复提交时覆盖之前的反馈
+	Submit(fb *bean.MessageFeedback) error
+	// Export 按条件导出反馈，用于构建评测数据集
+	Export(filter *dao.FeedbackFilter) ([]*Record, error)
+}
+
+type manager struct {
+	MessageFeedbackDao dao.MessageFeedbackDao `autowired:""`
+	AgentRunDao        dao.AgentRunDao        `autowired:""`
+}
+
+func NewManager() Manager {
+	return &manager{}
+}
+
+func (m *manager) Submit(fb *bean.MessageFeedback) error {
+	if fb.Rating != RatingUp && fb.Rating != RatingDown {
+		return fmt.Errorf("%w: rating must be %d or %d", ErrInvalidFeedback, RatingUp, RatingDown)
+	}
+
+	// 只能对自己的消息反馈
+	run, err := m.AgentRunDao.GetByRunID(fb.MessageID)
+	if err != nil {
+		return err
+	}
+	if run == nil || run.User != fb.User {
+		return ErrMessageNotFound
+	}
+
+	fb.RequestID = run.RequestID
+	fb.AgentID = run.AgentID
+	fb.Model = run.Model
+	fb.PromptName = run.PromptName
+	fb.PromptVersion = run.PromptVersion
+
+	exist, err := m.MessageFeedbackDao.GetByMessageIDAndUser(fb.MessageID, fb.User)
+	if err != nil {
+		return err
+	}
+	if exist == nil {
+		err = m.MessageFeedbackDao.Insert(fb)
+	} else {
+		_, err = m.MessageFeedbackDao.Update(fb)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = m.AgentRunDao.UpdateRating(fb.MessageID, fb.Rating)
+	return err
+}
+
+func (m *manager) Export(filter *dao.FeedbackFilter) ([]*Record, error) {
+	if filter.Limit <= 0 || filter.Limit > maxExportLimit {
+		filter.Limit = maxExportLimit
+	}
+
+	fbs, err := m.MessageFeedbackDao.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	runIDs := make([]string, 0, len(fbs))
+	for _, fb := range fbs {
+		runIDs = append(runIDs, fb.MessageID)
+	}
+	runs, err := m.AgentRunDao.ListByRunIDs(runIDs)
+	if err != nil {
+		return nil, err
+	}
+	runMap := make(map[string]*bean.AgentRun, len(runs))
+	for _, run := range runs {
+		runMap[run.RunID] = run
+	}
+
+	records := make([]*Record, 0, len(fbs))
+	for _, fb := range fbs {
+		record := &Record{Feedback: fb}
+		if run, found := runMap[fb.MessageID]; found {
+			record.Input = run.Input
+			record.Answer = run.Answer
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,57 @@
+package feedback
+
+import (
+	"errors"
+	"testing"
+
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubmit(t *testing.T) {
+	ctl := gomock.NewController(t)
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	feedbackDao := mockdao.NewMockMessageFeedbackDao(ctl)
+	m := &manager{MessageFeedbackDao: feedbackDao, AgentRunDao: agentRunDao}
+
+	run := &bean.AgentRun{
+		RunID:         "run-1",
+		RequestID:     "req-1",
+		User:          "user-1",
+		AgentID:       "default",
+		Model:         "qwen3",
+		PromptName:    "react_system",
+		PromptVersion: 3,
+	}
+	agentRunDao.EXPECT().GetByRunID("run-1").Return(run, nil).AnyTimes()
+	agentRunDao.EXPECT().GetByRunID("run-unknown").Return(nil, nil)
+
+	// 评分不合法
+	err := m.Submit(&bean.MessageFeedback{MessageID: "run-1", User: "user-1", Rating: 0})
+	assert.True(t, errors.Is(err, ErrInvalidFeedback))
+
+	// 消息不存在或者不属于当前用户
+	err = m.Submit(&bean.MessageFeedback{MessageID: "run-unknown", User: "user-1", Rating: RatingUp})
+	assert.True(t, errors.Is(err, ErrMessageNotFound))
+	err = m.Submit(&bean.MessageFeedback{MessageID: "run-1", User: "user-2", Rating: RatingUp})
+	assert.True(t, errors.Is(err, ErrMessageNotFound))
+
+	// 首次反馈写入，并冗余运行信息
+	feedbackDao.EXPECT().GetByMessageIDAndUser("run-1", "user-1").Return(nil, nil)
+	feedbackDao.EXPECT().Insert(gomock.Any()).DoAndReturn(func(fb *bean.MessageFeedback) error {
+		assert.Equal(t, "req-1", fb.RequestID)
+		assert.Equal(t, "qwen3", fb.Model)
+		assert.Equal(t, 3, fb.PromptVersion)
+		return nil
+	})
+	agentRunDao.EXPECT().UpdateRating("run-1", RatingUp).Return(int64(1), nil)
+	assert.Nil(t, m.Submit(&bean.MessageFeedback{MessageID: "run-1", User: "user-1", Rating: RatingUp}))
+
+	// 重复反馈覆盖
+	feedbackDao.EXPECT().GetByMessageIDAndUser("run-1", "user-1").Return(&bean.MessageFeedback{}, nil)
+	feedbackDao.EXPECT().Update(gomock.Any()).Return(int64(1), nil)
+	agentRunDao.EXPECT().UpdateRating("run-1", RatingDown).Return(int64(1), nil)
+	assert.Nil(t, m.Submit(&bean.MessageFeedback{MessageID: "run-1", User: "user-1", Rating: RatingDown, Category: "inaccurate"}))
+}
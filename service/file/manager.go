@@ -0,0 +1,216 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/google/uuid"
+)
+
+const (
+	metaSuffix = ".json"
+	gcInterval = time.Minute
+)
+
+var (
+	ErrFileNotFound    = errors.New("file not found")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrFileEmpty       = errors.New("file is empty")
+	ErrUnsupportedType = errors.New("file type is not supported")
+)
+
+// defaultContentTypes 未配置允许的类型时，允许模型常见支持的图片和纯文本
+var defaultContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "text/plain"}
+
+// File 上传的文件，ContentType按内容识别，与文件名的后缀无关
+type File struct {
+	FileID      string
+	User        string
+	Name        string
+	ContentType string
+	Size        int64
+	CreateTime  time.Time
+}
+
+//go:generate mockgen -destination ../../internal/mock/file/manager_mock.go -package file -source manager.go
+type Manager interface {
+	// Upload 识别类型并检查大小后保存
+	Upload(user, name string, data []byte) (*File, error)
+	// Get 返回文件信息和内容，只能读取自己上传的文件
+	Get(fileID, user string) (*File, []byte, error)
+}
+
+// manager 本地目录存储，每个文件保存内容和同名的.json信息，通过修改时间判断是否过期
+type manager struct {
+	dir    string
+	lock   sync.Mutex
+	lastGC time.Time
+}
+
+func NewManager() (Manager, error) {
+	return NewLocalManager(constants.Prop.File.Dir)
+}
+
+func NewLocalManager(dir string) (Manager, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &manager{dir: dir}, nil
+}
+
+func (m *manager) Upload(user, name string, data []byte) (*File, error) {
+	m.tryGC()
+
+	if len(data) == 0 {
+		return nil, ErrFileEmpty
+	}
+	if maxSize := constants.Prop.File.MaxSize; maxSize > 0 && int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w, max size is %d bytes", ErrFileTooLarge, maxSize)
+	}
+	contentType := DetectContentType(data)
+	if !allowed(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	f := &File{
+		FileID:      uuid.New().String(),
+		User:        user,
+		Name:        filepath.Base(name),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		CreateTime:  time.Now(),
+	}
+	// 先写内容再写信息，只有信息存在的文件才能被读取
+	if err := writeFile(m.dir, f.FileID, data); err != nil {
+		return nil, err
+	}
+	meta, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeFile(m.dir, f.FileID+metaSuffix, meta); err != nil {
+		_ = os.Remove(filepath.Join(m.dir, f.FileID))
+		return nil, err
+	}
+	return f, nil
+}
+
+func (m *manager) Get(fileID, user string) (*File, []byte, error) {
+	// 文件ID由服务端生成，不是uuid时不访问文件系统
+	if _, err := uuid.Parse(fileID); err != nil {
+		return nil, nil, ErrFileNotFound
+	}
+
+	meta, err := os.ReadFile(filepath.Join(m.dir, fileID+metaSuffix))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrFileNotFound
+		}
+		return nil, nil, err
+	}
+	f := &File{}
+	if err = json.Unmarshal(meta, f); err != nil {
+		return nil, nil, err
+	}
+	if f.User != user || expired(f.CreateTime, time.Now()) {
+		return nil, nil, ErrFileNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.dir, fileID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrFileNotFound
+		}
+		return nil, nil, err
+	}
+	return f, data, nil
+}
+
+// tryGC 每隔gcInterval清理一次过期的文件，包括写入过程中退出留下的临时文件
+func (m *manager) tryGC() {
+	now := time.Now()
+	m.lock.Lock()
+	if now.Sub(m.lastGC) < gcInterval {
+		m.lock.Unlock()
+		return
+	}
+	m.lastGC = now
+	m.lock.Unlock()
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !info.IsDir() && expired(info.ModTime(), now) {
+			_ = os.Remove(filepath.Join(m.dir, entry.Name()))
+		}
+	}
+}
+
+func expired(t, now time.Time) bool {
+	ttl := constants.Prop.File.TTL
+	return ttl > 0 && now.Sub(t) > ttl
+}
+
+// writeFile 先写临时文件再重命名，进程在写入过程中退出时不会留下不完整的文件
+func writeFile(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// DetectContentType 按内容识别类型，不包含charset等参数
+func DetectContentType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return contentType
+}
+
+func allowed(contentType string) bool {
+	contentTypes := constants.Prop.File.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultContentTypes
+	}
+	return slices.Contains(contentTypes, contentType)
+}
+
+func IsImage(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// ExtractText 提取文本类型文件的内容，超过maxTextLength个字符时截断，其他类型无法提取
+func ExtractText(contentType string, data []byte) (string, bool) {
+	if !strings.HasPrefix(contentType, "text/") || !utf8.Valid(data) {
+		return "", false
+	}
+	text := string(data)
+	if maxLength := constants.Prop.File.MaxTextLength; maxLength > 0 && utf8.RuneCountInString(text) > maxLength {
+		text = string([]rune(text)[:maxLength])
+	}
+	return text, true
+}
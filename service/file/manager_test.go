@@ -0,0 +1,102 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+// png文件头
+var pngData = []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+func TestManagerUpload(t *testing.T) {
+	maxSize := constants.Prop.File.MaxSize
+	constants.Prop.File.MaxSize = 64
+	defer func() { constants.Prop.File.MaxSize = maxSize }()
+
+	m, err := NewLocalManager(t.TempDir())
+	assert.Nil(t, err)
+
+	// 类型按内容识别，与文件名无关
+	f, err := m.Upload("alice", "../photo.txt", pngData)
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", f.ContentType)
+	assert.Equal(t, "photo.txt", f.Name)
+	assert.Equal(t, int64(len(pngData)), f.Size)
+
+	got, data, err := m.Get(f.FileID, "alice")
+	assert.Nil(t, err)
+	assert.Equal(t, f.Name, got.Name)
+	assert.Equal(t, pngData, data)
+
+	// 其他用户和不存在的文件
+	_, _, err = m.Get(f.FileID, "bob")
+	assert.True(t, errors.Is(err, ErrFileNotFound))
+	_, _, err = m.Get("../"+f.FileID, "alice")
+	assert.True(t, errors.Is(err, ErrFileNotFound))
+
+	_, err = m.Upload("alice", "a.txt", nil)
+	assert.True(t, errors.Is(err, ErrFileEmpty))
+	_, err = m.Upload("alice", "a.txt", []byte(strings.Repeat("a", 65)))
+	assert.True(t, errors.Is(err, ErrFileTooLarge))
+	_, err = m.Upload("alice", "a.pdf", []byte("%PDF-1.4"))
+	assert.True(t, errors.Is(err, ErrUnsupportedType))
+}
+
+// TestManagerTTL 过期的文件无法读取，上传时清理
+func TestManagerTTL(t *testing.T) {
+	ttl := constants.Prop.File.TTL
+	constants.Prop.File.TTL = time.Hour
+	defer func() { constants.Prop.File.TTL = ttl }()
+
+	dir := t.TempDir()
+	m, err := NewLocalManager(dir)
+	assert.Nil(t, err)
+
+	f, err := m.Upload("alice", "photo.png", pngData)
+	assert.Nil(t, err)
+	_, _, err = m.Get(f.FileID, "alice")
+	assert.Nil(t, err)
+
+	// 创建时间和修改时间都早于TTL
+	past := time.Now().Add(-2 * time.Hour)
+	f.CreateTime = past
+	meta, err := json.Marshal(f)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, f.FileID+metaSuffix), meta, 0o644))
+	for _, name := range []string{f.FileID, f.FileID + metaSuffix} {
+		assert.Nil(t, os.Chtimes(filepath.Join(dir, name), past, past))
+	}
+	_, _, err = m.Get(f.FileID, "alice")
+	assert.True(t, errors.Is(err, ErrFileNotFound))
+
+	m.(*manager).lastGC = time.Time{}
+	_, err = m.Upload("alice", "photo.png", pngData)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, f.FileID))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Stat(filepath.Join(dir, f.FileID+metaSuffix))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestExtractText(t *testing.T) {
+	maxTextLength := constants.Prop.File.MaxTextLength
+	constants.Prop.File.MaxTextLength = 3
+	defer func() { constants.Prop.File.MaxTextLength = maxTextLength }()
+
+	text, ok := ExtractText("text/plain", []byte("北京天气"))
+	assert.True(t, ok)
+	assert.Equal(t, "北京天", text)
+
+	_, ok = ExtractText("image/png", pngData)
+	assert.False(t, ok)
+	_, ok = ExtractText("text/plain", []byte{0xff, 0xfe})
+	assert.False(t, ok)
+}
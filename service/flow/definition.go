@@ -0,0 +1,170 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrFlowNotFound = errors.New("flow not found")
+	ErrInvalidFlow  = errors.New("invalid flow")
+)
+
+// 节点类型
+const (
+	NodeTypeOfTemplate  = "template"
+	NodeTypeOfChatModel = "chat_model"
+	NodeTypeOfTools     = "tools"
+	NodeTypeOfLambda    = "lambda"
+	NodeTypeOfRetriever = "retriever"
+)
+
+// 边和分支中表示图的开始和结束
+const (
+	KeyOfStart = "start"
+	KeyOfEnd   = "end"
+)
+
+// Definition 声明式的图定义，输入为变量map[string]any，输出为回答*schema.Message
+type Definition struct {
+	Name     string    `yaml:"name"`   // 为空时使用文件名
+	Answer   string    `yaml:"answer"` // 输出回答的chat_model节点，为空时为连接到end的节点
+	Nodes    []*Node   `yaml:"nodes"`
+	Edges    []*Edge   `yaml:"edges"`
+	Branches []*Branch `yaml:"branches"`
+}
+
+type Node struct {
+	Key  string `yaml:"key"`
+	Type string `yaml:"type"`
+
+	Format        string             `yaml:"format"`        // template: fstring(默认), jinja2, goTemplate
+	Messages      []*MessageTemplate `yaml:"messages"`      // template
+	ModelProfile  string             `yaml:"modelProfile"`  // chat_model: 模型配置名称
+	Tools         []string           `yaml:"tools"`         // chat_model绑定的工具，tools节点可以执行的工具
+	Lambda        string             `yaml:"lambda"`        // lambda: 注册的lambda名称
+	KnowledgeBase string             `yaml:"knowledgeBase"` // retriever: 知识库名称
+}
+
+// MessageTemplate 模板中的一条消息，设置Placeholder时为消息列表变量
+type MessageTemplate struct {
+	Role        string `yaml:"role"` // system, user, assistant, tool
+	Content     string `yaml:"content"`
+	ToolCallID  string `yaml:"toolCallID"`
+	Placeholder string `yaml:"placeholder"`
+	Optional    bool   `yaml:"optional"` // 消息列表变量可以不存在
+}
+
+type Edge struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Branch 按注册的条件选择下一个节点，Routes的key为条件的返回值
+type Branch struct {
+	From      string            `yaml:"from"`
+	Condition string            `yaml:"condition"`
+	Routes    map[string]string `yaml:"routes"`
+}
+
+// validate 检查定义的结构，节点引用的工具、模型等由构建图时检查
+func (d *Definition) validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidFlow)
+	}
+	if len(d.Nodes) == 0 {
+		return fmt.Errorf("%w: nodes is empty", ErrInvalidFlow)
+	}
+
+	nodes := make(map[string]*Node, len(d.Nodes))
+	for _, node := range d.Nodes {
+		if node == nil || node.Key == "" {
+			return fmt.Errorf("%w: node key is empty", ErrInvalidFlow)
+		}
+		if node.Key == KeyOfStart || node.Key == KeyOfEnd {
+			return fmt.Errorf("%w: node key '%s' is reserved", ErrInvalidFlow, node.Key)
+		}
+		if _, found := nodes[node.Key]; found {
+			return fmt.Errorf("%w: node '%s' is duplicated", ErrInvalidFlow, node.Key)
+		}
+		if err := node.validate(); err != nil {
+			return err
+		}
+		nodes[node.Key] = node
+	}
+
+	exist := func(key string, allowed string) bool {
+		_, found := nodes[key]
+		return found || key == allowed
+	}
+	var ends []string
+	for _, edge := range d.Edges {
+		if edge == nil || !exist(edge.From, KeyOfStart) || !exist(edge.To, KeyOfEnd) {
+			return fmt.Errorf("%w: edge %v references unknown node", ErrInvalidFlow, edge)
+		}
+		if edge.To == KeyOfEnd {
+			ends = append(ends, edge.From)
+		}
+	}
+	for _, branch := range d.Branches {
+		if branch == nil || !exist(branch.From, KeyOfStart) {
+			return fmt.Errorf("%w: branch %v references unknown node", ErrInvalidFlow, branch)
+		}
+		if branch.Condition == "" || len(branch.Routes) == 0 {
+			return fmt.Errorf("%w: branch from '%s' has no condition or routes", ErrInvalidFlow, branch.From)
+		}
+		for _, to := range branch.Routes {
+			if !exist(to, KeyOfEnd) {
+				return fmt.Errorf("%w: branch from '%s' routes to unknown node '%s'", ErrInvalidFlow, branch.From, to)
+			}
+			if to == KeyOfEnd {
+				ends = append(ends, branch.From)
+			}
+		}
+	}
+
+	if d.Answer == "" && len(ends) > 0 {
+		d.Answer = ends[0]
+	}
+	if answer, found := nodes[d.Answer]; !found || answer.Type != NodeTypeOfChatModel {
+		return fmt.Errorf("%w: answer node '%s' is not a chat_model node", ErrInvalidFlow, d.Answer)
+	}
+	return nil
+}
+
+func (n *Node) validate() error {
+	switch n.Type {
+	case NodeTypeOfTemplate:
+		if len(n.Messages) == 0 {
+			return fmt.Errorf("%w: template node '%s' has no messages", ErrInvalidFlow, n.Key)
+		}
+		if n.Format != "" && !slices.Contains([]string{"fstring", "jinja2", "goTemplate"}, n.Format) {
+			return fmt.Errorf("%w: template node '%s' format '%s' is not supported", ErrInvalidFlow, n.Key, n.Format)
+		}
+		for _, msg := range n.Messages {
+			if msg == nil || (msg.Placeholder == "" && !slices.Contains([]string{"system", "user", "assistant", "tool"}, msg.Role)) {
+				return fmt.Errorf("%w: template node '%s' has invalid message", ErrInvalidFlow, n.Key)
+			}
+		}
+	case NodeTypeOfChatModel:
+		if n.ModelProfile == "" {
+			return fmt.Errorf("%w: chat_model node '%s' has no model profile", ErrInvalidFlow, n.Key)
+		}
+	case NodeTypeOfTools:
+		if len(n.Tools) == 0 {
+			return fmt.Errorf("%w: tools node '%s' has no tools", ErrInvalidFlow, n.Key)
+		}
+	case NodeTypeOfLambda:
+		if n.Lambda == "" {
+			return fmt.Errorf("%w: lambda node '%s' has no lambda", ErrInvalidFlow, n.Key)
+		}
+	case NodeTypeOfRetriever:
+		if n.KnowledgeBase == "" {
+			return fmt.Errorf("%w: retriever node '%s' has no knowledge base", ErrInvalidFlow, n.Key)
+		}
+	default:
+		return fmt.Errorf("%w: node '%s' type '%s' is not supported", ErrInvalidFlow, n.Key, n.Type)
+	}
+	return nil
+}
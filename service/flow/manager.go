@@ -0,0 +1,343 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/service/knowledge"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/toolkit"
+	"github.com/caiflower/common-tools/global/env"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/pkg/tools"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+//go:generate mockgen -destination ../../internal/mock/flow/manager_mock.go -package flow -source manager.go
+type Manager interface {
+	Exist(name string) bool
+	List() []*Definition
+	// Graph 按定义创建新的图，answerNodeName为输出回答节点的节点名称，用于回调中识别回答；
+	// wrapTools不为空时包装tools节点执行的工具
+	Graph(name, answerNodeName string, wrapTools ToolWrapper) (*compose.Graph[map[string]any, *schema.Message], error)
+}
+
+// ToolWrapper 包装tools节点执行的工具，例如按策略判定和限制执行时间
+type ToolWrapper func(ctx context.Context, tools []tool.BaseTool) ([]tool.BaseTool, error)
+
+// flowFile 已加载的文件，修改时间和大小不变时不重新加载
+type flowFile struct {
+	modTime time.Time
+	size    int64
+	def     *Definition
+}
+
+type manager struct {
+	Registry          Registry           `autowired:""`
+	ToolRegistry      toolkit.Registry   `autowired:""`
+	KnowledgeRegistry knowledge.Registry `autowired:""`
+	Factory           chatmodel.Factory  `autowired:""`
+
+	lock       sync.Mutex
+	dir        string
+	files      map[string]*flowFile // key为文件路径
+	lastReload time.Time
+}
+
+func NewManager() Manager {
+	return &manager{files: make(map[string]*flowFile)}
+}
+
+// NewManagerWithDir 从指定目录加载图定义
+func NewManagerWithDir(dir string) Manager {
+	return &manager{dir: dir, files: make(map[string]*flowFile)}
+}
+
+func (m *manager) Exist(name string) bool {
+	return m.get(name) != nil
+}
+
+func (m *manager) List() []*Definition {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.tryReload()
+
+	defs := make([]*Definition, 0, len(m.files))
+	for _, f := range m.files {
+		defs = append(defs, f.def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+func (m *manager) Graph(name, answerNodeName string, wrapTools ToolWrapper) (*compose.Graph[map[string]any, *schema.Message], error) {
+	def := m.get(name)
+	if def == nil {
+		return nil, ErrFlowNotFound
+	}
+	return m.build(context.Background(), def, answerNodeName, wrapTools)
+}
+
+func (m *manager) get(name string) *Definition {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.tryReload()
+
+	for _, f := range m.files {
+		if f.def.Name == name {
+			return f.def
+		}
+	}
+	return nil
+}
+
+// tryReload 距离上次加载超过间隔时检查目录，重新加载修改过的文件。
+// 加载失败时保留之前的版本，文件删除后移除对应的图
+func (m *manager) tryReload() {
+	interval := constants.Prop.Flow.ReloadInterval
+	if !m.lastReload.IsZero() && time.Since(m.lastReload) < interval {
+		return
+	}
+	m.lastReload = time.Now()
+
+	dir := m.flowDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("read flow dir failed. Dir: %s, Error: %v", dir, err)
+		}
+		m.files = make(map[string]*flowFile)
+		return
+	}
+
+	exists := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		exists[path] = true
+
+		info, err := entry.Info()
+		if err != nil {
+			logger.Error("stat flow file failed. File: %s, Error: %v", path, err)
+			continue
+		}
+		if f, found := m.files[path]; found && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+			continue
+		}
+
+		def, err := m.load(path)
+		if err != nil {
+			logger.Error("load flow failed, keep the previous version. File: %s, Error: %v", path, err)
+			continue
+		}
+		m.files[path] = &flowFile{modTime: info.ModTime(), size: info.Size(), def: def}
+		logger.Info("load flow success. Name: %s, File: %s", def.Name, path)
+	}
+
+	for path := range m.files {
+		if !exists[path] {
+			logger.Info("flow file removed. File: %s", path)
+			delete(m.files, path)
+		}
+	}
+}
+
+func (m *manager) flowDir() string {
+	if m.dir != "" {
+		return m.dir
+	}
+	if constants.Prop.Flow.Dir != "" {
+		return constants.Prop.Flow.Dir
+	}
+	return filepath.Join(env.ConfigPath, "flows")
+}
+
+// load 解析并校验文件，编译一次确认节点之间的类型匹配。json是yaml的子集，使用同一个解析器
+func (m *manager) load(path string) (*Definition, error) {
+	def := &Definition{}
+	if err := tools.UnmarshalFileYaml(path, def); err != nil {
+		return nil, err
+	}
+	if def.Name == "" {
+		def.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	for p, f := range m.files {
+		if p != path && f.def.Name == def.Name {
+			return nil, fmt.Errorf("%w: name '%s' is already defined in %s", ErrInvalidFlow, def.Name, p)
+		}
+	}
+
+	ctx := context.Background()
+	g, err := m.build(ctx, def, def.Answer, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = g.Compile(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFlow, err)
+	}
+	return def, nil
+}
+
+func (m *manager) build(ctx context.Context, def *Definition, answerNodeName string, wrapTools ToolWrapper) (*compose.Graph[map[string]any, *schema.Message], error) {
+	g := compose.NewGraph[map[string]any, *schema.Message]()
+
+	for _, node := range def.Nodes {
+		name := node.Key
+		if node.Key == def.Answer {
+			name = answerNodeName
+		}
+		if err := m.addNode(ctx, g, node, wrapTools, compose.WithNodeName(name)); err != nil {
+			return nil, fmt.Errorf("%w: node '%s': %v", ErrInvalidFlow, node.Key, err)
+		}
+	}
+	for _, edge := range def.Edges {
+		if err := g.AddEdge(graphKey(edge.From), graphKey(edge.To)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFlow, err)
+		}
+	}
+	for _, branch := range def.Branches {
+		cond, err := m.Registry.GetCondition(branch.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFlow, err)
+		}
+		routes := make(map[string]string, len(branch.Routes))
+		for route, to := range branch.Routes {
+			routes[route] = graphKey(to)
+		}
+		if err = g.AddBranch(graphKey(branch.From), cond(routes)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFlow, err)
+		}
+	}
+	return g, nil
+}
+
+func (m *manager) addNode(ctx context.Context, g *compose.Graph[map[string]any, *schema.Message], node *Node, wrapTools ToolWrapper, opt compose.GraphAddNodeOpt) error {
+	switch node.Type {
+	case NodeTypeOfTemplate:
+		return g.AddChatTemplateNode(node.Key, buildTemplate(node), opt)
+	case NodeTypeOfChatModel:
+		profile, found := constants.GetModelProfile(node.ModelProfile)
+		if !found {
+			return fmt.Errorf("model profile '%s' not found", node.ModelProfile)
+		}
+		chatModel, err := m.Factory.CreateChatModel(chatmodel.Protocol(profile.Protocol), &chatmodel.Config{
+			BaseURL: profile.Url,
+			Model:   profile.Model,
+			Timeout: profile.Timeout,
+		})
+		if err != nil {
+			return err
+		}
+		if len(node.Tools) > 0 {
+			toolInfos, err := m.toolInfos(ctx, node.Tools)
+			if err != nil {
+				return err
+			}
+			if chatModel, err = chatModel.WithTools(toolInfos); err != nil {
+				return err
+			}
+		}
+		return g.AddChatModelNode(node.Key, chatModel, opt)
+	case NodeTypeOfTools:
+		nodeTools, err := m.ToolRegistry.GetTools(node.Tools)
+		if err != nil {
+			return err
+		}
+		if wrapTools != nil {
+			if nodeTools, err = wrapTools(ctx, nodeTools); err != nil {
+				return err
+			}
+		}
+		toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: nodeTools})
+		if err != nil {
+			return err
+		}
+		return g.AddToolsNode(node.Key, toolsNode, opt)
+	case NodeTypeOfLambda:
+		lambda, err := m.Registry.GetLambda(node.Lambda)
+		if err != nil {
+			return err
+		}
+		return g.AddLambdaNode(node.Key, lambda, opt)
+	case NodeTypeOfRetriever:
+		rt, err := m.KnowledgeRegistry.GetRetriever(node.KnowledgeBase)
+		if err != nil {
+			return err
+		}
+		return g.AddRetrieverNode(node.Key, rt, opt)
+	default:
+		return fmt.Errorf("node type '%s' is not supported", node.Type)
+	}
+}
+
+func (m *manager) toolInfos(ctx context.Context, names []string) ([]*schema.ToolInfo, error) {
+	nodeTools, err := m.ToolRegistry.GetTools(names)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*schema.ToolInfo, 0, len(nodeTools))
+	for _, t := range nodeTools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func buildTemplate(node *Node) prompt.ChatTemplate {
+	format := schema.FString
+	switch node.Format {
+	case "jinja2":
+		format = schema.Jinja2
+	case "goTemplate":
+		format = schema.GoTemplate
+	}
+
+	templates := make([]schema.MessagesTemplate, 0, len(node.Messages))
+	for _, msg := range node.Messages {
+		if msg.Placeholder != "" {
+			templates = append(templates, schema.MessagesPlaceholder(msg.Placeholder, msg.Optional))
+			continue
+		}
+		switch msg.Role {
+		case "system":
+			templates = append(templates, schema.SystemMessage(msg.Content))
+		case "user":
+			templates = append(templates, schema.UserMessage(msg.Content))
+		case "assistant":
+			templates = append(templates, schema.AssistantMessage(msg.Content, nil))
+		case "tool":
+			templates = append(templates, schema.ToolMessage(msg.Content, msg.ToolCallID))
+		}
+	}
+	return prompt.FromMessages(format, templates...)
+}
+
+func graphKey(key string) string {
+	switch key {
+	case KeyOfStart:
+		return compose.START
+	case KeyOfEnd:
+		return compose.END
+	default:
+		return key
+	}
+}
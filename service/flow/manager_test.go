@@ -0,0 +1,174 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caiflower/ai-agent/constants"
+	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	"github.com/caiflower/ai-agent/service/knowledge"
+	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/toolkit"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type userInfo struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Company  string `json:"company,omitempty"`
+	Position string `json:"position,omitempty"`
+	Salary   string `json:"salary,omitempty"`
+}
+
+// houseChatModel 用户提问时调用user_info工具，收到用户信息后给出推荐
+type houseChatModel struct {
+	model.ToolCallingChatModel
+}
+
+func (m *houseChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	last := input[len(input)-1]
+	switch last.Role {
+	case schema.User:
+		return schema.AssistantMessage("", []schema.ToolCall{{
+			ID:       "call_1",
+			Function: schema.FunctionCall{Name: "user_info", Arguments: `{"name": "zhangsan", "email": "zhangsan@bytedance.com"}`},
+		}}), nil
+	case schema.Tool:
+		return schema.AssistantMessage("推荐"+last.Content, nil), nil
+	}
+	return nil, errors.New("unexpected input")
+}
+
+func (m *houseChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func newTestManager(t *testing.T, dir string) *manager {
+	constants.Prop.ModelProfiles = []constants.ModelProfileConfig{{Name: "mock", Protocol: string(chatmodel.ProtocolMock)}}
+	constants.Prop.Flow.ReloadInterval = 0
+
+	ctl := gomock.NewController(t)
+	factory := mockchatmodel.NewMockFactory(ctl)
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Any()).Return(&houseChatModel{}, nil).AnyTimes()
+
+	toolRegistry := toolkit.NewRegistry()
+	userInfoTool := utils.NewTool(&schema.ToolInfo{Name: "user_info", Desc: "根据用户的姓名和邮箱，查询用户的公司、职位、薪酬信息"},
+		func(ctx context.Context, input *userInfo) (*userInfo, error) {
+			input.Company, input.Position, input.Salary = "Bytedance", "CEO", "9999"
+			return input, nil
+		})
+	assert.Nil(t, toolRegistry.Register(userInfoTool))
+
+	m := NewManagerWithDir(dir).(*manager)
+	m.Registry = NewRegistry()
+	m.ToolRegistry = toolRegistry
+	m.KnowledgeRegistry = knowledge.NewRegistry()
+	m.Factory = factory
+	return m
+}
+
+func TestManagerGraph(t *testing.T) {
+	m := newTestManager(t, "testdata")
+	assert.True(t, m.Exist("house_recommend"))
+
+	g, err := m.Graph("house_recommend", "answer", nil)
+	assert.Nil(t, err)
+	runner, err := g.Compile(context.Background())
+	assert.Nil(t, err)
+
+	answer, err := runner.Invoke(context.Background(), map[string]any{"query": "我叫 zhangsan, 邮箱是 zhangsan@bytedance.com, 帮我推荐一处房产"})
+	assert.Nil(t, err)
+	assert.Equal(t, "推荐姓名:zhangsan 邮箱：zhangsan@bytedance.com 公司: Bytedance 职位: CEO 薪水：9999", answer.Content)
+
+	_, err = m.Graph("not_exist", "answer", nil)
+	assert.ErrorIs(t, err, ErrFlowNotFound)
+}
+
+func TestManagerReload(t *testing.T) {
+	dir := t.TempDir()
+	content, err := os.ReadFile("testdata/house_recommend.yaml")
+	assert.Nil(t, err)
+	file := filepath.Join(dir, "house.yaml")
+	assert.Nil(t, os.WriteFile(file, content, 0644))
+
+	m := newTestManager(t, dir)
+	assert.True(t, m.Exist("house_recommend"))
+
+	// 引用未注册的工具，保留之前的版本
+	invalid := strings.ReplaceAll(string(content), "[user_info]", "[not_exist]")
+	assert.Nil(t, os.WriteFile(file, []byte(invalid), 0644))
+	assert.True(t, m.Exist("house_recommend"))
+	_, err = m.Graph("house_recommend", "answer", nil)
+	assert.Nil(t, err)
+
+	// 修改名称后按新名称加载
+	renamed := strings.Replace(string(content), "name: house_recommend", "name: house", 1)
+	assert.Nil(t, os.WriteFile(file, []byte(renamed), 0644))
+	assert.False(t, m.Exist("house_recommend"))
+	assert.True(t, m.Exist("house"))
+
+	assert.Nil(t, os.Remove(file))
+	_, err = m.Graph("house", "answer", nil)
+	assert.ErrorIs(t, err, ErrFlowNotFound)
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	model := &Node{Key: "model", Type: NodeTypeOfChatModel, ModelProfile: "mock"}
+	cases := []*Definition{
+		{Name: "empty"},
+		{Name: "reserved", Nodes: []*Node{{Key: KeyOfEnd, Type: NodeTypeOfChatModel, ModelProfile: "mock"}}},
+		{Name: "duplicated", Nodes: []*Node{model, model}, Edges: []*Edge{{From: KeyOfStart, To: "model"}, {From: "model", To: KeyOfEnd}}},
+		{Name: "unknown_type", Nodes: []*Node{model, {Key: "retry", Type: "retry"}}},
+		{Name: "unknown_node", Nodes: []*Node{model}, Edges: []*Edge{{From: KeyOfStart, To: "template"}, {From: "model", To: KeyOfEnd}}},
+		{Name: "no_answer", Nodes: []*Node{model, {Key: "parse", Type: NodeTypeOfLambda, Lambda: LambdaOfParseJSON}},
+			Edges: []*Edge{{From: KeyOfStart, To: "model"}, {From: "model", To: "parse"}, {From: "parse", To: KeyOfEnd}}},
+		{Name: "no_routes", Nodes: []*Node{model}, Edges: []*Edge{{From: KeyOfStart, To: "model"}},
+			Branches: []*Branch{{From: "model", Condition: ConditionOfHasToolCall}}},
+	}
+	for _, def := range cases {
+		assert.ErrorIs(t, def.validate(), ErrInvalidFlow, def.Name)
+	}
+
+	def := &Definition{Name: "valid", Nodes: []*Node{model}, Edges: []*Edge{{From: KeyOfStart, To: "model"}, {From: "model", To: KeyOfEnd}}}
+	assert.Nil(t, def.validate())
+	assert.Equal(t, "model", def.Answer)
+}
+
+func TestConditionRoute(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	def := &Definition{
+		Name: "route",
+		Nodes: []*Node{
+			{Key: "template", Type: NodeTypeOfTemplate, Messages: []*MessageTemplate{{Role: "user", Content: "{query}"}}},
+			{Key: "model", Type: NodeTypeOfChatModel, ModelProfile: "mock", Tools: []string{"user_info"}},
+			{Key: "tools", Type: NodeTypeOfTools, Tools: []string{"user_info"}},
+			{Key: "parse", Type: NodeTypeOfLambda, Lambda: LambdaOfParseJSON},
+			{Key: "recommend_template", Type: NodeTypeOfTemplate, Messages: []*MessageTemplate{{Role: "tool", Content: "{company}", ToolCallID: "user_info"}}},
+			{Key: "answer", Type: NodeTypeOfChatModel, ModelProfile: "mock"},
+		},
+		Edges: []*Edge{
+			{From: KeyOfStart, To: "template"}, {From: "template", To: "model"}, {From: "tools", To: "parse"},
+			{From: "parse", To: "recommend_template"}, {From: "recommend_template", To: "answer"}, {From: "answer", To: KeyOfEnd},
+		},
+		Branches: []*Branch{{From: "model", Condition: ConditionOfHasToolCall, Routes: map[string]string{"true": "tools", "false": KeyOfEnd}}},
+	}
+	assert.Nil(t, def.validate())
+	assert.Equal(t, "answer", def.Answer)
+
+	g, err := m.build(context.Background(), def, def.Answer, nil)
+	assert.Nil(t, err)
+	runner, err := g.Compile(context.Background(), compose.WithNodeTriggerMode(compose.AnyPredecessor))
+	assert.Nil(t, err)
+	answer, err := runner.Invoke(context.Background(), map[string]any{"query": "zhangsan"})
+	assert.Nil(t, err)
+	assert.Equal(t, "推荐Bytedance", answer.Content)
+}
@@ -0,0 +1,156 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 内置的lambda和分支条件
+const (
+	LambdaOfParseJSON      = "parse_json"    // []*schema.Message -> map[string]any，合并各消息内容中的JSON对象
+	ConditionOfHasToolCall = "has_tool_call" // *schema.Message流，有工具调用时返回"true"，否则返回"false"
+)
+
+// Condition 根据路由创建分支，路由的key为条件的返回值，value为节点key
+type Condition func(routes map[string]string) *compose.GraphBranch
+
+// NewCondition 非流式的分支条件，返回值为路由的key
+func NewCondition[T any](cond func(ctx context.Context, input T) (string, error)) Condition {
+	return func(routes map[string]string) *compose.GraphBranch {
+		return compose.NewGraphBranch(func(ctx context.Context, input T) (string, error) {
+			route, err := cond(ctx, input)
+			if err != nil {
+				return "", err
+			}
+			return routeTo(routes, route)
+		}, endNodes(routes))
+	}
+}
+
+// NewStreamCondition 流式的分支条件，返回值为路由的key
+func NewStreamCondition[T any](cond func(ctx context.Context, input *schema.StreamReader[T]) (string, error)) Condition {
+	return func(routes map[string]string) *compose.GraphBranch {
+		return compose.NewStreamGraphBranch(func(ctx context.Context, input *schema.StreamReader[T]) (string, error) {
+			route, err := cond(ctx, input)
+			if err != nil {
+				return "", err
+			}
+			return routeTo(routes, route)
+		}, endNodes(routes))
+	}
+}
+
+// Registry 管理图定义中可以引用的lambda和分支条件
+type Registry interface {
+	RegisterLambda(name string, lambda *compose.Lambda) error
+	RegisterCondition(name string, cond Condition) error
+	GetLambda(name string) (*compose.Lambda, error)
+	GetCondition(name string) (Condition, error)
+}
+
+type registry struct {
+	lock       sync.RWMutex
+	lambdas    map[string]*compose.Lambda
+	conditions map[string]Condition
+}
+
+func NewRegistry() Registry {
+	return &registry{
+		lambdas: map[string]*compose.Lambda{
+			LambdaOfParseJSON: compose.InvokableLambda(parseJSON),
+		},
+		conditions: map[string]Condition{
+			ConditionOfHasToolCall: NewStreamCondition(hasToolCall),
+		},
+	}
+}
+
+func (r *registry) RegisterLambda(name string, lambda *compose.Lambda) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, found := r.lambdas[name]; found {
+		return fmt.Errorf("[RegisterLambda] lambda already registered, name=%s", name)
+	}
+	r.lambdas[name] = lambda
+	return nil
+}
+
+func (r *registry) RegisterCondition(name string, cond Condition) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, found := r.conditions[name]; found {
+		return fmt.Errorf("[RegisterCondition] condition already registered, name=%s", name)
+	}
+	r.conditions[name] = cond
+	return nil
+}
+
+func (r *registry) GetLambda(name string) (*compose.Lambda, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	lambda, found := r.lambdas[name]
+	if !found {
+		return nil, fmt.Errorf("[GetLambda] lambda not found, name=%s", name)
+	}
+	return lambda, nil
+}
+
+func (r *registry) GetCondition(name string) (Condition, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	cond, found := r.conditions[name]
+	if !found {
+		return nil, fmt.Errorf("[GetCondition] condition not found, name=%s", name)
+	}
+	return cond, nil
+}
+
+func routeTo(routes map[string]string, route string) (string, error) {
+	to, found := routes[route]
+	if !found {
+		return "", fmt.Errorf("route '%s' not found", route)
+	}
+	return to, nil
+}
+
+func endNodes(routes map[string]string) map[string]bool {
+	ends := make(map[string]bool, len(routes))
+	for _, to := range routes {
+		ends[to] = true
+	}
+	return ends
+}
+
+func parseJSON(_ context.Context, input []*schema.Message) (map[string]any, error) {
+	output := make(map[string]any)
+	for _, msg := range input {
+		if err := json.Unmarshal([]byte(msg.Content), &output); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// hasToolCall 检查整个流，部分模型不会在第一个chunk中返回工具调用
+func hasToolCall(_ context.Context, sr *schema.StreamReader[*schema.Message]) (string, error) {
+	defer sr.Close()
+	for {
+		msg, err := sr.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "false", nil
+			}
+			return "", err
+		}
+		if len(msg.ToolCalls) > 0 {
+			return "true", nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Registry 管理可以被智能体定义引用的知识库，key为知识库名称
+type Registry interface {
+	Register(name string, r retriever.Retriever) error
+	Exist(name string) bool
+	GetRetriever(name string) (retriever.Retriever, error)
+	Retrieve(ctx context.Context, names []string, query string) ([]*schema.Document, error)
+}
+
+type registry struct {
+	lock       sync.RWMutex
+	retrievers map[string]retriever.Retriever
+}
+
+func NewRegistry() Registry {
+	return &registry{
+		retrievers: make(map[string]retriever.Retriever),
+	}
+}
+
+func (r *registry) Register(name string, rt retriever.Retriever) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, found := r.retrievers[name]; found {
+		return fmt.Errorf("[Register] knowledge base already registered, name=%s", name)
+	}
+	r.retrievers[name] = rt
+	return nil
+}
+
+func (r *registry) Exist(name string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	_, found := r.retrievers[name]
+	return found
+}
+
+func (r *registry) GetRetriever(name string) (retriever.Retriever, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	rt, found := r.retrievers[name]
+	if !found {
+		return nil, fmt.Errorf("[GetRetriever] knowledge base not found, name=%s", name)
+	}
+	return rt, nil
+}
+
+func (r *registry) Retrieve(ctx context.Context, names []string, query string) ([]*schema.Document, error) {
+	var docs []*schema.Document
+	for _, name := range names {
+		r.lock.RLock()
+		rt, found := r.retrievers[name]
+		r.lock.RUnlock()
+		if !found {
+			return nil, fmt.Errorf("[Retrieve] knowledge base not found, name=%s", name)
+		}
+
+		recall, err := rt.Retrieve(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, recall...)
+	}
+	return docs, nil
+}
@@ -4,12 +4,21 @@ import (
 	"time"
 
 	"github.com/cloudwego/eino/components/model"
+	"github.com/eino-contrib/jsonschema"
 )
 
 type Config struct {
 	BaseURL string
 	Model   string
 	Timeout time.Duration
+	// Thinking 是否开启思考模式，为空时使用模型默认行为
+	Thinking *bool
+	// JSONOutput 是否要求模型输出JSON
+	JSONOutput bool
+	// ResponseSchema 回答需要符合的JSON Schema，支持的模型按该格式输出，JSONOutput优先
+	ResponseSchema *jsonschema.Schema
+	// Vision 模型是否支持图片输入
+	Vision bool
 }
 
 //go:generate mockgen -destination ../../internal/mock/model/factory_mock.go -package chatmodel -source factory.go
@@ -1,25 +1,51 @@
 package chatmodel
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
 	"time"
 
 	golocalv1 "github.com/caiflower/common-tools/pkg/golocal/v1"
 	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
 	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
 	"github.com/ollama/ollama/api"
 )
 
 func ollamaBuilder(config *Config) (model.ToolCallingChatModel, error) {
 	keepalive := 60 * time.Second
+	var thinking *api.ThinkValue
+	if config.Thinking != nil {
+		thinking = &api.ThinkValue{Value: *config.Thinking}
+	}
+	var format json.RawMessage
+	if config.JSONOutput {
+		format = json.RawMessage(`"json"`)
+	} else if config.ResponseSchema != nil {
+		// ollama的format支持直接传入JSON Schema
+		data, err := json.Marshal(config.ResponseSchema)
+		if err != nil {
+			return nil, err
+		}
+		format = data
+	}
+
 	m, err := ollama.NewChatModel(golocalv1.GetContext(), &ollama.ChatModelConfig{
 		// 基础配置
 		BaseURL: config.BaseURL, // Ollama 服务地址
 		Timeout: config.Timeout, // 请求超时时间
 
 		// 模型配置
-		Model: config.Model, // 模型名称
-		//Format:    json.RawMessage(`"json"`), // 输出格式（可选）
-		KeepAlive: &keepalive, // 保持连接时间
+		Model:     config.Model, // 模型名称
+		Format:    format,       // 输出格式
+		KeepAlive: &keepalive,   // 保持连接时间
+		Thinking:  thinking,     // 思考模式
 
 		// 模型参数
 		Options: &api.Options{
@@ -37,6 +63,150 @@ func ollamaBuilder(config *Config) (model.ToolCallingChatModel, error) {
 			RepeatPenalty: 1.1,        // 重复惩罚
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ollamaChatModel{ToolCallingChatModel: m, config: &model.Config{Model: config.Model}}, nil
+}
+
+// ollamaChatModel ollama的图片为原始数据，发送前把data URL形式的图片解码；
+// ollama的工具调用没有ID，返回前补上，回调由这里触发以保证回调和返回的ID一致
+type ollamaChatModel struct {
+	model.ToolCallingChatModel
+	config *model.Config
+	tools  []*schema.ToolInfo
+}
+
+func (m *ollamaChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (msg *schema.Message, err error) {
+	ctx = callbacks.EnsureRunInfo(ctx, m.GetType(), components.ComponentOfChatModel)
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input, Tools: m.tools, Config: m.config})
+	defer func() {
+		if err != nil {
+			_ = callbacks.OnError(ctx, err)
+		}
+	}()
+
+	msg, err = m.ToolCallingChatModel.Generate(withoutCallbacks(ctx), decodeImages(input), opts...)
+	if err != nil {
+		return nil, err
+	}
+	msg = assignToolCallIDs(msg)
+
+	output := &model.CallbackOutput{Message: msg, Config: m.config}
+	if msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+		usage := msg.ResponseMeta.Usage
+		output.TokenUsage = &model.TokenUsage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}
+	}
+	_ = callbacks.OnEnd(ctx, output)
+	return msg, nil
+}
+
+func (m *ollamaChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (sr *schema.StreamReader[*schema.Message], err error) {
+	ctx = callbacks.EnsureRunInfo(ctx, m.GetType(), components.ComponentOfChatModel)
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input, Tools: m.tools, Config: m.config})
+	defer func() {
+		if err != nil {
+			_ = callbacks.OnError(ctx, err)
+		}
+	}()
+
+	sr, err = m.ToolCallingChatModel.Stream(withoutCallbacks(ctx), decodeImages(input), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// ollama流式输出的工具调用在同一个分片中完整返回，按分片补ID即可
+	output := schema.StreamReaderWithConvert(sr, func(msg *schema.Message) (*model.CallbackOutput, error) {
+		return &model.CallbackOutput{Message: assignToolCallIDs(msg), Config: m.config}, nil
+	})
+	_, output = callbacks.OnEndWithStreamOutput(ctx, output)
+	return schema.StreamReaderWithConvert(output, func(out *model.CallbackOutput) (*schema.Message, error) {
+		if out.Message == nil {
+			return nil, schema.ErrNoValue
+		}
+		return out.Message, nil
+	}), nil
+}
+
+func (m *ollamaChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	withTools, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaChatModel{ToolCallingChatModel: withTools, config: m.config, tools: tools}, nil
+}
+
+func (m *ollamaChatModel) GetType() string {
+	typ, _ := components.GetType(m.ToolCallingChatModel)
+	return typ
+}
+
+// IsCallbacksEnabled 回调由ollamaChatModel触发，图不再为其注入回调
+func (m *ollamaChatModel) IsCallbacksEnabled() bool {
+	return true
+}
+
+// withoutCallbacks 内部模型不再触发回调，避免回调中的工具调用没有ID
+func withoutCallbacks(ctx context.Context) context.Context {
+	return callbacks.InitCallbacks(ctx, nil)
+}
+
+// assignToolCallIDs 为没有ID的工具调用生成ID，不修改原消息
+func assignToolCallIDs(msg *schema.Message) *schema.Message {
+	if msg == nil || !slices.ContainsFunc(msg.ToolCalls, func(call schema.ToolCall) bool { return call.ID == "" }) {
+		return msg
+	}
+	assigned := *msg
+	assigned.ToolCalls = append([]schema.ToolCall(nil), msg.ToolCalls...)
+	for i := range assigned.ToolCalls {
+		if assigned.ToolCalls[i].ID == "" {
+			assigned.ToolCalls[i].ID = "call_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+		}
+	}
+	return &assigned
+}
 
-	return m, err
+// decodeImages 返回图片解码后的消息，不修改原消息
+func decodeImages(input []*schema.Message) []*schema.Message {
+	var res []*schema.Message
+	for i, msg := range input {
+		var parts []schema.ChatMessagePart
+		for j, part := range msg.MultiContent {
+			if part.Type != schema.ChatMessagePartTypeImageURL || part.ImageURL == nil || !strings.HasPrefix(part.ImageURL.URL, "data:") {
+				continue
+			}
+			_, encoded, found := strings.Cut(part.ImageURL.URL, ";base64,")
+			if !found {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				continue
+			}
+			if parts == nil {
+				parts = append([]schema.ChatMessagePart(nil), msg.MultiContent...)
+			}
+			imageURL := *part.ImageURL
+			imageURL.URL = string(data)
+			parts[j].ImageURL = &imageURL
+		}
+		if parts == nil {
+			continue
+		}
+		if res == nil {
+			res = append([]*schema.Message(nil), input...)
+		}
+		decoded := *msg
+		decoded.MultiContent = parts
+		res[i] = &decoded
+	}
+	if res == nil {
+		return input
+	}
+	return res
 }
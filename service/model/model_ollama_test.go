@@ -0,0 +1,77 @@
+package chatmodel
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// toolCallChatModel 与ollama一样返回没有ID的工具调用
+type toolCallChatModel struct {
+	model.ToolCallingChatModel
+}
+
+func (m *toolCallChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage("", []schema.ToolCall{
+		{Type: "function", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"beijing"}`}},
+		{Type: "function", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"shanghai"}`}},
+	}), nil
+}
+
+func (m *toolCallChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, _ := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func TestOllamaToolCallID(t *testing.T) {
+	var callbackMsgs []*schema.Message
+	handler := callbacks.NewHandlerBuilder().
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			callbackMsgs = append(callbackMsgs, model.ConvCallbackOutput(output).Message)
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			defer output.Close()
+			for {
+				chunk, err := output.Recv()
+				if err != nil {
+					assert.Equal(t, io.EOF, err)
+					return ctx
+				}
+				callbackMsgs = append(callbackMsgs, model.ConvCallbackOutput(chunk).Message)
+			}
+		}).
+		Build()
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{Name: "chat_model_node"}, handler)
+	m := &ollamaChatModel{ToolCallingChatModel: &toolCallChatModel{}, config: &model.Config{}}
+
+	assertIDs := func(msg *schema.Message) {
+		if assert.Len(t, msg.ToolCalls, 2) {
+			assert.NotEmpty(t, msg.ToolCalls[0].ID)
+			assert.NotEmpty(t, msg.ToolCalls[1].ID)
+			assert.NotEqual(t, msg.ToolCalls[0].ID, msg.ToolCalls[1].ID)
+		}
+	}
+
+	msg, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("北京和上海的天气")})
+	assert.Nil(t, err)
+	assertIDs(msg)
+	if assert.Len(t, callbackMsgs, 1) {
+		assert.Equal(t, msg.ToolCalls, callbackMsgs[0].ToolCalls)
+	}
+
+	callbackMsgs = nil
+	sr, err := m.Stream(ctx, []*schema.Message{schema.UserMessage("北京和上海的天气")})
+	assert.Nil(t, err)
+	msg, err = schema.ConcatMessageStream(sr)
+	assert.Nil(t, err)
+	assertIDs(msg)
+	if assert.Len(t, callbackMsgs, 1) {
+		assert.Equal(t, msg.ToolCalls, callbackMsgs[0].ToolCalls)
+	}
+}
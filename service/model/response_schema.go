@@ -0,0 +1,79 @@
+package chatmodel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+var ErrJSONNotFound = errors.New("json not found in model output")
+
+// CompileSchema 转换为用于校验的OpenAPI Schema，不合法的JSON Schema在保存定义或发起对话时拒绝
+func CompileSchema(s *jsonschema.Schema) (*openapi3.Schema, error) {
+	if s == nil {
+		return nil, errors.New("schema is empty")
+	}
+	sc, err := schema.NewParamsOneOfByJSONSchema(s).ToOpenAPIV3()
+	if err != nil {
+		return nil, err
+	}
+	if err = sc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// ValidateJSON 解析模型输出的JSON并按Schema校验，兼容代码块包裹，返回压缩后的JSON
+func ValidateJSON(s *openapi3.Schema, content string) (json.RawMessage, error) {
+	data, err := extractJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	if err = json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	if err = s.VisitJSON(value); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err = json.Compact(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// extractJSON 整个输出是JSON时直接使用，否则取第一个{或[到最后一个}或]之间的内容
+func extractJSON(content string) ([]byte, error) {
+	content = strings.TrimSpace(content)
+	if json.Valid([]byte(content)) {
+		return []byte(content), nil
+	}
+
+	start := strings.IndexAny(content, "{[")
+	if start < 0 {
+		return nil, ErrJSONNotFound
+	}
+	closing := "}"
+	if content[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(content, closing)
+	if end < start {
+		return nil, ErrJSONNotFound
+	}
+	data := []byte(content[start : end+1])
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%w: invalid json", ErrJSONNotFound)
+	}
+	return data, nil
+}
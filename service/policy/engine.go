@@ -0,0 +1,193 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 策略的判定结果
+const (
+	ActionAllow   = "allow"   // 直接执行
+	ActionDeny    = "deny"    // 拒绝，原因作为工具消息返回给模型
+	ActionApprove = "approve" // 中断运行，等待用户确认
+)
+
+// ExtraKeyOfRisk 工具在Info.Extra中声明风险等级
+const ExtraKeyOfRisk = "risk"
+
+// RuleOfApprovalTools 智能体定义中的需要确认的工具，优先于策略规则
+const RuleOfApprovalTools = "agent.approvalTools"
+
+// ToolCall 待判定的工具调用
+type ToolCall struct {
+	RunID      string
+	ToolCallID string
+	User       string
+	AgentID    string
+	Tool       string
+	Risk       string
+	Arguments  string
+}
+
+type Decision struct {
+	Action  string
+	Rule    string // 命中的规则，为空时为默认策略
+	Message string // 拒绝时返回给模型的原因
+}
+
+//go:generate mockgen -destination ../../internal/mock/policy/engine_mock.go -package policy -source engine.go
+type Engine interface {
+	// Evaluate 按规则判定工具调用，并记录审计日志
+	Evaluate(call *ToolCall) *Decision
+	// Record 记录已经做出的决定，例如智能体定义要求确认的工具和用户的确认
+	Record(call *ToolCall, source string, decision *Decision)
+	// RecordUserDecision 记录用户对工具调用的确认
+	RecordUserDecision(call *ToolCall, decision *entity.ToolDecision)
+}
+
+type engine struct {
+	ToolAuditLogDao dao.ToolAuditLogDao `autowired:""`
+
+	patterns sync.Map // 参数的正则表达式，key为表达式
+}
+
+func NewEngine() Engine {
+	return &engine{}
+}
+
+// Risk 工具的风险等级，工具声明的优先于配置
+func Risk(info *schema.ToolInfo) string {
+	if risk, ok := info.Extra[ExtraKeyOfRisk].(string); ok && risk != "" {
+		return risk
+	}
+	return constants.Prop.ToolPolicy.Risks[info.Name]
+}
+
+func (e *engine) Evaluate(call *ToolCall) *Decision {
+	decision := e.evaluate(call)
+	e.Record(call, bean.AuditSourcePolicy, decision)
+	return decision
+}
+
+func (e *engine) evaluate(call *ToolCall) *Decision {
+	cfg := &constants.Prop.ToolPolicy
+	for _, rule := range cfg.Rules {
+		if e.match(&rule, call) {
+			return newDecision(rule.Action, rule.Name, rule.Message)
+		}
+	}
+	return newDecision(cfg.Default, "", "")
+}
+
+func newDecision(action, rule, message string) *Decision {
+	switch action {
+	case ActionAllow, ActionApprove:
+	case "":
+		action = ActionAllow
+	case ActionDeny:
+		if message == "" {
+			message = "The tool call was denied by policy."
+		}
+	default:
+		// 配置错误时拒绝执行
+		logger.Error("unknown tool policy action. Rule: %s, Action: %s", rule, action)
+		action, message = ActionDeny, "The tool call was denied by policy."
+	}
+	return &Decision{Action: action, Rule: rule, Message: message}
+}
+
+func (e *engine) match(rule *constants.ToolPolicyRule, call *ToolCall) bool {
+	if len(rule.Tools) > 0 && !slices.Contains(rule.Tools, call.Tool) {
+		return false
+	}
+	if len(rule.Risks) > 0 && !slices.Contains(rule.Risks, call.Risk) {
+		return false
+	}
+	if len(rule.Users) > 0 || len(rule.Groups) > 0 {
+		if !slices.Contains(rule.Users, call.User) && !inGroups(rule.Groups, call.User) {
+			return false
+		}
+	}
+	if len(rule.Arguments) == 0 {
+		return true
+	}
+
+	var arguments map[string]any
+	if err := json.Unmarshal([]byte(call.Arguments), &arguments); err != nil {
+		return false
+	}
+	for name, expr := range rule.Arguments {
+		value, found := arguments[name]
+		if !found {
+			return false
+		}
+		pattern, err := e.compile(expr)
+		if err != nil {
+			logger.Error("compile tool policy pattern failed. Rule: %s, Pattern: %s, Error: %v", rule.Name, expr, err)
+			return false
+		}
+		if !pattern.MatchString(fmt.Sprint(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *engine) compile(expr string) (*regexp.Regexp, error) {
+	if v, found := e.patterns.Load(expr); found {
+		return v.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	e.patterns.Store(expr, pattern)
+	return pattern, nil
+}
+
+func inGroups(groups []string, user string) bool {
+	for _, group := range groups {
+		if slices.Contains(constants.Prop.ToolPolicy.Groups[group], user) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *engine) Record(call *ToolCall, source string, decision *Decision) {
+	e.insert(call, source, decision.Rule, decision.Action, decision.Message)
+}
+
+func (e *engine) RecordUserDecision(call *ToolCall, decision *entity.ToolDecision) {
+	e.insert(call, bean.AuditSourceUser, "", string(decision.Action), decision.Reason)
+}
+
+// insert 审计日志写入失败不影响工具调用
+func (e *engine) insert(call *ToolCall, source, rule, action, reason string) {
+	err := e.ToolAuditLogDao.Insert(&bean.ToolAuditLog{
+		RunID:      call.RunID,
+		ToolCallID: call.ToolCallID,
+		User:       call.User,
+		AgentID:    call.AgentID,
+		Tool:       call.Tool,
+		Risk:       call.Risk,
+		Arguments:  call.Arguments,
+		Source:     source,
+		Rule:       rule,
+		Action:     action,
+		Reason:     reason,
+	})
+	if err != nil {
+		logger.Error("insert tool audit log failed. RunID: %s, Tool: %s, Error: %v", call.RunID, call.Tool, err)
+	}
+}
@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/caiflower/ai-agent/constants"
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/ai-agent/model/entity"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEvaluate(t *testing.T) {
+	toolPolicy := constants.Prop.ToolPolicy
+	constants.Prop.ToolPolicy = constants.ToolPolicyConfig{
+		Default: ActionAllow,
+		Risks:   map[string]string{"delete_order": "high"},
+		Groups:  map[string][]string{"ops": {"alice"}},
+		Rules: []constants.ToolPolicyRule{
+			{Name: "ops-delete", Tools: []string{"delete_order"}, Groups: []string{"ops"}, Action: ActionApprove},
+			{Name: "high-risk", Risks: []string{"high"}, Action: ActionDeny, Message: "only ops can delete orders"},
+			{Name: "large-refund", Tools: []string{"refund"}, Arguments: map[string]string{"amount": `^\d{4,}$`}, Action: ActionApprove},
+			{Name: "typo", Tools: []string{"transfer"}, Action: "alow"},
+		},
+	}
+	defer func() { constants.Prop.ToolPolicy = toolPolicy }()
+
+	ctl := gomock.NewController(t)
+	auditDao := mockdao.NewMockToolAuditLogDao(ctl)
+	var logs []*bean.ToolAuditLog
+	auditDao.EXPECT().Insert(gomock.Any()).DoAndReturn(func(log *bean.ToolAuditLog) error {
+		logs = append(logs, log)
+		return nil
+	}).AnyTimes()
+	e := &engine{ToolAuditLogDao: auditDao}
+
+	risk := Risk(&schema.ToolInfo{Name: "delete_order"})
+	assert.Equal(t, "high", risk)
+	assert.Equal(t, "low", Risk(&schema.ToolInfo{Name: "delete_order", Extra: map[string]any{ExtraKeyOfRisk: "low"}}))
+
+	cases := []struct {
+		call   *ToolCall
+		action string
+		rule   string
+	}{
+		{&ToolCall{User: "alice", Tool: "delete_order", Risk: risk}, ActionApprove, "ops-delete"},
+		{&ToolCall{User: "bob", Tool: "delete_order", Risk: risk}, ActionDeny, "high-risk"},
+		{&ToolCall{User: "bob", Tool: "refund", Arguments: `{"amount": 5000}`}, ActionApprove, "large-refund"},
+		{&ToolCall{User: "bob", Tool: "refund", Arguments: `{"amount": 50}`}, ActionAllow, ""},
+		{&ToolCall{User: "bob", Tool: "refund", Arguments: `{}`}, ActionAllow, ""},
+		{&ToolCall{User: "bob", Tool: "transfer"}, ActionDeny, "typo"},
+	}
+	for _, c := range cases {
+		decision := e.Evaluate(c.call)
+		assert.Equal(t, c.action, decision.Action, c.call.Tool)
+		assert.Equal(t, c.rule, decision.Rule, c.call.Tool)
+	}
+	assert.Equal(t, "only ops can delete orders", logs[1].Reason)
+
+	e.RecordUserDecision(&ToolCall{User: "alice", Tool: "delete_order"}, &entity.ToolDecision{Action: entity.DecisionActionReject, Reason: "wrong order"})
+	assert.Len(t, logs, len(cases)+1)
+	last := logs[len(logs)-1]
+	assert.Equal(t, bean.AuditSourceUser, last.Source)
+	assert.Equal(t, "reject", last.Action)
+	assert.Equal(t, "wrong order", last.Reason)
+}
@@ -0,0 +1,149 @@
+package prompttpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("prompt template not found")
+	ErrInvalidTemplate  = errors.New("invalid prompt template")
+	ErrVersionConflict  = errors.New("prompt template version conflict")
+)
+
+// maxCreateAttempts 并发保存同一模板时版本号冲突，重新分配版本号的次数
+const maxCreateAttempts = 3
+
+var placeholderReg = regexp.MustCompile(`\{\{-?\s*([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+//go:generate mockgen -destination ../../internal/mock/prompt/manager_mock.go -package prompttpl -source manager.go
+type Manager interface {
+	// GetActive 获取生效的版本，没有保存过版本时返回内置模板
+	GetActive(name string) (*bean.PromptTemplate, error)
+	// GetVersion 获取指定版本，version=0为内置模板
+	GetVersion(name string, version int) (*bean.PromptTemplate, error)
+	ListVersions(name string) ([]*bean.PromptTemplate, error)
+	// Create 校验并保存为新版本，新版本立即生效，并发保存多次冲突时返回ErrVersionConflict
+	Create(tpl *bean.PromptTemplate) error
+	// Rollback 将指定版本设置为生效版本
+	Rollback(name string, version int) error
+	Validate(content string) error
+}
+
+type manager struct {
+	PromptTemplateDao dao.PromptTemplateDao `autowired:""`
+}
+
+func NewManager() Manager {
+	return &manager{}
+}
+
+func (m *manager) GetActive(name string) (*bean.PromptTemplate, error) {
+	tpl, err := m.PromptTemplateDao.GetActive(name)
+	if err != nil {
+		return nil, err
+	}
+	if tpl != nil {
+		return tpl, nil
+	}
+
+	return getBuiltin(name)
+}
+
+func (m *manager) GetVersion(name string, version int) (*bean.PromptTemplate, error) {
+	if version == 0 {
+		return getBuiltin(name)
+	}
+
+	tpl, err := m.PromptTemplateDao.GetVersion(name, version)
+	if err != nil {
+		return nil, err
+	}
+	if tpl == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return tpl, nil
+}
+
+func (m *manager) ListVersions(name string) ([]*bean.PromptTemplate, error) {
+	return m.PromptTemplateDao.ListVersions(name)
+}
+
+func (m *manager) Create(tpl *bean.PromptTemplate) error {
+	if err := m.Validate(tpl.Content); err != nil {
+		return err
+	}
+
+	for i := 0; i < maxCreateAttempts; i++ {
+		latest, err := m.PromptTemplateDao.GetLatestVersion(tpl.Name)
+		if err != nil {
+			return err
+		}
+
+		// 其他请求同时保存了相同的版本号，重新分配
+		tpl.Version = latest + 1
+		if err = m.PromptTemplateDao.InsertAndActivate(tpl); !errors.Is(err, dao.ErrDuplicateKey) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: name=%s", ErrVersionConflict, tpl.Name)
+}
+
+func (m *manager) Rollback(name string, version int) error {
+	if _, err := m.GetVersion(name, version); err != nil {
+		return err
+	}
+
+	// 回滚到内置模板时，所有保存的版本都失效
+	rows, err := m.PromptTemplateDao.Activate(name, version)
+	if err != nil {
+		return err
+	}
+	if rows == 0 && version != 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+func (m *manager) Validate(content string) error {
+	if content == "" {
+		return fmt.Errorf("%w: content is empty", ErrInvalidTemplate)
+	}
+
+	variables := make(map[string]any)
+	for _, placeholder := range KnownPlaceholders {
+		variables[placeholder] = ""
+	}
+
+	for _, match := range placeholderReg.FindAllStringSubmatch(content, -1) {
+		if _, found := variables[match[1]]; !found {
+			return fmt.Errorf("%w: unknown placeholder '%s', allowed placeholders are %v", ErrInvalidTemplate, match[1], KnownPlaceholders)
+		}
+	}
+
+	if _, err := prompt.FromMessages(schema.Jinja2, schema.SystemMessage(content)).Format(context.Background(), variables); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTemplate, err.Error())
+	}
+	return nil
+}
+
+func getBuiltin(name string) (*bean.PromptTemplate, error) {
+	content, found := builtinTemplates[name]
+	if !found {
+		return nil, ErrTemplateNotFound
+	}
+
+	return &bean.PromptTemplate{
+		Name:    name,
+		Content: content,
+		Author:  "builtin",
+		Active:  true,
+	}, nil
+}
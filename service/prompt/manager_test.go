@@ -0,0 +1,54 @@
+package prompttpl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/caiflower/ai-agent/dao"
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestValidate(t *testing.T) {
+	m := NewManager()
+
+	assert.Nil(t, m.Validate(ReactSystemPromptJinja2))
+	assert.Nil(t, m.Validate("你是{{ agent_name }}。{{persona}}"))
+	assert.Nil(t, m.Validate("{% if knowledge %}{{ knowledge }}{% endif %}"))
+
+	err := m.Validate("")
+	assert.True(t, errors.Is(err, ErrInvalidTemplate))
+
+	err = m.Validate("你是{{ agent_nmae }}")
+	assert.True(t, errors.Is(err, ErrInvalidTemplate))
+	assert.Contains(t, err.Error(), "agent_nmae")
+
+	err = m.Validate("{% if persona %}{{ persona }}")
+	assert.True(t, errors.Is(err, ErrInvalidTemplate))
+}
+
+// TestCreateVersionConflict 并发保存时版本号冲突，重新分配版本号，多次冲突后返回ErrVersionConflict
+func TestCreateVersionConflict(t *testing.T) {
+	ctl := gomock.NewController(t)
+	promptTemplateDao := mockdao.NewMockPromptTemplateDao(ctl)
+	m := &manager{PromptTemplateDao: promptTemplateDao}
+	duplicate := fmt.Errorf("%w: uk_name_version", dao.ErrDuplicateKey)
+
+	gomock.InOrder(
+		promptTemplateDao.EXPECT().GetLatestVersion(TemplateOfReactSystem).Return(1, nil),
+		promptTemplateDao.EXPECT().InsertAndActivate(gomock.Any()).Return(duplicate),
+		promptTemplateDao.EXPECT().GetLatestVersion(TemplateOfReactSystem).Return(2, nil),
+		promptTemplateDao.EXPECT().InsertAndActivate(gomock.Any()).Return(nil),
+	)
+	tpl := &bean.PromptTemplate{Name: TemplateOfReactSystem, Content: ReactSystemPromptJinja2}
+	assert.Nil(t, m.Create(tpl))
+	assert.Equal(t, 3, tpl.Version)
+
+	promptTemplateDao.EXPECT().GetLatestVersion(TemplateOfReactSystem).Return(3, nil).Times(maxCreateAttempts)
+	promptTemplateDao.EXPECT().InsertAndActivate(gomock.Any()).Return(duplicate).Times(maxCreateAttempts)
+	err := m.Create(&bean.PromptTemplate{Name: TemplateOfReactSystem, Content: ReactSystemPromptJinja2})
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+}
@@ -0,0 +1,20 @@
+package toolkit
+
+import "context"
+
+// ProgressReporter 上报工具执行的进度或部分输出
+type ProgressReporter func(content string)
+
+type progressReporterKey struct{}
+
+// WithProgressReporter 工具执行前由回调设置，工具通过ReportProgress上报
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+// ReportProgress 长时间运行的工具上报进度，不在智能体中运行时忽略
+func ReportProgress(ctx context.Context, content string) {
+	if reporter, ok := ctx.Value(progressReporterKey{}).(ProgressReporter); ok && content != "" {
+		reporter(content)
+	}
+}
@@ -0,0 +1,64 @@
+package toolkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Registry 管理可以被智能体定义引用的工具，key为工具名称
+type Registry interface {
+	Register(t tool.BaseTool) error
+	Exist(name string) bool
+	GetTools(names []string) ([]tool.BaseTool, error)
+}
+
+type registry struct {
+	lock  sync.RWMutex
+	tools map[string]tool.BaseTool
+}
+
+func NewRegistry() Registry {
+	return &registry{
+		tools: make(map[string]tool.BaseTool),
+	}
+}
+
+func (r *registry) Register(t tool.BaseTool) error {
+	info, err := t.Info(context.Background())
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, found := r.tools[info.Name]; found {
+		return fmt.Errorf("[Register] tool already registered, name=%s", info.Name)
+	}
+	r.tools[info.Name] = t
+	return nil
+}
+
+func (r *registry) Exist(name string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	_, found := r.tools[name]
+	return found
+}
+
+func (r *registry) GetTools(names []string) ([]tool.BaseTool, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	tools := make([]tool.BaseTool, 0, len(names))
+	for _, name := range names {
+		t, found := r.tools[name]
+		if !found {
+			return nil, fmt.Errorf("[GetTools] tool not found, name=%s", name)
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
@@ -0,0 +1,90 @@
+package xsse
+
+import (
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/ai-agent/dao"
+	"github.com/caiflower/ai-agent/model/bean"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/tmaxmax/go-sse"
+)
+
+const dbGCInterval = 10 * time.Minute
+
+// dbStore 数据库存储，每个事件一条记录，适合没有redis的多实例部署
+type dbStore struct {
+	ChatEventDao dao.ChatEventDao `autowired:""`
+	lock         sync.Mutex
+	lastGC       time.Time
+}
+
+func NewDBStore() Store {
+	return &dbStore{}
+}
+
+func (s *dbStore) Append(requestID string, msg *sse.Message) error {
+	runID, seq, err := parseSeq(msg)
+	if err != nil {
+		return err
+	}
+	s.tryGC()
+
+	return s.ChatEventDao.Insert(&bean.ChatEvent{
+		RequestID:  requestID,
+		RunID:      runID,
+		Seq:        seq,
+		Data:       msg.String(),
+		ExpireTime: time.Now().Add(constants.Prop.SSE.ReplayWindow),
+	})
+}
+
+func (s *dbStore) Range(runID string, seq int64) ([]*sse.Message, error) {
+	events, err := s.ChatEventDao.ListByRunID(runID, seq)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]*sse.Message, 0, len(events))
+	for _, event := range events {
+		msg, err := parseMessage(event.Data)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+func (s *dbStore) Lookup(requestID string) (string, error) {
+	event, err := s.ChatEventDao.GetFirstByRequestID(requestID)
+	if err != nil || event == nil {
+		return "", err
+	}
+	return FormatEventID(event.RunID, event.Seq), nil
+}
+
+func (s *dbStore) LastSeq(runID string) (int64, error) {
+	event, err := s.ChatEventDao.GetLastByRunID(runID)
+	if err != nil || event == nil {
+		return 0, err
+	}
+	return event.Seq, nil
+}
+
+// tryGC 每隔dbGCInterval清理一次过期的事件
+func (s *dbStore) tryGC() {
+	now := time.Now()
+	s.lock.Lock()
+	if now.Sub(s.lastGC) < dbGCInterval {
+		s.lock.Unlock()
+		return
+	}
+	s.lastGC = now
+	s.lock.Unlock()
+
+	if _, err := s.ChatEventDao.DeleteExpired(); err != nil {
+		logger.Warn("delete expired chat events failed. Error: %v", err)
+	}
+}
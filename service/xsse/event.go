@@ -0,0 +1,31 @@
+package xsse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NextSeq 运行的下一个事件序号，大于last且不小于当前时间的纳秒数。
+// last为存储中运行最后一个事件的序号，运行在其他实例上恢复时继续递增；存储中的事件过期后，新的序号仍大于过期事件的序号
+func NextSeq(last int64) int64 {
+	return max(last+1, time.Now().UnixNano())
+}
+
+// FormatEventID 事件ID格式为runID:序号
+func FormatEventID(runID string, seq int64) string {
+	return fmt.Sprintf("%s:%d", runID, seq)
+}
+
+func ParseEventID(id string) (runID string, seq int64, ok bool) {
+	i := strings.LastIndex(id, ":")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseInt(id[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
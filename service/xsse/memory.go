@@ -0,0 +1,116 @@
+package xsse
+
+import (
+	"sync"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/tmaxmax/go-sse"
+)
+
+type memoryEvent struct {
+	seq      int64
+	msg      *sse.Message
+	expireAt time.Time
+}
+
+type memoryRequest struct {
+	startID  string
+	expireAt time.Time
+}
+
+// memoryStore 内存存储，过期的事件在写入时清理
+type memoryStore struct {
+	lock     sync.Mutex
+	runs     map[string][]*memoryEvent
+	requests map[string]*memoryRequest
+	lastGC   time.Time
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{runs: make(map[string][]*memoryEvent), requests: make(map[string]*memoryRequest)}
+}
+
+func (s *memoryStore) Append(requestID string, msg *sse.Message) error {
+	runID, seq, err := parseSeq(msg)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	expireAt := now.Add(constants.Prop.SSE.ReplayWindow)
+	s.tryGC(now)
+	s.runs[runID] = append(s.runs[runID], &memoryEvent{seq: seq, msg: msg, expireAt: expireAt})
+	if requestID != "" {
+		if req, found := s.requests[requestID]; found {
+			req.expireAt = expireAt
+		} else {
+			s.requests[requestID] = &memoryRequest{startID: msg.ID.String(), expireAt: expireAt}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Range(runID string, seq int64) ([]*sse.Message, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	var msgs []*sse.Message
+	for _, event := range s.runs[runID] {
+		if event.seq > seq && now.Before(event.expireAt) {
+			msgs = append(msgs, event.msg)
+		}
+	}
+	return msgs, nil
+}
+
+func (s *memoryStore) Lookup(requestID string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	req, found := s.requests[requestID]
+	if !found || time.Now().After(req.expireAt) {
+		return "", nil
+	}
+	return req.startID, nil
+}
+
+func (s *memoryStore) LastSeq(runID string) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	events := s.runs[runID]
+	if len(events) == 0 {
+		return 0, nil
+	}
+	return events[len(events)-1].seq, nil
+}
+
+// tryGC 每隔半个回放窗口清理一次过期的事件
+func (s *memoryStore) tryGC(now time.Time) {
+	if now.Sub(s.lastGC) < constants.Prop.SSE.ReplayWindow/2 {
+		return
+	}
+	s.lastGC = now
+
+	for runID, events := range s.runs {
+		i := 0
+		for i < len(events) && now.After(events[i].expireAt) {
+			i++
+		}
+		if i == len(events) {
+			delete(s.runs, runID)
+		} else {
+			s.runs[runID] = events[i:]
+		}
+	}
+	for requestID, req := range s.requests {
+		if now.After(req.expireAt) {
+			delete(s.requests, requestID)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package xsse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/tmaxmax/go-sse"
+)
+
+func TestMemoryStoreSeq(t *testing.T) {
+	window := constants.Prop.SSE.ReplayWindow
+	constants.Prop.SSE.ReplayWindow = time.Minute
+	defer func() { constants.Prop.SSE.ReplayWindow = window }()
+	s := NewMemoryStore()
+
+	last, err := s.LastSeq("run")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), last)
+
+	// 运行在其他实例上推送过的序号大于当前时间，例如时钟较快，恢复后从最后一个事件继续递增
+	seq := time.Now().Add(time.Hour).UnixNano()
+	assert.Nil(t, s.Append("request", &sse.Message{ID: sse.ID(FormatEventID("run", seq))}))
+	last, err = s.LastSeq("run")
+	assert.Nil(t, err)
+	assert.Equal(t, seq, last)
+
+	next := NextSeq(last)
+	assert.Equal(t, seq+1, next)
+	assert.Nil(t, s.Append("request", &sse.Message{ID: sse.ID(FormatEventID("run", next))}))
+	msgs, err := s.Range("run", seq)
+	assert.Nil(t, err)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, FormatEventID("run", next), msgs[0].ID.String())
+	}
+
+	// 存储中没有事件时以当前时间为起点
+	assert.GreaterOrEqual(t, NextSeq(0), time.Now().Add(-time.Second).UnixNano())
+}
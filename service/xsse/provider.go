@@ -1,13 +1,114 @@
 package xsse
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/caiflower/common-tools/pkg/safego"
 	"github.com/tmaxmax/go-sse"
 )
 
+// provider 订阅期间没有事件时定时发送注释，避免代理和负载均衡断开空闲的连接
+type provider struct {
+	sse.Provider
+	heartbeat time.Duration
+}
+
+// NewSSEProvider 本实例正在推送的运行在内存中回放，事件保留replayWindow
 func NewSSEProvider() sse.Provider {
-	rp, _ := sse.NewValidReplayer(time.Minute*2, true)
-	rp.GCInterval = time.Minute
-	return &sse.Joe{Replayer: rp}
+	// 事件ID由调用方设置，格式为runID:序号
+	window := constants.Prop.SSE.ReplayWindow
+	if window <= 0 {
+		window = time.Minute * 2
+	}
+	rp, _ := sse.NewValidReplayer(window, false)
+	rp.GCInterval = window / 2
+	return &provider{Provider: &sse.Joe{Replayer: rp}, heartbeat: constants.Prop.SSE.Heartbeat}
+}
+
+func (p *provider) Subscribe(ctx context.Context, sub sse.Subscription) error {
+	if p.heartbeat <= 0 {
+		return p.Provider.Subscribe(ctx, sub)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	client := &heartbeatClient{client: sub.Client, last: time.Now()}
+	sub.Client = client
+	safego.Go(func() {
+		client.run(ctx, cancel, p.heartbeat)
+	})
+	return p.Provider.Subscribe(ctx, sub)
+}
+
+// KeepAlive 不经过provider直接推送时使用，没有事件时定时发送心跳，返回的函数停止发送心跳
+func KeepAlive(client sse.MessageWriter, interval time.Duration) (sse.MessageWriter, context.CancelFunc) {
+	if interval <= 0 {
+		return client, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hc := &heartbeatClient{client: client, last: time.Now()}
+	safego.Go(func() {
+		hc.run(ctx, cancel, interval)
+	})
+	return hc, cancel
+}
+
+// heartbeatClient 心跳和事件在不同的goroutine中写入
+type heartbeatClient struct {
+	lock   sync.Mutex
+	client sse.MessageWriter
+	last   time.Time // 最后一次写入的时间
+}
+
+func (c *heartbeatClient) Send(msg *sse.Message) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.last = time.Now()
+	return c.client.Send(msg)
+}
+
+func (c *heartbeatClient) Flush() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.client.Flush()
+}
+
+// run 距离最后一次写入超过interval时发送心跳，写入失败说明连接已经断开，结束订阅
+func (c *heartbeatClient) run(ctx context.Context, cancel context.CancelFunc, interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.lock.Lock()
+		var err error
+		if time.Since(c.last) >= interval {
+			c.last = time.Now()
+			if err = c.client.Send(Heartbeat()); err == nil {
+				err = c.client.Flush()
+			}
+		}
+		c.lock.Unlock()
+		if err != nil {
+			logger.Warn("xsse send heartbeat failed. Error: %v", err)
+			cancel()
+			return
+		}
+	}
+}
+
+// Heartbeat 只包含注释的消息，客户端不会产生事件
+func Heartbeat() *sse.Message {
+	msg := &sse.Message{}
+	msg.AppendComment("heartbeat")
+	return msg
 }
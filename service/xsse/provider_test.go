@@ -0,0 +1,89 @@
+package xsse
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/tmaxmax/go-sse"
+)
+
+type recordClient struct {
+	lock sync.Mutex
+	msgs []string
+	err  error
+}
+
+func (c *recordClient) Send(msg *sse.Message) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.msgs = append(c.msgs, msg.String())
+	return nil
+}
+
+func (c *recordClient) Flush() error {
+	return nil
+}
+
+func (c *recordClient) sent() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string(nil), c.msgs...)
+}
+
+func TestProviderHeartbeat(t *testing.T) {
+	config := constants.Prop.SSE
+	constants.Prop.SSE.ReplayWindow = time.Minute
+	constants.Prop.SSE.Heartbeat = 50 * time.Millisecond
+	defer func() { constants.Prop.SSE = config }()
+	p := NewSSEProvider()
+	defer p.Shutdown(context.Background())
+
+	// 没有事件时定时发送心跳
+	client := &recordClient{}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, p.Subscribe(ctx, sse.Subscription{Client: client, Topics: []string{"run"}}))
+	sent := client.sent()
+	assert.NotEmpty(t, sent)
+	for _, msg := range sent {
+		assert.True(t, strings.HasPrefix(msg, ": heartbeat"), msg)
+	}
+
+	// 心跳写入失败时结束订阅
+	client = &recordClient{err: errors.New("broken pipe")}
+	start := time.Now()
+	assert.Nil(t, p.Subscribe(context.Background(), sse.Subscription{Client: client, Topics: []string{"run"}}))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestKeepAlive(t *testing.T) {
+	// 没有事件时定时发送心跳，停止后不再发送
+	record := &recordClient{}
+	client, stop := KeepAlive(record, 50*time.Millisecond)
+	assert.Nil(t, client.Send(&sse.Message{ID: sse.ID("run:1")}))
+	time.Sleep(200 * time.Millisecond)
+	stop()
+	sent := record.sent()
+	if assert.Greater(t, len(sent), 1) {
+		assert.Equal(t, "id: run:1\n\n", sent[0])
+		for _, msg := range sent[1:] {
+			assert.True(t, strings.HasPrefix(msg, ": heartbeat"), msg)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, record.sent(), len(sent))
+
+	// interval不大于0时不发送心跳
+	client, stop = KeepAlive(record, 0)
+	defer stop()
+	assert.Same(t, record, client)
+}
@@ -0,0 +1,91 @@
+package xsse
+
+import (
+	"errors"
+
+	"github.com/caiflower/ai-agent/constants"
+	redisv1 "github.com/caiflower/common-tools/redis/v1"
+	"github.com/go-redis/redis/v8"
+	"github.com/tmaxmax/go-sse"
+)
+
+const (
+	redisKeyOfEvents  = "sse:events:"  // 运行的事件列表，按推送顺序追加
+	redisKeyOfRequest = "sse:request:" // 请求推送的第一个事件的ID
+)
+
+// redisStore redis存储，运行的事件在最后一个事件推送后保留replayWindow
+type redisStore struct {
+	RedisClient redisv1.RedisClient `autowired:""`
+}
+
+func NewRedisStore() Store {
+	return &redisStore{}
+}
+
+func (s *redisStore) Append(requestID string, msg *sse.Message) error {
+	runID, _, err := parseSeq(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx := redisv1.GetContext()
+	window := constants.Prop.SSE.ReplayWindow
+	eventsKey := s.RedisClient.GetKey(redisKeyOfEvents + runID)
+	_, err = s.RedisClient.GetRedis().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, eventsKey, msg.String())
+		pipe.Expire(ctx, eventsKey, window)
+		if requestID != "" {
+			requestKey := s.RedisClient.GetKey(redisKeyOfRequest + requestID)
+			pipe.SetNX(ctx, requestKey, msg.ID.String(), window)
+			pipe.Expire(ctx, requestKey, window)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) Range(runID string, seq int64) ([]*sse.Message, error) {
+	data, err := s.RedisClient.GetRedis().LRange(redisv1.GetContext(), s.RedisClient.GetKey(redisKeyOfEvents+runID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []*sse.Message
+	for _, d := range data {
+		msg, err := parseMessage(d)
+		if err != nil {
+			return nil, err
+		}
+		if _, msgSeq, err := parseSeq(msg); err != nil {
+			return nil, err
+		} else if msgSeq > seq {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+func (s *redisStore) LastSeq(runID string) (int64, error) {
+	data, err := s.RedisClient.GetRedis().LIndex(redisv1.GetContext(), s.RedisClient.GetKey(redisKeyOfEvents+runID), -1).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	msg, err := parseMessage(data)
+	if err != nil {
+		return 0, err
+	}
+	_, seq, err := parseSeq(msg)
+	return seq, err
+}
+
+func (s *redisStore) Lookup(requestID string) (string, error) {
+	startID, err := s.RedisClient.GetRedis().Get(redisv1.GetContext(), s.RedisClient.GetKey(redisKeyOfRequest+requestID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return startID, err
+}
@@ -0,0 +1,46 @@
+package xsse
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/caiflower/common-tools/pkg/logger"
+	"github.com/tmaxmax/go-sse"
+)
+
+// Session 升级后的SSE连接，每次写入前重新设置写超时，
+// 推送的总时长不受HTTP服务WriteTimeout的限制，客户端停止读取时写入仍然会超时返回
+type Session struct {
+	*sse.Session
+	rc           *http.ResponseController
+	writeTimeout time.Duration
+}
+
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	sess, err := sse.Upgrade(w, r)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{Session: sess, rc: http.NewResponseController(w), writeTimeout: constants.Prop.SSE.WriteTimeout}, nil
+}
+
+func (s *Session) Send(msg *sse.Message) error {
+	s.extendDeadline()
+	return s.Session.Send(msg)
+}
+
+func (s *Session) Flush() error {
+	s.extendDeadline()
+	return s.Session.Flush()
+}
+
+func (s *Session) extendDeadline() {
+	if s.writeTimeout <= 0 {
+		return
+	}
+	if err := s.rc.SetWriteDeadline(time.Now().Add(s.writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger.Warn("xsse set write deadline failed. Error: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package xsse
+
+import (
+	"fmt"
+
+	"github.com/caiflower/ai-agent/constants"
+	"github.com/tmaxmax/go-sse"
+)
+
+const (
+	StoreOfMemory = "memory"
+	StoreOfRedis  = "redis"
+	StoreOfDB     = "db"
+)
+
+//go:generate mockgen -destination ../../internal/mock/xsse/store_mock.go -package xsse -source store.go
+type Store interface {
+	// Append 保存请求推送的事件，事件ID格式为runID:序号
+	Append(requestID string, msg *sse.Message) error
+	// Range 运行中序号大于seq且未过期的事件，按序号排序
+	Range(runID string, seq int64) ([]*sse.Message, error)
+	// Lookup 请求推送的第一个事件的ID，不存在或已过期时返回空
+	Lookup(requestID string) (string, error)
+	// LastSeq 运行最后一个事件的序号，没有事件时为0
+	LastSeq(runID string) (int64, error)
+}
+
+// NewStore 根据配置创建回放存储，memory存储只能回放本实例推送的事件
+func NewStore() (Store, error) {
+	switch constants.Prop.SSE.ReplayStore {
+	case StoreOfMemory, "":
+		return NewMemoryStore(), nil
+	case StoreOfRedis:
+		return NewRedisStore(), nil
+	case StoreOfDB:
+		return NewDBStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown sse replay store '%s'", constants.Prop.SSE.ReplayStore)
+	}
+}
+
+// Shared 存储在实例之间共享时，可以从存储中读取其他实例正在推送的事件
+func Shared() bool {
+	return constants.Prop.SSE.ReplayStore == StoreOfRedis || constants.Prop.SSE.ReplayStore == StoreOfDB
+}
+
+func parseMessage(data string) (*sse.Message, error) {
+	msg := &sse.Message{}
+	if err := msg.UnmarshalText([]byte(data)); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func parseSeq(msg *sse.Message) (string, int64, error) {
+	runID, seq, ok := ParseEventID(msg.ID.String())
+	if !ok {
+		return "", 0, fmt.Errorf("invalid event id '%s'", msg.ID)
+	}
+	return runID, seq, nil
+}
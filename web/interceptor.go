@@ -7,6 +7,18 @@ import (
 	"github.com/caiflower/common-tools/web/interceptor"
 )
 
+// adminActions 只有管理员可以执行的操作，智能体定义决定人设、工具和需要确认的工具，与提示词模板一样只能由管理员修改
+var adminActions = map[string]struct{}{
+	"CreateAgent":            {},
+	"UpdateAgent":            {},
+	"DeleteAgent":            {},
+	"CreatePromptTemplate":   {},
+	"RollbackPromptTemplate": {},
+	"CreateExperiment":       {},
+	"StopExperiment":         {},
+	"ExportFeedback":         {},
+}
+
 type userInterceptor struct {
 }
 
@@ -22,6 +34,10 @@ func (i *userInterceptor) Before(ctx *web.Context) e.ApiError {
 		if header["X-User-Id"] == nil {
 			return e.NewApiError(constants.NotLoginError, "not login", nil)
 		}
+
+		if _, ok := adminActions[ctx.GetAction()]; ok && !constants.IsAdmin(header.Get("X-User-Id")) {
+			return e.NewApiError(constants.ForbiddenError, "permission denied", nil)
+		}
 	}
 
 	return nil
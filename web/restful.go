@@ -6,7 +6,34 @@ import (
 	. "github.com/caiflower/common-tools/web/v1"
 )
 
-func register() {
-	Register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.healthController").Path("/healthz").Action("DescribeHealth"))
-	Register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat").Action("Chat"))
+func register(r *router) {
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.healthController").Path("/healthz").Action("DescribeHealth"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.agentController").Path("/chat/resume").Action("ResumeChat"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.chatCompletionController").Path("/chat/completions").Action("CreateChatCompletion"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.agentController").Path("/chat/{targetRequestID}/cancel").Action("CancelChat"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat/ws").Action("ChatWs"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat/{targetRequestID}/events").Action("ChatEvents"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat").Action("Chat"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.agentController").Path("/chat").Action("PostChat"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.fileController").Path("/files").Action("UploadFile"))
+
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.agentDefinitionController").Path("/agents").Action("CreateAgent"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentDefinitionController").Path("/agents/{agentID}").Action("DescribeAgent"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentDefinitionController").Path("/agents").Action("ListAgents"))
+	r.register(NewRestFul().Method(http.MethodPut).Version("v1").Controller("v1.agentDefinitionController").Path("/agents/{agentID}").Action("UpdateAgent"))
+	r.register(NewRestFul().Method(http.MethodDelete).Version("v1").Controller("v1.agentDefinitionController").Path("/agents/{agentID}").Action("DeleteAgent"))
+
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.promptTemplateController").Path("/prompts/{name}/versions").Action("CreatePromptTemplate"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.promptTemplateController").Path("/prompts/{name}/versions").Action("ListPromptTemplateVersions"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.promptTemplateController").Path("/prompts/{name}").Action("DescribePromptTemplate"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.promptTemplateController").Path("/prompts/{name}/rollback").Action("RollbackPromptTemplate"))
+
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.experimentController").Path("/experiments/{experimentID}/stop").Action("StopExperiment"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.experimentController").Path("/experiments").Action("CreateExperiment"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.experimentController").Path("/experiments/{experimentID}/report").Action("DescribeExperimentReport"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.experimentController").Path("/experiments/{experimentID}").Action("DescribeExperiment"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.experimentController").Path("/experiments").Action("ListExperiments"))
+
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.feedbackController").Path("/messages/{messageID}/feedback").Action("SubmitFeedback"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.feedbackController").Path("/feedback/export").Action("ExportFeedback"))
 }
@@ -0,0 +1,47 @@
+package web
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	. "github.com/caiflower/common-tools/web/v1"
+)
+
+// pathParamPattern 框架把路径参数替换为的正则
+const pathParamPattern = "/[a-zA-Z0-9_-]+"
+
+// router 框架把路径参数之后的内容全部绑定到最后一个参数，例如/v1/chat/abc/cancel的targetRequestID为abc/cancel，
+// 请求路径以/结尾时才能正确绑定。路径参数之后还有固定路径的路由，分发前为请求路径补上/
+type router struct {
+	server  *HttpServer
+	slashed []*regexp.Regexp
+}
+
+func newRouter(server *HttpServer) *router {
+	r := &router{server: server}
+	server.SetBeforeDispatchCallBack(r.beforeDispatch)
+	return r
+}
+
+func (r *router) register(controller *RestfulController) {
+	r.server.Register(controller)
+
+	path := controller.GetPath()
+	if strings.Contains(path, pathParamPattern) && !strings.HasSuffix(path, pathParamPattern+"/?$") {
+		r.slashed = append(r.slashed, regexp.MustCompile("^/"+controller.GetVersion()+path))
+	}
+}
+
+func (r *router) beforeDispatch(_ http.ResponseWriter, req *http.Request) bool {
+	if strings.HasSuffix(req.URL.Path, "/") {
+		return false
+	}
+	for _, reg := range r.slashed {
+		if reg.MatchString(req.URL.Path) {
+			req.URL.Path += "/"
+			break
+		}
+	}
+	return false
+}
@@ -8,7 +8,7 @@ func StartUp() {
 	DefaultHttpServer.AddInterceptor(NewUserInterceptor(), 1)
 
 	// register
-	register()
+	register(newRouter(DefaultHttpServer))
 
 	DefaultHttpServer.StartUp()
 }
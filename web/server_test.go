@@ -2,24 +2,44 @@ package web
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/caiflower/ai-agent/constants"
 	"github.com/caiflower/ai-agent/controller/v1"
+	mockdao "github.com/caiflower/ai-agent/internal/mock/dao"
 	mockchatmodel "github.com/caiflower/ai-agent/internal/mock/model"
+	apiv1 "github.com/caiflower/ai-agent/model/api/v1"
+	modelbean "github.com/caiflower/ai-agent/model/bean"
 	"github.com/caiflower/ai-agent/service/agent"
+	"github.com/caiflower/ai-agent/service/checkpoint"
+	"github.com/caiflower/ai-agent/service/definition"
+	"github.com/caiflower/ai-agent/service/experiment"
+	"github.com/caiflower/ai-agent/service/file"
+	"github.com/caiflower/ai-agent/service/flow"
+	"github.com/caiflower/ai-agent/service/knowledge"
 	chatmodel "github.com/caiflower/ai-agent/service/model"
+	"github.com/caiflower/ai-agent/service/policy"
+	prompttpl "github.com/caiflower/ai-agent/service/prompt"
+	"github.com/caiflower/ai-agent/service/toolkit"
 	"github.com/caiflower/ai-agent/service/xsse"
 	"github.com/caiflower/common-tools/pkg/bean"
 	xhttp "github.com/caiflower/common-tools/pkg/http"
 	"github.com/caiflower/common-tools/pkg/logger"
 	"github.com/caiflower/common-tools/web/e"
 	. "github.com/caiflower/common-tools/web/v1"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/tmaxmax/go-sse"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/net/websocket"
 )
 
 type CommonResponse struct {
@@ -30,6 +50,11 @@ type CommonResponse struct {
 
 func TestChat(t *testing.T) {
 	ctl := gomock.NewController(t)
+	constants.Prop.Checkpoint.TTL = time.Minute
+	constants.Prop.SSE.ReplayWindow = time.Minute
+	constants.Prop.SSE.WriteTimeout = 5 * time.Second
+	constants.Prop.SSE.Heartbeat = 50 * time.Millisecond
+	constants.Prop.SSE.MaxIdle = time.Minute
 
 	mockServer := NewHttpServer(Config{
 		Name: "mockSever",
@@ -37,22 +62,95 @@ func TestChat(t *testing.T) {
 	})
 	factory := mockchatmodel.NewMockFactory(ctl)
 	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, &chatmodel.Config{}).Return(&chatmodel.MockChatModel{}, nil).AnyTimes()
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Cond(func(cfg any) bool {
+		return cfg.(*chatmodel.Config).ResponseSchema != nil
+	})).Return(&jsonChatModel{}, nil).AnyTimes()
+	blocking := &blockingChatModel{cancelled: make(chan struct{}, 1)}
+	factory.EXPECT().CreateChatModel(chatmodel.ProtocolMock, gomock.Cond(func(cfg any) bool {
+		return cfg.(*chatmodel.Config).Model == blockingModel
+	})).Return(blocking, nil).AnyTimes()
+	profiles := constants.Prop.ModelProfiles
+	constants.Prop.ModelProfiles = append(slices.Clip(profiles), constants.ModelProfileConfig{Name: blockingModel, Protocol: string(chatmodel.ProtocolMock), Model: blockingModel})
+	defer func() { constants.Prop.ModelProfiles = profiles }()
 
 	bean.AddBean(xsse.NewSSEProvider())
+	bean.AddBean(xsse.NewMemoryStore())
 	bean.AddBean(agent.NewAgentRuntime())
 	bean.AddBean(agent.NewSingleAgent())
 	bean.AddBean(factory)
+	definitionDao := mockdao.NewMockAgentDefinitionDao(ctl)
+	definitionDao.EXPECT().GetByAgentID("agent-unknown").Return(nil, nil).AnyTimes()
+	bean.AddBean(definitionDao)
+	bean.AddBean(toolkit.NewRegistry())
+	bean.AddBean(knowledge.NewRegistry())
+	bean.AddBean(flow.NewRegistry())
+	bean.AddBean(flow.NewManager())
+	bean.AddBean(policy.NewEngine())
+	bean.AddBean(mockdao.NewMockToolAuditLogDao(ctl))
+	bean.AddBean(definition.NewManager())
+	promptTemplateDao := mockdao.NewMockPromptTemplateDao(ctl)
+	promptTemplateDao.EXPECT().GetActive(gomock.Any()).Return(nil, nil).AnyTimes()
+	bean.AddBean(promptTemplateDao)
+	bean.AddBean(prompttpl.NewManager())
+	agentRunDao := mockdao.NewMockAgentRunDao(ctl)
+	agentRunDao.EXPECT().Insert(gomock.Any()).Return(nil).AnyTimes()
+	agentRunDao.EXPECT().UpdateResult(gomock.Any()).Return(int64(1), nil).AnyTimes()
+	agentRunDao.EXPECT().GetByRunID(gomock.Any()).DoAndReturn(func(runID string) (*modelbean.AgentRun, error) {
+		return &modelbean.AgentRun{RunID: runID, User: "test-user", State: modelbean.RunStateFinished}, nil
+	}).AnyTimes()
+	bean.AddBean(agentRunDao)
+	experimentDao := mockdao.NewMockExperimentDao(ctl)
+	experimentDao.EXPECT().ListRunning().Return(nil, nil).AnyTimes()
+	bean.AddBean(experimentDao)
+	bean.AddBean(experiment.NewManager())
+	bean.AddBean(checkpoint.NewMemoryStore())
+	fileManager, err := file.NewLocalManager(t.TempDir())
+	assert.Nil(t, err)
+	bean.AddBean(fileManager)
 	mockServer.AddController(v1.NewAgentController())
+	mockServer.AddController(v1.NewFileController())
+	mockServer.AddController(v1.NewChatCompletionController())
+	mockServer.AddController(v1.NewPromptTemplateController())
+	mockServer.AddController(v1.NewAgentDefinitionController())
 	bean.Ioc()
 
 	mockServer.AddInterceptor(NewUserInterceptor(), 0)
-	mockServer.Register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat").Action("Chat"))
+	r := newRouter(mockServer)
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.chatCompletionController").Path("/chat/completions").Action("CreateChatCompletion"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat/ws").Action("ChatWs"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.agentController").Path("/chat/{targetRequestID}/cancel").Action("CancelChat"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat/{targetRequestID}/events").Action("ChatEvents"))
+	r.register(NewRestFul().Method(http.MethodGet).Version("v1").Controller("v1.agentController").Path("/chat").Action("Chat"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.agentController").Path("/chat").Action("PostChat"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.fileController").Path("/files").Action("UploadFile"))
+	r.register(NewRestFul().Method(http.MethodPost).Version("v1").Controller("v1.promptTemplateController").Path("/prompts/{name}/rollback").Action("RollbackPromptTemplate"))
+	r.register(NewRestFul().Method(http.MethodDelete).Version("v1").Controller("v1.agentDefinitionController").Path("/agents/{agentID}").Action("DeleteAgent"))
 	mockServer.StartUp()
 	time.Sleep(1 * time.Second)
 	defer mockServer.Close()
 
 	// v1.agentController.Chat /v1/chat
 	chatV1(t)
+	// v1.agentController.PostChat POST /v1/chat
+	postChatV1(t)
+	// v1.agentController.PostChat POST /v1/chat，指定回答的JSON Schema
+	structuredChatV1(t)
+	// v1.agentController.ChatWs /v1/chat/ws
+	chatWsV1(t)
+	// v1.agentController.CancelChat /v1/chat/{targetRequestID}/cancel
+	cancelChatV1(t, blocking)
+	// 客户端断开后没有重连时取消运行
+	orphanChatV1(t, blocking)
+	// v1.agentController.ChatEvents /v1/chat/{targetRequestID}/events
+	chatEventsV1(t)
+	// v1.chatCompletionController.CreateChatCompletion /v1/chat/completions
+	chatCompletionsV1(t)
+	// v1.fileController.UploadFile /v1/files
+	uploadFileV1(t)
+	// v1.promptTemplateController.RollbackPromptTemplate /v1/prompts/{name}/rollback
+	rollbackPromptTemplateV1(t)
+	// v1.agentDefinitionController.DeleteAgent DELETE /v1/agents/{agentID}
+	deleteAgentV1(t)
 }
 
 func chatV1(t *testing.T) {
@@ -117,6 +215,20 @@ func chatV1(t *testing.T) {
 			},
 		})
 
+	mockCompare(t,
+		"Agent not found",
+		c, http.MethodGet,
+		"http://127.0.0.1:8081/v1/chat?input=what%20is%20weather%20in%20beijing?&chatProtocol=mock&agentId=agent-unknown",
+		headers,
+		nil,
+		&CommonResponse{
+			Error: &e.Error{
+				Code:    e.NotFound.Code,
+				Type:    e.NotFound.Type,
+				Message: "agent not found",
+			},
+		})
+
 	req, _ := http.NewRequestWithContext(context.Background(),
 		http.MethodGet,
 		"http://127.0.0.1:8081/v1/chat?input=what%20is%20weather%20in%20beijing?&chatProtocol=mock",
@@ -129,26 +241,681 @@ func chatV1(t *testing.T) {
 		return
 	}
 
-	message := ""
+	var (
+		message string
+		lastSeq int64
+		finish  *apiv1.ChatEventData
+	)
 breakPoint:
 	for ev, err := range sse.Read(res.Body, nil) {
 		if err != nil {
 			assert.Fail(t, err.Error(), "unknown error")
 			break
 		}
+		data := chatEventData(t, ev.Data)
+		assert.Equal(t, apiv1.ChatEventVersion, data.Version)
+		assert.Greater(t, data.Seq, lastSeq)
+		lastSeq = data.Seq
 		switch ev.Type {
-		case v1.EventTypeOfChatModelAnswer:
-			message += ev.Data
-		case v1.EventTypeOfChatError:
+		case apiv1.EventTypeOfChatModelAnswer:
+			message += data.Delta
+		case apiv1.EventTypeOfChatError:
 			logger.Error("chat failed. Error: %v", ev.Data)
-		case v1.EventTypeOfChatFinish:
+		case apiv1.EventTypeOfChatFinish:
 			logger.Info("chat finished")
+			finish = data
 			break breakPoint
 		}
 	}
 
 	assert.NotEmpty(t, res.Header.Get("X-Request-Id"), "request id found")
 	assert.Equal(t, "the weather is good", message)
+	if assert.NotNil(t, finish) {
+		assert.Equal(t, apiv1.FinishReasonOfStop, finish.FinishReason)
+		assert.Equal(t, "default", finish.Agent)
+		assert.NotNil(t, finish.Usage)
+	}
+}
+
+// chatEventData 事件的data为版本化的JSON
+func chatEventData(t *testing.T, data string) *apiv1.ChatEventData {
+	res := &apiv1.ChatEventData{}
+	assert.Nil(t, json.Unmarshal([]byte(data), res))
+	return res
+}
+
+func postChatV1(t *testing.T) {
+	c := xhttp.NewHttpClient(xhttp.Config{})
+
+	headers := make(map[string]string)
+	headers["X-User-Id"] = "test-user"
+	invalid := func(name string, body map[string]interface{}, message string) {
+		mockCompare(t, name, c, http.MethodPost, "http://127.0.0.1:8081/v1/chat", headers, body, &CommonResponse{
+			Error: &e.Error{
+				Code:    e.InvalidArgument.Code,
+				Type:    e.InvalidArgument.Type,
+				Message: message,
+			},
+		})
+	}
+	invalid("Missing messages", map[string]interface{}{"chatProtocol": "mock"}, "PostChatRequest.Messages is missing")
+	invalid("Unknown role", map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     []map[string]string{{"role": "tool", "content": "sunny"}},
+	}, "PostChatRequest.Messages.Role is not in [system user assistant]")
+	invalid("Last message not from user", map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     []map[string]string{{"role": "user", "content": "hi"}, {"role": "assistant", "content": "hello"}},
+	}, "PostChatRequest.Messages must end with a user message")
+	invalid("Temperature out of range", map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     []map[string]string{{"role": "user", "content": "hi"}},
+		"options":      map[string]interface{}{"temperature": 3},
+	}, "PostChatRequest.Options.Temperature is not between 0 and 2")
+	invalid("ToolChoice not in list", map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     []map[string]string{{"role": "user", "content": "hi"}},
+		"options":      map[string]interface{}{"toolChoice": "any"},
+	}, "PostChatRequest.Options.ToolChoice is not in [auto none required]")
+	invalid("Model profile not found", map[string]interface{}{
+		"modelProfile": "unknown",
+		"messages":     []map[string]string{{"role": "user", "content": "hi"}},
+	}, "PostChatRequest.ModelProfile 'unknown' is not found")
+
+	body := `{"conversationId":"conv-1","chatProtocol":"mock","messages":[` +
+		`{"role":"user","content":"what is weather in shanghai?"},{"role":"assistant","content":"sunny"},` +
+		`{"role":"user","content":"what is weather in beijing?"}],"options":{"temperature":0.2,"maxTokens":256,"toolChoice":"auto"}}`
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://127.0.0.1:8081/v1/chat", strings.NewReader(body))
+	req.Header.Set("X-User-Id", "test-user")
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		assert.Fail(t, err.Error())
+		return
+	}
+
+	message := ""
+breakPoint:
+	for ev, err := range sse.Read(res.Body, nil) {
+		if err != nil {
+			assert.Fail(t, err.Error(), "unknown error")
+			break
+		}
+		switch ev.Type {
+		case apiv1.EventTypeOfChatModelAnswer:
+			message += chatEventData(t, ev.Data).Delta
+		case apiv1.EventTypeOfChatError:
+			assert.Fail(t, ev.Data, "chat failed")
+		case apiv1.EventTypeOfChatFinish:
+			break breakPoint
+		}
+	}
+	assert.Equal(t, "the weather is good", message)
+
+	// stream为false时返回完整结果
+	result := &struct {
+		Data  *apiv1.ChatResult
+		Error *e.Error
+	}{}
+	err = c.Do(http.MethodPost, "", "http://127.0.0.1:8081/v1/chat", xhttp.ContentTypeJson, map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     []map[string]string{{"role": "user", "content": "what is weather in beijing?"}},
+		"stream":       false,
+	}, nil, &xhttp.Response{Data: result}, headers)
+	assert.Nil(t, err)
+	assert.Nil(t, result.Error)
+	if assert.NotNil(t, result.Data) {
+		assert.NotEmpty(t, result.Data.MessageID)
+		assert.Equal(t, "the weather is good", result.Data.Answer)
+		assert.NotNil(t, result.Data.Usage)
+	}
+}
+
+// jsonChatModel 回答为JSON
+type jsonChatModel struct {
+	chatmodel.MockChatModel
+}
+
+const jsonAnswer = `{"city": "北京", "temperature": 25}`
+
+func (m *jsonChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage(jsonAnswer, nil), nil
+}
+
+func (m *jsonChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage(jsonAnswer, nil)}), nil
+}
+
+func structuredChatV1(t *testing.T) {
+	maxRepairs := constants.Prop.Agent.MaxRepairs
+	constants.Prop.Agent.MaxRepairs = 1
+	defer func() { constants.Prop.Agent.MaxRepairs = maxRepairs }()
+
+	c := xhttp.NewHttpClient(xhttp.Config{})
+	headers := map[string]string{"X-User-Id": "test-user"}
+	messages := []map[string]string{{"role": "user", "content": "what is weather in beijing?"}}
+	weatherSchema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"city": map[string]string{"type": "string"}, "temperature": map[string]string{"type": "number"}},
+		"required":   []string{"city", "temperature"},
+	}
+
+	mockCompare(t, "Invalid response schema", c, http.MethodPost, "http://127.0.0.1:8081/v1/chat", headers, map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     messages,
+		"options":      map[string]interface{}{"responseSchema": map[string]string{"type": "foo"}},
+	}, &CommonResponse{
+		Error: &e.Error{
+			Code:    e.InvalidArgument.Code,
+			Type:    e.InvalidArgument.Type,
+			Message: `PostChatRequest.Options.ResponseSchema is invalid, unsupported 'type' value "foo"`,
+		},
+	})
+
+	// ReAct的模型不约束输出格式，回答不符合格式时修正，非流式返回修正后的JSON
+	result := &struct {
+		Data  *apiv1.ChatResult
+		Error *e.Error
+	}{}
+	err := c.Do(http.MethodPost, "", "http://127.0.0.1:8081/v1/chat", xhttp.ContentTypeJson, map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     messages,
+		"options":      map[string]interface{}{"responseSchema": weatherSchema},
+		"stream":       false,
+	}, nil, &xhttp.Response{Data: result}, headers)
+	assert.Nil(t, err)
+	assert.Nil(t, result.Error)
+	if assert.NotNil(t, result.Data) {
+		assert.Equal(t, "the weather is good", result.Data.Answer)
+		assert.JSONEq(t, jsonAnswer, string(result.Data.Structured))
+	}
+
+	// 流式在chat.finish之前发送chat.structured
+	body, _ := json.Marshal(map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     messages,
+		"options":      map[string]interface{}{"responseSchema": weatherSchema},
+	})
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://127.0.0.1:8081/v1/chat", strings.NewReader(string(body)))
+	req.Header.Set("X-User-Id", "test-user")
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		assert.Fail(t, err.Error())
+		return
+	}
+	var structured json.RawMessage
+breakPoint:
+	for ev, err := range sse.Read(res.Body, nil) {
+		if err != nil {
+			assert.Fail(t, err.Error(), "unknown error")
+			break
+		}
+		switch ev.Type {
+		case apiv1.EventTypeOfChatStructured:
+			structured = chatEventData(t, ev.Data).Structured
+		case apiv1.EventTypeOfChatError:
+			assert.Fail(t, ev.Data, "chat failed")
+		case apiv1.EventTypeOfChatFinish:
+			break breakPoint
+		}
+	}
+	assert.JSONEq(t, jsonAnswer, string(structured))
+
+	// 修正后仍然不符合格式时失败
+	weatherSchema["required"] = []string{"city", "weather"}
+	result = &struct {
+		Data  *apiv1.ChatResult
+		Error *e.Error
+	}{}
+	err = c.Do(http.MethodPost, "", "http://127.0.0.1:8081/v1/chat", xhttp.ContentTypeJson, map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     messages,
+		"options":      map[string]interface{}{"responseSchema": weatherSchema},
+		"stream":       false,
+	}, nil, &xhttp.Response{Data: result}, headers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, result.Error) {
+		assert.Equal(t, constants.InvalidModelOutputError.Code, result.Error.Code)
+		assert.Equal(t, constants.InvalidModelOutputError.Type, result.Error.Type)
+	}
+}
+
+// blockingModel 回答直到运行取消才结束的模型
+const blockingModel = "blocking"
+
+type blockingChatModel struct {
+	chatmodel.MockChatModel
+	cancelled chan struct{}
+}
+
+func (m *blockingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	sr, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer sw.Close()
+		<-ctx.Done()
+		sw.Send(nil, ctx.Err())
+		m.cancelled <- struct{}{}
+	}()
+	return sr, nil
+}
+
+func (m *blockingChatModel) wait(t *testing.T) {
+	select {
+	case <-m.cancelled:
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "chat is not cancelled")
+	}
+}
+
+// startBlockingChat 开始一个不会结束的流式对话
+func startBlockingChat(t *testing.T, ctx context.Context, requestID string) *http.Response {
+	body := `{"modelProfile":"blocking","messages":[{"role":"user","content":"what is weather in beijing?"}]}`
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://127.0.0.1:8081/v1/chat", strings.NewReader(body))
+	req.Header.Set("X-User-Id", "test-user")
+	req.Header.Set("X-Request-Id", requestID)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+	return res
+}
+
+func cancelChatV1(t *testing.T, blocking *blockingChatModel) {
+	c := xhttp.NewHttpClient(xhttp.Config{})
+
+	headers := make(map[string]string)
+	headers["X-User-Id"] = "test-user"
+	mockCompare(t, "Cancel unknown chat", c, http.MethodPost, "http://127.0.0.1:8081/v1/chat/unknown/cancel", headers, nil, &CommonResponse{
+		Error: &e.Error{
+			Code:    e.NotFound.Code,
+			Type:    e.NotFound.Type,
+			Message: "chat is not running",
+		},
+	})
+
+	res := startBlockingChat(t, context.Background(), "cancel-test")
+	if res == nil {
+		return
+	}
+	defer res.Body.Close()
+
+	var (
+		types  []string
+		finish *apiv1.ChatEventData
+	)
+	for ev, err := range sse.Read(res.Body, nil) {
+		if err != nil {
+			assert.Fail(t, err.Error(), "unknown error")
+			break
+		}
+		types = append(types, ev.Type)
+		if ev.Type == apiv1.EventTypeOfChatStart {
+			mockCompare(t, "Cancel other user's chat", c, http.MethodPost, "http://127.0.0.1:8081/v1/chat/cancel-test/cancel", map[string]string{"X-User-Id": "other-user"}, nil, &CommonResponse{
+				Error: &e.Error{
+					Code:    e.NotFound.Code,
+					Type:    e.NotFound.Type,
+					Message: "chat is not running",
+				},
+			})
+			mockCompare(t, "Cancel chat", c, http.MethodPost, "http://127.0.0.1:8081/v1/chat/cancel-test/cancel", headers, nil, &CommonResponse{})
+		}
+		if ev.Type == apiv1.EventTypeOfChatFinish || ev.Type == apiv1.EventTypeOfChatError {
+			finish = chatEventData(t, ev.Data)
+			break
+		}
+	}
+	blocking.wait(t)
+	assert.Equal(t, []string{apiv1.EventTypeOfChatStart, apiv1.EventTypeOfChatCancelled, apiv1.EventTypeOfChatFinish}, types)
+	if assert.NotNil(t, finish) {
+		assert.Equal(t, apiv1.FinishReasonOfCancelled, finish.FinishReason)
+	}
+}
+
+func orphanChatV1(t *testing.T, blocking *blockingChatModel) {
+	window := constants.Prop.SSE.ReplayWindow
+	constants.Prop.SSE.ReplayWindow = 200 * time.Millisecond
+	defer func() { constants.Prop.SSE.ReplayWindow = window }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res := startBlockingChat(t, ctx, "orphan-test")
+	if res == nil {
+		cancel()
+		return
+	}
+	for ev, err := range sse.Read(res.Body, nil) {
+		assert.Nil(t, err)
+		assert.Equal(t, apiv1.EventTypeOfChatStart, ev.Type)
+		break
+	}
+	// 断开连接，心跳发送失败后订阅结束，回放窗口内没有重连则取消运行
+	cancel()
+	res.Body.Close()
+	blocking.wait(t)
+}
+
+func chatEventsV1(t *testing.T) {
+	c := xhttp.NewHttpClient(xhttp.Config{})
+
+	headers := make(map[string]string)
+	headers["X-User-Id"] = "test-user"
+	mockCompare(t, "Unknown request", c, http.MethodGet, "http://127.0.0.1:8081/v1/chat/unknown/events", headers, nil, &CommonResponse{
+		Error: &e.Error{
+			Code:    e.NotFound.Code,
+			Type:    e.NotFound.Type,
+			Message: "chat events not found",
+		},
+	})
+
+	read := func(url string, lastEventID string) []sse.Event {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, url, http.NoBody)
+		req.Header.Set("X-User-Id", "test-user")
+		req.Header.Set("X-Request-Id", "chat-events-test")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if !assert.Nil(t, err) {
+			return nil
+		}
+		defer res.Body.Close()
+
+		var events []sse.Event
+		for ev, err := range sse.Read(res.Body, nil) {
+			if err != nil {
+				break
+			}
+			events = append(events, ev)
+			if ev.Type == apiv1.EventTypeOfChatFinish || ev.Type == apiv1.EventTypeOfChatError {
+				break
+			}
+		}
+		return events
+	}
+
+	streamed := read("http://127.0.0.1:8081/v1/chat?input=what%20is%20weather%20in%20beijing?&chatProtocol=mock", "")
+	if !assert.NotEmpty(t, streamed) {
+		return
+	}
+	assert.Equal(t, apiv1.EventTypeOfChatStart, streamed[0].Type)
+
+	// 运行结束后按请求ID回放全部事件，ID与推送时相同
+	replayed := read("http://127.0.0.1:8081/v1/chat/chat-events-test/events", "")
+	assert.Equal(t, streamed, replayed)
+
+	// 携带Last-Event-ID时从断开的位置继续
+	resumed := read("http://127.0.0.1:8081/v1/chat/chat-events-test/events", streamed[0].LastEventID)
+	assert.Equal(t, streamed[1:], resumed)
+}
+
+// wsServerMessage ChatWsServerMessage的Error为接口，测试中使用具体类型解析
+type wsServerMessage struct {
+	Type  string
+	ID    string
+	Data  string
+	Error *e.Error
+}
+
+func chatWsV1(t *testing.T) {
+	config, _ := websocket.NewConfig("ws://127.0.0.1:8081/v1/chat/ws", "http://127.0.0.1:8081")
+	config.Header.Set("X-User-Id", "test-user")
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		assert.Fail(t, err.Error())
+		return
+	}
+	defer conn.Close()
+
+	send := func(msg map[string]interface{}) {
+		assert.Nil(t, websocket.JSON.Send(conn, msg))
+	}
+	receive := func() *wsServerMessage {
+		msg := &wsServerMessage{}
+		_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		assert.Nil(t, websocket.JSON.Receive(conn, msg))
+		return msg
+	}
+
+	send(map[string]interface{}{"type": "ping"})
+	assert.Equal(t, apiv1.WsMessageTypeOfPong, receive().Type)
+
+	send(map[string]interface{}{"type": "hello"})
+	msg := receive()
+	assert.Equal(t, apiv1.WsMessageTypeOfError, msg.Type)
+	assert.Equal(t, "ChatWsClientMessage.Type is not in [user_message cancel approve ping]", msg.Error.Message)
+
+	send(map[string]interface{}{"type": "cancel"})
+	msg = receive()
+	assert.Equal(t, e.NotAcceptable.Code, msg.Error.Code)
+
+	send(map[string]interface{}{"type": "user_message", "chatProtocol": "mock", "messages": []map[string]string{{"role": "assistant", "content": "hi"}}})
+	msg = receive()
+	assert.Equal(t, "PostChatRequest.Messages must end with a user message", msg.Error.Message)
+
+	// 同一连接上连续对话
+	for i := 0; i < 2; i++ {
+		send(map[string]interface{}{"type": "user_message", "chatProtocol": "mock", "messages": []map[string]string{{"role": "user", "content": "what is weather in beijing?"}}})
+		msg = receive()
+		assert.Equal(t, apiv1.EventTypeOfChatStart, msg.Type)
+		assert.NotEmpty(t, msg.ID)
+
+		answer := ""
+		for msg = receive(); msg.Type != apiv1.EventTypeOfChatFinish && msg.Type != apiv1.EventTypeOfChatError; msg = receive() {
+			if msg.Type == apiv1.EventTypeOfChatModelAnswer {
+				answer += chatEventData(t, msg.Data).Delta
+			}
+		}
+		assert.Equal(t, apiv1.EventTypeOfChatFinish, msg.Type)
+		assert.Equal(t, "the weather is good", answer)
+	}
+}
+
+func chatCompletionsV1(t *testing.T) {
+	chatProtocol := constants.Prop.OpenAI.ChatProtocol
+	constants.Prop.OpenAI.ChatProtocol = string(chatmodel.ProtocolMock)
+	defer func() { constants.Prop.OpenAI.ChatProtocol = chatProtocol }()
+
+	post := func(body string) *http.Response {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://127.0.0.1:8081/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("X-User-Id", "test-user")
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := post(`{"model":"default","messages":[{"role":"user","content":[{"type":"text","text":"what is weather in beijing?"}]}],"temperature":0.2}`)
+	completion := &apiv1.ChatCompletion{}
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(completion))
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "chat.completion", completion.Object)
+	assert.Equal(t, "default", completion.Model)
+	if assert.Len(t, completion.Choices, 1) {
+		assert.Equal(t, "the weather is good", completion.Choices[0].Message.Content)
+		assert.Equal(t, "stop", *completion.Choices[0].FinishReason)
+	}
+	assert.NotNil(t, completion.Usage)
+
+	res = post(`{"model":"default","messages":[{"role":"user","content":"what is weather in beijing?"}],"stream":true,"stream_options":{"include_usage":true}}`)
+	var (
+		content string
+		chunks  []*apiv1.ChatCompletion
+		done    bool
+	)
+	for ev, err := range sse.Read(res.Body, nil) {
+		if err != nil {
+			break
+		}
+		if ev.Data == "[DONE]" {
+			done = true
+			break
+		}
+		chunk := &apiv1.ChatCompletion{}
+		assert.Nil(t, json.Unmarshal([]byte(ev.Data), chunk))
+		assert.Equal(t, "chat.completion.chunk", chunk.Object)
+		chunks = append(chunks, chunk)
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != nil {
+			content += chunk.Choices[0].Delta.Content.(string)
+		}
+	}
+	assert.True(t, done)
+	assert.Equal(t, "the weather is good", content)
+	if assert.GreaterOrEqual(t, len(chunks), 2) {
+		assert.Equal(t, "stop", *chunks[len(chunks)-2].Choices[0].FinishReason)
+		assert.Empty(t, chunks[len(chunks)-1].Choices)
+		assert.NotNil(t, chunks[len(chunks)-1].Usage)
+	}
+
+	res = post(`{"model":"default","messages":[{"role":"assistant","content":"hello"}]}`)
+	errRes := &apiv1.ChatCompletionError{}
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(errRes))
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "the last message must be a user or tool message", errRes.Error.Message)
+
+	res = post(`{"model":"agent-unknown","messages":[{"role":"user","content":"hi"}]}`)
+	errRes = &apiv1.ChatCompletionError{}
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(errRes))
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "The model 'agent-unknown' does not exist", errRes.Error.Message)
+}
+
+func uploadFileV1(t *testing.T) {
+	maxSize := constants.Prop.File.MaxSize
+	constants.Prop.File.MaxSize = 1024
+	defer func() { constants.Prop.File.MaxSize = maxSize }()
+
+	c := xhttp.NewHttpClient(xhttp.Config{})
+
+	headers := make(map[string]string)
+	headers["X-User-Id"] = "test-user"
+	mockCompare(t, "Invalid content", c, http.MethodPost, "http://127.0.0.1:8081/v1/files", headers,
+		map[string]interface{}{"name": "a.txt", "content": "not base64"}, &CommonResponse{
+			Error: &e.Error{
+				Code:    e.InvalidArgument.Code,
+				Type:    e.InvalidArgument.Type,
+				Message: "UploadFileRequest.Content is not valid base64",
+			},
+		})
+	mockCompare(t, "Too large", c, http.MethodPost, "http://127.0.0.1:8081/v1/files", headers,
+		map[string]interface{}{"name": "a.txt", "content": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 1025)))}, &CommonResponse{
+			Error: &e.Error{
+				Code:    constants.PayloadTooLargeError.Code,
+				Type:    constants.PayloadTooLargeError.Type,
+				Message: "file is too large, max size is 1024 bytes",
+			},
+		})
+	// 编码后的长度超过限制时不解码
+	mockCompare(t, "Too large before decoding", c, http.MethodPost, "http://127.0.0.1:8081/v1/files", headers,
+		map[string]interface{}{"name": "a.txt", "content": strings.Repeat("!", 1372)}, &CommonResponse{
+			Error: &e.Error{
+				Code:    constants.PayloadTooLargeError.Code,
+				Type:    constants.PayloadTooLargeError.Type,
+				Message: "file is too large, max size is 1024 bytes",
+			},
+		})
+	mockCompare(t, "Unknown file", c, http.MethodPost, "http://127.0.0.1:8081/v1/chat", headers,
+		map[string]interface{}{
+			"chatProtocol": "mock",
+			"messages":     []map[string]string{{"role": "user", "content": "summarize the file"}},
+			"files":        []string{"unknown"},
+		}, &CommonResponse{
+			Error: &e.Error{
+				Code:    e.InvalidArgument.Code,
+				Type:    e.InvalidArgument.Type,
+				Message: "PostChatRequest.Files 'unknown' is not found",
+			},
+		})
+
+	uploaded := &struct {
+		Data  *apiv1.File
+		Error *e.Error
+	}{}
+	err := c.Do(http.MethodPost, "", "http://127.0.0.1:8081/v1/files", xhttp.ContentTypeJson, map[string]interface{}{
+		"name":    "weather.md",
+		"content": base64.StdEncoding.EncodeToString([]byte("beijing is sunny")),
+	}, nil, &xhttp.Response{Data: uploaded}, headers)
+	assert.Nil(t, err)
+	assert.Nil(t, uploaded.Error)
+	if !assert.NotNil(t, uploaded.Data) {
+		return
+	}
+	assert.Equal(t, "text/plain", uploaded.Data.ContentType)
+	assert.Equal(t, int64(16), uploaded.Data.Size)
+
+	// 其他用户不能引用
+	mockCompare(t, "File of other user", c, http.MethodPost, "http://127.0.0.1:8081/v1/chat", map[string]string{"X-User-Id": "other-user"},
+		map[string]interface{}{
+			"chatProtocol": "mock",
+			"messages":     []map[string]string{{"role": "user", "content": "summarize the file"}},
+			"files":        []string{uploaded.Data.FileID},
+		}, &CommonResponse{
+			Error: &e.Error{
+				Code:    e.InvalidArgument.Code,
+				Type:    e.InvalidArgument.Type,
+				Message: fmt.Sprintf("PostChatRequest.Files '%s' is not found", uploaded.Data.FileID),
+			},
+		})
+
+	result := &struct {
+		Data  *apiv1.ChatResult
+		Error *e.Error
+	}{}
+	err = c.Do(http.MethodPost, "", "http://127.0.0.1:8081/v1/chat", xhttp.ContentTypeJson, map[string]interface{}{
+		"chatProtocol": "mock",
+		"messages":     []map[string]string{{"role": "user", "content": "summarize the file"}},
+		"files":        []string{uploaded.Data.FileID},
+		"stream":       false,
+	}, nil, &xhttp.Response{Data: result}, headers)
+	assert.Nil(t, err)
+	assert.Nil(t, result.Error)
+	if assert.NotNil(t, result.Data) {
+		assert.Equal(t, "the weather is good", result.Data.Answer)
+	}
+}
+
+func rollbackPromptTemplateV1(t *testing.T) {
+	c := xhttp.NewHttpClient(xhttp.Config{})
+
+	headers := make(map[string]string)
+	headers["X-User-Id"] = "test-user"
+	mockCompare(t,
+		"Rollback without permission",
+		c, http.MethodPost,
+		"http://127.0.0.1:8081/v1/prompts/react_system/rollback",
+		headers,
+		map[string]interface{}{"version": 1},
+		&CommonResponse{
+			Error: &e.Error{
+				Code:    constants.ForbiddenError.Code,
+				Type:    constants.ForbiddenError.Type,
+				Message: "permission denied",
+			},
+		})
+}
+
+func deleteAgentV1(t *testing.T) {
+	c := xhttp.NewHttpClient(xhttp.Config{})
+
+	headers := make(map[string]string)
+	headers["X-User-Id"] = "test-user"
+	mockCompare(t,
+		"Delete agent without permission",
+		c, http.MethodDelete,
+		"http://127.0.0.1:8081/v1/agents/agent-weather",
+		headers,
+		nil,
+		&CommonResponse{
+			Error: &e.Error{
+				Code:    constants.ForbiddenError.Code,
+				Type:    constants.ForbiddenError.Type,
+				Message: "permission denied",
+			},
+		})
 }
 
 func mockCompare(t *testing.T, testCaseName string, c xhttp.HttpClient, method string, url string, headers map[string]string, body interface{}, want *CommonResponse) {